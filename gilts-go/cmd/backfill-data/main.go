@@ -0,0 +1,246 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+	"benritz/gilts/internal/types"
+	"time"
+
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	_ "github.com/pbnjay/grate/xls"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+const dateFormat = "2006-01-02"
+
+// businessDays returns the weekdays between from and to inclusive.
+func businessDays(from time.Time, to time.Time) []time.Time {
+	var days []time.Time
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// rateLimiter spaces requests at least delay apart across all workers.
+type rateLimiter struct {
+	mu    sync.Mutex
+	delay time.Duration
+	next  time.Time
+}
+
+// wait blocks until the next request slot, reserving it for the caller.
+func (r *rateLimiter) wait(ctx context.Context) error {
+	r.mu.Lock()
+	now := time.Now()
+	slot := r.next
+	if slot.Before(now) {
+		slot = now
+	}
+	r.next = slot.Add(r.delay)
+	r.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(slot)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type status int
+
+const (
+	statusStored status = iota
+	statusPresent
+	statusUnavailable
+	statusFailed
+)
+
+// result is the outcome of backfilling a single date.
+type result struct {
+	date    time.Time
+	status  status
+	outPath string
+	err     error
+}
+
+func (r result) String() string {
+	day := r.date.Format(dateFormat)
+	switch r.status {
+	case statusStored:
+		return fmt.Sprintf("%s: stored to %s", day, r.outPath)
+	case statusPresent:
+		return fmt.Sprintf("%s: already present", day)
+	case statusUnavailable:
+		return fmt.Sprintf("%s: data unavailable: %v", day, r.err)
+	default:
+		return fmt.Sprintf("%s: failed: %v", day, r.err)
+	}
+}
+
+// backfill collects and stores the bonds for a single date unless they are already stored.
+func backfill(
+	ctx context.Context,
+	collector collect.Collector,
+	store collect.Store,
+	limiter *rateLimiter,
+	date time.Time,
+) result {
+	exists, err := store.Exists(ctx, collector.Source(), date)
+	if err != nil {
+		return result{date: date, status: statusFailed, err: fmt.Errorf("failed to check existing data: %w", err)}
+	}
+	if exists {
+		return result{date: date, status: statusPresent}
+	}
+
+	if err := limiter.wait(ctx); err != nil {
+		return result{date: date, status: statusFailed, err: err}
+	}
+
+	collected, err := collector.Collect(ctx, date)
+	if err != nil {
+		if errors.Is(err, types.ErrDataUnavailable) {
+			return result{date: date, status: statusUnavailable, err: err}
+		}
+		return result{date: date, status: statusFailed, err: fmt.Errorf("failed to collect data: %w", err)}
+	}
+
+	outPath, err := store.Store(ctx, collected)
+	if err != nil {
+		return result{date: date, status: statusFailed, err: fmt.Errorf("failed to store data: %w", err)}
+	}
+
+	return result{date: date, status: statusStored, outPath: outPath}
+}
+
+func main() {
+	ctx := context.Background()
+
+	fromFlag := flag.String("from", "", "the first date to collect (YYYY-MM-DD)")
+	toFlag := flag.String("to", "", "the last date to collect (YYYY-MM-DD), defaults to the from date")
+	source := flag.String("source", "dmo", "the data source, dmo or dividenddata")
+	delay := flag.Duration("delay", 5*time.Second, "the minimum delay between collection requests")
+	concurrency := flag.Int("concurrency", 1, "the number of dates to collect in parallel")
+	profile := flag.String("profile", "default", "the AWS profile to use")
+	layoutFlag := flag.String("layout", "date", "the storage layout, date (YYYY/MM/DD/source) or hive (source=/year=/month=/day=)")
+	sseKMSKeyID := flag.String("ssekmskeyid", "", "the KMS key ID for S3 server-side encryption, defaults to the bucket's encryption")
+	storageClass := flag.String("storageclass", "", "the S3 storage class, defaults to the bucket's storage class")
+	helpFlag := flag.Bool("help", false, "print this help message")
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) != 1 || *fromFlag == "" || *helpFlag {
+		fmt.Printf("Usage: %s -from YYYY-MM-DD [-to YYYY-MM-DD] <flags> <destination>\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	from, err := time.Parse(dateFormat, *fromFlag)
+	if err != nil {
+		fmt.Printf("Error: invalid from date: %s\n", *fromFlag)
+		os.Exit(1)
+	}
+
+	to := from
+	if *toFlag != "" {
+		to, err = time.Parse(dateFormat, *toFlag)
+		if err != nil {
+			fmt.Printf("Error: invalid to date: %s\n", *toFlag)
+			os.Exit(1)
+		}
+	}
+
+	if to.Before(from) {
+		fmt.Println("Error: to date must not be before the from date")
+		os.Exit(1)
+	}
+
+	if *concurrency < 1 {
+		fmt.Println("Error: concurrency must be greater than 0")
+		os.Exit(1)
+	}
+
+	layout, err := collect.ParseKeyLayout(*layoutFlag)
+	if err != nil {
+		fmt.Printf("Invalid layout: %s\n", *layoutFlag)
+		os.Exit(1)
+	}
+
+	collector, err := collect.CollectorByName(*source)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := collect.NewStore(ctx, args[0], *profile, layout, collect.StoreOptions{
+		SSEKMSKeyID:  *sseKMSKeyID,
+		StorageClass: *storageClass,
+	})
+	if err != nil {
+		fmt.Printf("Failed to create store: %v\n", err)
+		os.Exit(1)
+	}
+
+	dates := businessDays(from, to)
+	results := make([]result, len(dates))
+	limiter := &rateLimiter{delay: *delay}
+	sem := semaphore.NewWeighted(int64(*concurrency))
+
+	// failures are recorded in the results rather than returned, so a failing
+	// date doesn't cancel the remaining dates
+	var g errgroup.Group
+	for i, date := range dates {
+		if err := sem.Acquire(ctx, 1); err != nil {
+			results[i] = result{date: date, status: statusFailed, err: err}
+			continue
+		}
+		g.Go(func() error {
+			defer sem.Release(1)
+			results[i] = backfill(ctx, collector, store, limiter, date)
+			fmt.Println(results[i])
+			return nil
+		})
+	}
+	g.Wait()
+
+	var stored, present, unavailable, failed []string
+	for _, r := range results {
+		day := r.date.Format(dateFormat)
+		switch r.status {
+		case statusStored:
+			stored = append(stored, day)
+		case statusPresent:
+			present = append(present, day)
+		case statusUnavailable:
+			unavailable = append(unavailable, day)
+		default:
+			failed = append(failed, day)
+		}
+	}
+
+	fmt.Printf("Backfill Report:\n")
+	for _, r := range results {
+		fmt.Printf("\t%s\n", r)
+	}
+
+	fmt.Printf("Backfill Summary:\n")
+	fmt.Printf("\tStored: %d\n", len(stored))
+	fmt.Printf("\tAlready Present: %d\n", len(present))
+	fmt.Printf("\tSkipped (Unavailable): %d %v\n", len(unavailable), unavailable)
+	fmt.Printf("\tFailed: %d %v\n", len(failed), failed)
+
+	if len(failed) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"benritz/gilts/internal/analytics"
+	"benritz/gilts/internal/collect"
+
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	ctx := context.Background()
+
+	income := flag.Float64("income", 0, "the income required in each year")
+	fromYear := flag.Int("from", 0, "the first year of the ladder")
+	toYear := flag.Int("to", 0, "the last year of the ladder")
+	profile := flag.String("profile", "default", "the AWS profile to use")
+	helpFlag := flag.Bool("help", false, "print this help message")
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) != 1 || *helpFlag {
+		fmt.Printf("Usage: %s -income <income> -from <year> -to <year> <flags> <data>\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *income <= 0 {
+		fmt.Println("Error: income must be greater than 0.0")
+		os.Exit(1)
+	}
+
+	if *fromYear == 0 || *toYear < *fromYear {
+		fmt.Println("Error: -from and -to years are required and -to must not be before -from")
+		os.Exit(1)
+	}
+
+	bonds, err := collect.LoadBonds(ctx, args[0], *profile)
+	if err != nil {
+		fmt.Printf("Failed to load %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+
+	ladder, err := analytics.BuildLadder(bonds, *income, *fromYear, *toYear)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Gilt Ladder (%.2f per year, %d-%d):\n", *income, *fromYear, *toYear)
+	for _, r := range ladder.Rungs {
+		fmt.Printf(
+			"\t%d  %-12s  %-32s  %s  Yield: %.3f%%  Nominal: %.2f  Cost: %.2f\n",
+			r.Year,
+			r.Bond.ISIN,
+			r.Bond.Desc,
+			r.Bond.MaturityDate.Format("2006-01-02"),
+			r.Bond.YieldToMaturity,
+			r.Nominal,
+			r.Cost,
+		)
+	}
+
+	if len(ladder.MissingYears) > 0 {
+		fmt.Printf("\tNo gilt maturing in: %v\n", ladder.MissingYears)
+	}
+
+	fmt.Printf("Total Cost: %.2f\n", ladder.TotalCost)
+	fmt.Printf("Blended Yield: %.3f%%\n", ladder.BlendedYield)
+}
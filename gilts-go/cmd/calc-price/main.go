@@ -0,0 +1,80 @@
+package main
+
+import (
+	"benritz/gilts/internal/types"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	coupon := flag.Float64("coupon", 0.0, "Coupon rate (%) of the bond")
+	faceValue := flag.Float64("facevalue", 100, "Face value of the bond")
+	ytm := flag.Float64("ytm", 0.0, "Yield to maturity of the bond")
+	frequency := flag.Int("frequency", 2, "Number of coupon payments per year")
+	periods := flag.Int("periods", 0, "Number of coupon payments remaining to maturity")
+	remainingDays := flag.Int("remainingdays", 0, "Number of days from the settlement date to the next coupon date")
+	periodDays := flag.Int("perioddays", 0, "Number of days between the previous coupon date and the next coupon date")
+
+	flag.Parse()
+
+	flagsSet := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		flagsSet[f.Name] = true
+	})
+
+	for _, name := range []string{"coupon", "ytm", "periods", "remainingdays", "perioddays"} {
+		if !flagsSet[name] {
+			fmt.Printf("Error: -%s flag is required\n", name)
+			os.Exit(1)
+		}
+	}
+
+	if *coupon < 0.0 || *coupon > 100.0 {
+		fmt.Println("Error: coupon rate must be between 0.0 and 100.0")
+		os.Exit(1)
+	}
+
+	if *faceValue <= 0.0 {
+		fmt.Println("Error: face value must be greater than 0.0")
+		os.Exit(1)
+	}
+
+	if *ytm <= -100.0 || *ytm > 100.0 {
+		fmt.Println("Error: yield to maturity must be between -100.0 and 100.0")
+		os.Exit(1)
+	}
+
+	if *frequency < 1 || *frequency > 12 {
+		fmt.Println("Error: frequency must be between 1 and 12")
+		os.Exit(1)
+	}
+
+	if *periods < 1 {
+		fmt.Println("Error: periods must be greater than 0")
+		os.Exit(1)
+	}
+
+	if *periodDays < 1 {
+		fmt.Println("Error: period days must be greater than 0")
+		os.Exit(1)
+	}
+
+	if *remainingDays < 0 || *remainingDays > *periodDays {
+		fmt.Println("Error: remaining days must be between 0 and the period days")
+		os.Exit(1)
+	}
+
+	cleanPrice := types.CleanPrice(*coupon, *ytm, *faceValue, *frequency, *periods, *remainingDays, *periodDays)
+	dirtyPrice := types.DirtyPrice(*coupon, *ytm, *faceValue, *frequency, *periods, *remainingDays, *periodDays)
+
+	fmt.Printf("Bond Prices:\n")
+	fmt.Printf("\tFace Value: %.3f\n", *faceValue)
+	fmt.Printf("\tCoupon Rate: %.3f%%\n", *coupon)
+	fmt.Printf("\tYield to Maturity: %.6f%%\n", *ytm)
+	fmt.Printf("\tCoupon Periods: %d\n", *periods)
+	fmt.Printf("\tRemaining Days: %d\n", *remainingDays)
+	fmt.Printf("\tCoupon Period Days: %d\n", *periodDays)
+	fmt.Printf("\tClean Price: %.3f\n", cleanPrice)
+	fmt.Printf("\tDirty Price: %.3f\n", dirtyPrice)
+}
@@ -2,11 +2,20 @@ package main
 
 import (
 	"benritz/gilts/internal/types"
+
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"time"
 )
 
+var (
+	errUsage = errors.New("usage")
+)
+
 func parseDate(s *string) (time.Time, error) {
 	if s == nil || *s == "" {
 		return time.Now(), nil
@@ -18,104 +27,216 @@ func parseDate(s *string) (time.Time, error) {
 	return time.Time{}, err
 }
 
-func main() {
-	coupon := flag.Float64("coupon", 0.0, "Coupon rate (%) of the bond")
-	faceValue := flag.Float64("facevalue", 100, "Face value of the bond")
-	cleanPrice := flag.Float64("cleanprice", 0.0, "Clean price of the bond")
-	ytm := flag.Float64("ytm", 0.0, "Yield to maturity of the bond")
-	settlementDateStr := flag.String("settlementdate", "", "Settlement date of the bond (YYYY-MM-DD)")
-	maturityDateStr := flag.String("maturitydate", "", "Maturity date of the bond (YYYY-MM-DD)")
+// run calculates the yield to maturity or the prices of the bond in the arguments and writes
+// the bond's details to stdout.
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	coupon := flags.Float64("coupon", 0.0, "Coupon rate (%) of the bond")
+	strip := flags.Bool("strip", false, "The bond is a strip (zero-coupon)")
+	faceValue := flags.Float64("facevalue", 100, "Face value of the bond")
+	cleanPrice := flags.Float64("cleanprice", 0.0, "Clean price of the bond")
+	ytm := flags.Float64("ytm", 0.0, "Yield to maturity of the bond")
+	settlementDateStr := flags.String("settlementdate", "", "Settlement date of the bond (YYYY-MM-DD)")
+	maturityDateStr := flags.String("maturitydate", "", "Maturity date of the bond (YYYY-MM-DD)")
+	periods := flags.Int("periods", 0, "Override the number of coupon payments remaining to maturity")
+	remainingDays := flags.Int("remainingdays", 0, "Override the number of days from the settlement date to the next coupon date")
+	periodDays := flags.Int("perioddays", 0, "Override the number of days between the previous coupon date and the next coupon date")
+	dayCountStr := flags.String("daycount", "actualactual", "Day count convention for accrued interest: actualactual, act365 or 30360")
+	accrualStr := flags.String("accrual", "exclusive", "Whether interest accrues to the settlement date exclusive (UK gilts) or inclusive")
+	seedStr := flags.String("seed", "estimate", "Initial guess of the yield to maturity solver: estimate, coupon or current")
+	precision := flags.Int("precision", 0, "Number of decimal places (0 to 12) of the printed prices and yields, defaults to 3 for prices and 6 for yields")
+
+	selfTest := flags.Bool("selftest", false, "Check the pricing engine against a known gilt and exit")
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
 
-	flag.Parse()
+	if *selfTest {
+		if err := types.SelfTest(); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, "Self-test passed")
+		return nil
+	}
 
 	flagsSet := make(map[string]bool)
-	flag.Visit(func(f *flag.Flag) {
+	flags.Visit(func(f *flag.Flag) {
 		flagsSet[f.Name] = true
 	})
 
-	if !flagsSet["coupon"] {
-		fmt.Println("Error: -coupon flag is required")
-		return
+	if !flagsSet["coupon"] && !*strip {
+		return fmt.Errorf("-coupon flag is required")
 	}
 
 	if !flagsSet["cleanprice"] && !flagsSet["ytm"] {
-		fmt.Println("Error: -cleanprice or -ytm flag is required")
-		return
+		return fmt.Errorf("-cleanprice or -ytm flag is required")
 	}
 
-	if !flagsSet["maturitydate"] || maturityDateStr == nil || *maturityDateStr == "" {
-		fmt.Println("Error: -maturitydate flag is required")
-		return
+	// the coupon schedule overrides bypass the inference from the coupon and maturity dates
+	scheduleFlags := 0
+	for _, name := range []string{"periods", "remainingdays", "perioddays"} {
+		if flagsSet[name] {
+			scheduleFlags++
+		}
 	}
 
-	settlementDate, err := parseDate(settlementDateStr)
+	if scheduleFlags != 0 && scheduleFlags != 3 {
+		return fmt.Errorf("-periods, -remainingdays and -perioddays must be set together")
+	}
+
+	overrideSchedule := scheduleFlags == 3
+
+	if !overrideSchedule && (!flagsSet["maturitydate"] || maturityDateStr == nil || *maturityDateStr == "") {
+		return fmt.Errorf("-maturitydate flag is required")
+	}
+
+	// the decimal places of the prices, yields and other values
+	pricePrec, yieldPrec, otherPrec := 3, 6, 4
+	if flagsSet["precision"] {
+		if *precision < 0 || *precision > 12 {
+			return fmt.Errorf("precision must be between 0 and 12")
+		}
+		pricePrec, yieldPrec, otherPrec = *precision, *precision, *precision
+	}
+
+	dayCount, err := types.ParseDayCount(*dayCountStr)
+	if err != nil {
+		return err
+	}
+
+	accrualRule, err := types.ParseAccrualRule(*accrualStr)
 	if err != nil {
-		fmt.Printf("Error: invalid settlement date: %v\n", err)
-		return
+		return err
 	}
 
-	maturityDate, err := parseDate(maturityDateStr)
+	seed, err := types.ParseSeedStrategy(*seedStr)
 	if err != nil {
-		fmt.Printf("Error: invalid maturity date: %v\n", err)
-		return
+		return fmt.Errorf("%w: %s", err, *seedStr)
 	}
 
-	if maturityDate.Before(settlementDate) {
-		fmt.Println("Error: maturity date cannot be before settlement date")
-		return
+	settlementDate, err := parseDate(settlementDateStr)
+	if err != nil {
+		return fmt.Errorf("invalid settlement date: %v", err)
+	}
+
+	var maturityDate time.Time
+	if *maturityDateStr != "" {
+		maturityDate, err = parseDate(maturityDateStr)
+		if err != nil {
+			return fmt.Errorf("invalid maturity date: %v", err)
+		}
+
+		if maturityDate.Before(settlementDate) {
+			return fmt.Errorf("maturity date cannot be before settlement date")
+		}
 	}
 
 	if *coupon < 0.0 || *coupon > 100.0 {
-		fmt.Println("Error: coupon rate must be between 0.0 and 100.0")
-		return
+		return fmt.Errorf("coupon rate must be between 0.0 and 100.0")
+	}
+
+	if *strip && *coupon != 0.0 {
+		return fmt.Errorf("coupon rate must be 0.0 for a strip")
 	}
 
 	if *faceValue <= 0.0 {
-		fmt.Println("Error: face value must be greater than 0.0")
-		return
+		return fmt.Errorf("face value must be greater than 0.0")
 	}
 
 	if *cleanPrice < 0.0 {
-		fmt.Println("Error: clean price must be greater than or equal to 0.0")
-		return
+		return fmt.Errorf("clean price must be greater than or equal to 0.0")
 	}
 
-	if *ytm < 0.0 {
-		fmt.Println("Error: yield to maturity must be greater than or equal to 0.0")
-		return
+	// a negative yield is valid, it is checked against the solver bounds when the bond is completed
+	if *ytm <= -100.0 {
+		return fmt.Errorf("yield to maturity must be greater than -100.0")
 	}
 
 	bond := types.Bond{
-		Type:            types.UKGilt,
-		FacePrice:       *faceValue,
-		Coupon:          *coupon,
-		SettlementDate:  settlementDate,
-		MaturityDate:    maturityDate,
-		CleanPrice:      *cleanPrice,
-		YieldToMaturity: *ytm,
-	}
-
-	if err := types.CompleteBond(&bond); err != nil {
-		fmt.Printf("Error completing bond: %v\n", err)
-		return
-	}
-
-	fmt.Printf("Bond Details:\n")
-	fmt.Printf("\tType: %s\n", bond.Type)
-	fmt.Printf("\tFace Value: %.3f\n", bond.FacePrice)
-	fmt.Printf("\tCoupon Rate: %.3f%%\n", bond.Coupon)
-	fmt.Printf("\tSettlement Date: %s\n", bond.SettlementDate.Format("2006-01-02"))
-	fmt.Printf("\tMaturity Date: %s\n", bond.MaturityDate.Format("2006-01-02"))
-	fmt.Printf("\tClean Price: %.3f\n", bond.CleanPrice)
-	fmt.Printf("\tDirty Price: %.3f\n", bond.DirtyPrice)
-	fmt.Printf("\tRemaining Days: %d\n", bond.RemainingDays)
-	fmt.Printf("\tAccrued Days: %d\n", bond.AccruedDays)
-	fmt.Printf("\tAccrued Amount: %.3f\n", bond.AccruedAmount)
-	fmt.Printf("\tCoupon Period Days: %d\n", bond.CouponPeriodDays)
-	fmt.Printf("\tCoupon Periods: %d\n", bond.CouponPeriods)
-	fmt.Printf("\tNext Coupon Date: %s\n", bond.NextCouponDate.Format("2006-01-02"))
-	fmt.Printf("\tPrevious Coupon Date: %s\n", bond.PrevCouponDate.Format("2006-01-02"))
-	fmt.Printf("\tMaturity Years: %d\n", bond.MaturityYears)
-	fmt.Printf("\tMaturity Days: %d\n", bond.MaturityDays)
-	fmt.Printf("\tYield to Maturity: %.6f%%\n", bond.YieldToMaturity)
+		Type:               types.UKGilt,
+		FacePrice:          *faceValue,
+		Coupon:             *coupon,
+		Strip:              *strip,
+		DayCountConvention: dayCount,
+		AccrualRule:        accrualRule,
+		SettlementDate:     settlementDate,
+		MaturityDate:       maturityDate,
+		CleanPrice:         *cleanPrice,
+		YieldToMaturity:    *ytm,
+	}
+
+	// CompleteBond solves the yield from the clean price if given, otherwise the
+	// prices are derived from the yield. A yield given with the price is compared
+	// with the solved yield.
+	solveYield := bond.CleanPrice > 0
+	sourceYield := solveYield && bond.YieldToMaturity != 0
+	if sourceYield {
+		bond.SourceYield = bond.YieldToMaturity
+		bond.YieldToMaturity = 0
+	}
+
+	opts := types.DefaultSolverOptions()
+	opts.Seed = seed
+
+	if overrideSchedule {
+		err = types.CompleteBondWithSchedule(&bond, *periods, *remainingDays, *periodDays, opts)
+	} else {
+		err = types.CompleteBondWithOptions(&bond, opts)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to complete bond: %v", err)
+	}
+
+	if solveYield {
+		fmt.Fprintf(stdout, "Solved: yield to maturity from clean price\n")
+	} else {
+		fmt.Fprintf(stdout, "Solved: clean and dirty price from yield to maturity\n")
+	}
+
+	fmt.Fprintf(stdout, "Bond Details:\n")
+	fmt.Fprintf(stdout, "\tType: %s\n", bond.Type)
+	fmt.Fprintf(stdout, "\tFace Value: %.*f\n", pricePrec, bond.FacePrice)
+	fmt.Fprintf(stdout, "\tCoupon Rate: %.*f%%\n", pricePrec, bond.Coupon)
+	fmt.Fprintf(stdout, "\tStrip: %t\n", bond.Strip)
+	fmt.Fprintf(stdout, "\tDay Count: %s\n", bond.DayCountConvention)
+	fmt.Fprintf(stdout, "\tAccrual: %s\n", bond.AccrualRule)
+	fmt.Fprintf(stdout, "\tSettlement Date: %s\n", bond.SettlementDate.Format("2006-01-02"))
+	fmt.Fprintf(stdout, "\tMaturity Date: %s\n", bond.MaturityDate.Format("2006-01-02"))
+	fmt.Fprintf(stdout, "\tClean Price: %.*f\n", pricePrec, bond.CleanPrice)
+	fmt.Fprintf(stdout, "\tDirty Price: %.*f\n", pricePrec, bond.DirtyPrice)
+	fmt.Fprintf(stdout, "\tRemaining Days: %d\n", bond.RemainingDays)
+	fmt.Fprintf(stdout, "\tAccrued Days: %d\n", bond.AccruedDays)
+	fmt.Fprintf(stdout, "\tAccrued Amount: %.*f\n", pricePrec, bond.AccruedAmount)
+	fmt.Fprintf(stdout, "\tCoupon Period Days: %d\n", bond.CouponPeriodDays)
+	fmt.Fprintf(stdout, "\tCoupon Periods: %d\n", bond.CouponPeriods)
+	fmt.Fprintf(stdout, "\tNext Coupon Date: %s\n", bond.NextCouponDate.Format("2006-01-02"))
+	fmt.Fprintf(stdout, "\tNext Coupon Amount: %.*f\n", pricePrec, bond.NextCouponAmount)
+	fmt.Fprintf(stdout, "\tPrevious Coupon Date: %s\n", bond.PrevCouponDate.Format("2006-01-02"))
+	fmt.Fprintf(stdout, "\tMaturity Years: %d\n", bond.MaturityYears)
+	fmt.Fprintf(stdout, "\tMaturity Days: %d\n", bond.MaturityDays)
+	fmt.Fprintf(stdout, "\tAverage Life: %.*f\n", otherPrec, bond.AverageLife)
+	fmt.Fprintf(stdout, "\tYield to Maturity: %.*f%%\n", yieldPrec, bond.YieldToMaturity)
+	fmt.Fprintf(stdout, "\tModified Duration: %.*f\n", otherPrec, bond.Duration)
+	fmt.Fprintf(stdout, "\tConvexity: %.*f\n", otherPrec, bond.Convexity)
+	fmt.Fprintf(stdout, "\tDV01: %.*f\n", otherPrec, bond.DV01)
+	if solveYield {
+		fmt.Fprintf(stdout, "\tSolver Iterations: %d\n", bond.SolverIterations)
+	}
+	if sourceYield {
+		fmt.Fprintf(stdout, "\tGiven Yield: %.*f%%\n", yieldPrec, bond.SourceYield)
+		fmt.Fprintf(stdout, "\tComputed vs Given Yield: %+.*f%%\n", yieldPrec, bond.ComputedVsSourceYield)
+	}
+
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"benritz/gilts/internal/types"
+
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// runOutput runs the command and returns the value of each "Name: value" line of its output.
+func runOutput(t *testing.T, args ...string) map[string]string {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	if err := run(args, &stdout); err != nil {
+		t.Fatalf("run(%v) error = %v", args, err)
+	}
+
+	values := make(map[string]string)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if name, value, ok := strings.Cut(strings.TrimSpace(line), ": "); ok {
+			values[name] = value
+		}
+	}
+
+	return values
+}
+
+func TestRunDayCount(t *testing.T) {
+	// the 3½% 2025 gilt settling on 2025-03-07, 136 days after the 2024-10-22 coupon of
+	// a 182 day period, and 135 days by 30/360
+	args := []string{"-coupon", "3.5", "-cleanprice", "99.5", "-settlementdate", "2025-03-07", "-maturitydate", "2025-10-22", "-precision", "6"}
+
+	tests := []struct {
+		dayCount    string
+		wantAccrued string
+	}{
+		{dayCount: "actualactual", wantAccrued: "1.307692"}, // 1.75 * 136/182
+		{dayCount: "act365", wantAccrued: "1.304110"},       // 3.5 * 136/365
+		{dayCount: "30360", wantAccrued: "1.312500"},        // 3.5 * 135/360
+	}
+
+	yields := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.dayCount, func(t *testing.T) {
+			values := runOutput(t, append(args, "-daycount", tt.dayCount)...)
+
+			if got := values["Accrued Amount"]; got != tt.wantAccrued {
+				t.Errorf("Accrued Amount = %s, want %s", got, tt.wantAccrued)
+			}
+
+			yield := values["Yield to Maturity"]
+			if other, ok := yields[yield]; ok {
+				t.Errorf("Yield to Maturity = %s, the same as %s", yield, other)
+			}
+			yields[yield] = tt.dayCount
+		})
+	}
+
+	var stdout bytes.Buffer
+	if err := run(append(args, "-daycount", "actual360"), &stdout); err == nil {
+		t.Errorf("run() with an unknown day count error = nil, want an error")
+	}
+}
+
+func TestRunPrecision(t *testing.T) {
+	args := []string{"-coupon", "3.5", "-cleanprice", "99.5", "-settlementdate", "2025-03-07", "-maturitydate", "2025-10-22"}
+
+	values := runOutput(t, append(args, "-precision", "8")...)
+
+	want := map[string]string{
+		"Clean Price":    "99.50000000",
+		"Accrued Amount": "1.30769231", // 1.75 * 136/182
+		"Face Value":     "100.00000000",
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %s, want %s", name, values[name], value)
+		}
+	}
+
+	yield, ok := strings.CutSuffix(values["Yield to Maturity"], "%")
+	if _, decimals, _ := strings.Cut(yield, "."); !ok || len(decimals) != 8 {
+		t.Errorf("Yield to Maturity = %s, want 8 decimal places", values["Yield to Maturity"])
+	}
+
+	// the defaults are 3 decimal places for prices and 6 for yields
+	values = runOutput(t, args...)
+	if values["Clean Price"] != "99.500" || values["Accrued Amount"] != "1.308" {
+		t.Errorf("Clean Price = %s, Accrued Amount = %s, want 3 decimal places", values["Clean Price"], values["Accrued Amount"])
+	}
+
+	for _, precision := range []string{"-1", "13"} {
+		var stdout bytes.Buffer
+		if err := run(append(args, "-precision", precision), &stdout); err == nil {
+			t.Errorf("run() with precision %s error = nil, want an error", precision)
+		}
+	}
+}
+
+func TestRunYieldOnly(t *testing.T) {
+	values := runOutput(t, "-coupon", "3.5", "-ytm", "4.5", "-settlementdate", "2025-03-07", "-maturitydate", "2025-10-22", "-precision", "8")
+
+	if got := values["Solved"]; got != "clean and dirty price from yield to maturity" {
+		t.Errorf("Solved = %q, want the prices solved from the yield", got)
+	}
+
+	// 46 days to the next coupon of a 182 day period with 2 coupons remaining
+	want := map[string]string{
+		"Clean Price":       fmt.Sprintf("%.8f", types.CleanPrice(3.5, 4.5, 100, 2, 2, 46, 182)),
+		"Dirty Price":       fmt.Sprintf("%.8f", types.DirtyPrice(3.5, 4.5, 100, 2, 2, 46, 182)),
+		"Yield to Maturity": "4.50000000%",
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %s, want %s", name, values[name], value)
+		}
+	}
+
+	// the solver isn't used and there's no given yield to compare with
+	for _, name := range []string{"Solver Iterations", "Given Yield"} {
+		if value, ok := values[name]; ok {
+			t.Errorf("%s = %s, want no %s from a yield", name, value, name)
+		}
+	}
+
+	// the clean price prices back to the yield
+	values = runOutput(t, "-coupon", "3.5", "-cleanprice", want["Clean Price"], "-settlementdate", "2025-03-07", "-maturitydate", "2025-10-22", "-precision", "4")
+	if got := values["Solved"]; got != "yield to maturity from clean price" {
+		t.Errorf("Solved = %q, want the yield solved from the price", got)
+	}
+	if got := values["Yield to Maturity"]; got != "4.5000%" {
+		t.Errorf("Yield to Maturity = %s, want 4.5000%%", got)
+	}
+}
@@ -6,86 +6,151 @@ import (
 	"time"
 
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	_ "github.com/pbnjay/grate/xls"
 )
 
-func getAwsConfig(ctx context.Context, profile string) (aws.Config, error) {
-	if profile == "default" {
-		return config.LoadDefaultConfig(ctx)
+var (
+	errUsage = errors.New("usage")
+)
+
+// run collects the bonds and stores them or writes them to stdout. The collectors write their
+// progress and metrics to stderr so only the bonds are written to stdout when the destination
+// is -.
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	profile := flags.String("profile", "default", "the AWS profile to use")
+	source := flags.String("source", "dmo", "the data source, dmo or dividenddata")
+	layoutFlag := flags.String("layout", "date", "the storage layout, date (YYYY/MM/DD/source) or hive (source=/year=/month=/day=)")
+	sseKMSKeyID := flags.String("ssekmskeyid", "", "the KMS key ID for S3 server-side encryption, defaults to the bucket's encryption")
+	storageClass := flags.String("storageclass", "", "the S3 storage class, defaults to the bucket's storage class")
+	file := flags.String("file", "", "collect from a DMO report XLS file already downloaded rather than the DMO website")
+	dateFlag := flags.String("date", "", "the trade date to collect (YYYY-MM-DD), defaults to today and required with -file")
+	enrich := flags.Bool("enrich", false, "store the raw bonds and the bonds enriched with the yield to maturity")
+	formatFlag := flags.String("format", "parquet", "the format when the destination is - (stdout), parquet, csv or json")
+	helpFlag := flags.Bool("help", false, "print this help message")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
 	}
-	return config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
-}
 
-func storeToS3(
-	ctx context.Context,
-	collected *collect.CollectedBonds,
-	profile string,
-	s3Path *collect.S3Path,
-) (string, error) {
-	cfg, err := getAwsConfig(ctx, profile)
-	if err != nil {
-		return "", fmt.Errorf("failed to load AWS config: %v", err)
+	if flags.NArg() != 1 || *helpFlag {
+		fmt.Fprintf(os.Stderr, "Usage: %s <flags> <destination|->\n", flags.Name())
+		flags.PrintDefaults()
+		return errUsage
 	}
 
-	s3Client := s3.NewFromConfig(cfg)
+	layout, err := collect.ParseKeyLayout(*layoutFlag)
+	if err != nil {
+		return fmt.Errorf("invalid layout: %s", *layoutFlag)
+	}
 
-	outPath, err := collect.StoreToS3(ctx, collected, s3Client, s3Path)
+	format, err := collect.ParseFormat(*formatFlag)
 	if err != nil {
-		return "", fmt.Errorf("failed to store data to S3: %v", err)
+		return fmt.Errorf("invalid format: %s", *formatFlag)
 	}
 
-	return outPath, nil
-}
+	// a downloaded report is for a past trade date, pricing it at today's settlement date
+	// would give the wrong yields and accrued interest
+	if *file != "" && *dateFlag == "" {
+		return fmt.Errorf("-date is required with -file, the trade date of the report")
+	}
 
-func main() {
-	ctx := context.Background()
+	date := time.Now()
+	if *dateFlag != "" {
+		date, err = time.Parse("2006-01-02", *dateFlag)
+		if err != nil {
+			return fmt.Errorf("invalid date: %s", *dateFlag)
+		}
+	}
 
-	profile := flag.String("profile", "default", "the AWS profile to use")
-	helpFlag := flag.Bool("help", false, "print this help message")
-	flag.Parse()
-	args := flag.Args()
+	dst := flags.Arg(0)
 
-	if len(args) != 1 || *helpFlag {
-		fmt.Printf("Usage: %s <flags> <destination>\n", filepath.Base(os.Args[0]))
-		flag.PrintDefaults()
-		os.Exit(1)
+	// the bonds are written to stdout for piping into other tools
+	toStdout := dst == "-"
+
+	if !toStdout && format != collect.FormatParquet {
+		return fmt.Errorf("-format is only supported when the destination is - (stdout)")
 	}
 
-	dst := args[0]
+	if toStdout && *enrich {
+		return fmt.Errorf("-enrich is not supported when the destination is - (stdout)")
+	}
 
-	// collector := collect.NewDividendDataCollector()
-	collector := collect.NewDMOCollector()
+	var store collect.Store
+	if !toStdout {
+		store, err = collect.NewStore(ctx, dst, *profile, layout, collect.StoreOptions{
+			SSEKMSKeyID:  *sseKMSKeyID,
+			StorageClass: *storageClass,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create store: %v", err)
+		}
+	}
 
-	collected, err := collector.Collect(ctx, time.Now())
+	collector, err := collect.CollectorByName(*source)
 	if err != nil {
-		switch err {
-		case types.ErrDataUnavailable:
-			fmt.Printf("Data unavailable\n")
-		default:
-			fmt.Printf("Failed to collect data: %v\n", err)
-		}
-		os.Exit(1)
+		return err
 	}
 
-	var outPath string
-	if s3Path, _ := collect.ParseS3(dst); s3Path != nil {
-		outPath, err = storeToS3(ctx, collected, *profile, s3Path)
+	var collected *collect.CollectedBonds
+	if *file != "" {
+		dmo, ok := collector.(*collect.DMOCollector)
+		if !ok {
+			return fmt.Errorf("-file is only supported for the dmo source")
+		}
+		collected, err = dmo.CollectFromFile(date, *file)
 	} else {
-		outPath, err = collect.StoreToPath(ctx, collected, dst)
+		collected, err = collector.Collect(ctx, date)
+	}
+	if err != nil {
+		if errors.Is(err, types.ErrDataUnavailable) {
+			return fmt.Errorf("data unavailable: %v", err)
+		}
+		return fmt.Errorf("failed to collect data: %v", err)
+	}
+
+	if toStdout {
+		if err := collect.WriteTo(stdout, collected, format); err != nil {
+			return fmt.Errorf("failed to write data: %v", err)
+		}
+		return nil
 	}
 
+	if *enrich {
+		stored, err := collect.StoreEnriched(ctx, collected, store)
+		if err != nil {
+			return fmt.Errorf("failed to store data: %v", err)
+		}
+
+		for _, f := range stored.Failures {
+			fmt.Fprintf(stdout, "Failed to enrich %s %s: %v\n", f.Bond.ISIN, f.Bond.Desc, f.Err)
+		}
+
+		fmt.Fprintf(stdout, "Stored to %s and %s\n", stored.RawPath, stored.EnrichedPath)
+		return nil
+	}
+
+	outPath, err := store.Store(ctx, collected)
 	if err != nil {
-		fmt.Printf("Failed to store data: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to store data: %v", err)
 	}
 
-	fmt.Printf("Stored to %s\n", outPath)
+	fmt.Fprintf(stdout, "Stored to %s\n", outPath)
+
+	return nil
+}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// d10bFixture is the DMO report fixture of the collect package, 3 gilts on 2025-03-07.
+var d10bFixture = filepath.Join("..", "..", "internal", "collect", "testdata", "D10B_20250307.xls")
+
+// captureStdout runs the command with the process's stdout captured, so anything the
+// collectors print to stdout rather than stderr is in the output.
+func captureStdout(t *testing.T, args []string) []byte {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+
+	err = run(context.Background(), args, w)
+	w.Close()
+	data := <-output
+
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	return data
+}
+
+func TestRunToStdout(t *testing.T) {
+	args := []string{"-file", d10bFixture, "-date", "2025-03-07"}
+
+	t.Run("csv", func(t *testing.T) {
+		data := captureStdout(t, append(args, "-format", "csv", "-"))
+
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			t.Fatalf("stdout isn't CSV: %v\n%s", err, data)
+		}
+
+		if len(records) != 4 || records[0][0] != "Source" || records[0][1] != "ISIN" {
+			t.Fatalf("got %d records, want the header and 3 gilts:\n%s", len(records), data)
+		}
+		for _, r := range records[1:] {
+			if r[0] != collect.SourceDMO || !strings.HasPrefix(r[1], "GB") {
+				t.Errorf("record %v isn't a DMO gilt", r)
+			}
+		}
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		data := captureStdout(t, append(args, "-"))
+
+		bonds, err := collect.ReadBonds(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("stdout isn't parquet: %v", err)
+		}
+		if len(bonds) != 3 {
+			t.Errorf("got %d bonds, want 3", len(bonds))
+		}
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no destination", args: []string{}},
+		{name: "file without date", args: []string{"-file", d10bFixture, "-"}},
+		{name: "invalid date", args: []string{"-date", "07-03-2025", "-"}},
+		{name: "invalid format", args: []string{"-format", "xml", "-"}},
+		{name: "format to a store", args: []string{"-format", "csv", t.TempDir()}},
+		{name: "unknown source", args: []string{"-source", "lse", "-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := run(context.Background(), tt.args, &stdout); err == nil {
+				t.Errorf("run() error = nil, want an error")
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("stdout = %q, want nothing", stdout.String())
+			}
+		})
+	}
+}
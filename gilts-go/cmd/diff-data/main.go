@@ -0,0 +1,92 @@
+package main
+
+import (
+	"benritz/gilts/internal/analytics"
+	"benritz/gilts/internal/collect"
+
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// loadBonds loads the bonds from a local parquet file or an s3:// object.
+func loadBonds(ctx context.Context, path string, profile string) (*collect.CollectedBonds, error) {
+	bonds, err := collect.LoadBonds(ctx, path, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bonds) == 0 {
+		return nil, fmt.Errorf("no bonds in %s", path)
+	}
+
+	collected := collect.NewCollectedBonds(bonds[0].Source, bonds[0].SettlementDate)
+	collected.Bonds = bonds
+
+	return collected, nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	profile := flag.String("profile", "default", "the AWS profile to use")
+	sortFlag := flag.String("sort", "maturity", "the order of the bonds, maturity, yield, coupon or isin")
+	helpFlag := flag.Bool("help", false, "print this help message")
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) != 2 || *helpFlag {
+		fmt.Printf("Usage: %s <flags> <previous> <current>\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	sortKey, err := analytics.ParseSortKey(*sortFlag)
+	if err != nil {
+		fmt.Printf("Invalid sort: %s\n", *sortFlag)
+		os.Exit(1)
+	}
+
+	prev, err := loadBonds(ctx, args[0], *profile)
+	if err != nil {
+		fmt.Printf("Failed to load %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+
+	curr, err := loadBonds(ctx, args[1], *profile)
+	if err != nil {
+		fmt.Printf("Failed to load %s: %v\n", args[1], err)
+		os.Exit(1)
+	}
+
+	// the report is in the order of the collections
+	analytics.SortBonds(prev.Bonds, sortKey)
+	analytics.SortBonds(curr.Bonds, sortKey)
+
+	report := collect.Diff(prev, curr)
+
+	fmt.Printf("Added (%d):\n", len(report.Added))
+	for _, b := range report.Added {
+		fmt.Printf("\t%s %s\n", b.ISIN, b.Desc)
+	}
+
+	fmt.Printf("Removed (%d):\n", len(report.Removed))
+	for _, b := range report.Removed {
+		fmt.Printf("\t%s %s\n", b.ISIN, b.Desc)
+	}
+
+	fmt.Printf("Changed (%d):\n", len(report.Changed))
+	for _, c := range report.Changed {
+		fmt.Printf(
+			"\t%s %s: clean price %.3f (%+.3f), yield %.3f%% (%+.3f%%)\n",
+			c.Curr.ISIN,
+			c.Curr.Desc,
+			c.Curr.CleanPrice,
+			c.CleanPriceDelta,
+			c.Curr.YieldToMaturity,
+			c.YieldDelta,
+		)
+	}
+}
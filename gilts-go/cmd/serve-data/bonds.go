@@ -0,0 +1,182 @@
+package main
+
+import (
+	"benritz/gilts/internal/analytics"
+	"benritz/gilts/internal/collect"
+	"benritz/gilts/internal/types"
+
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// maxLimit is the maximum number of bonds returned in a page.
+const maxLimit = 1_000
+
+// queryFilter parses the bond filter from the query, parameters which are missing are not constrained.
+func queryFilter(q url.Values) (analytics.BondFilter, error) {
+	var f analytics.BondFilter
+	var err error
+
+	if f.MaturityFrom, err = queryDate(q, "maturityFrom", time.Time{}); err != nil {
+		return f, err
+	}
+	if f.MaturityTo, err = queryDate(q, "maturityTo", time.Time{}); err != nil {
+		return f, err
+	}
+	if f.MinCoupon, err = queryFloat(q, "minCoupon", 0); err != nil {
+		return f, err
+	}
+	if f.MaxCoupon, err = queryFloat(q, "maxCoupon", 0); err != nil {
+		return f, err
+	}
+	if f.MinYield, err = queryFloat(q, "minYield", 0); err != nil {
+		return f, err
+	}
+	if f.MinAmountInIssue, err = queryFloat(q, "minAmountInIssue", 0); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
+
+// loadBonds loads the bonds collected from the source on the date of the request query, the
+// source is a collector name, e.g. dmo or dividenddata. The error response is written and
+// false returned if they can't be loaded.
+func (s *server) loadBonds(w http.ResponseWriter, r *http.Request) ([]*types.Bond, bool) {
+	q := r.URL.Query()
+
+	date, err := queryDate(q, "date", time.Time{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if date.IsZero() {
+		http.Error(w, "date is required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	source := q.Get("source")
+	if source == "" {
+		source = "dmo"
+	}
+
+	// the bonds are stored under the collector's source, e.g. DMO for dmo
+	collector, err := collect.CollectorByName(source)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	bonds, err := s.store.Load(r.Context(), collector.Source(), date)
+	if err != nil {
+		if errors.Is(err, collect.ErrBondsNotFound) {
+			http.Error(w, "no bonds for the source and date", http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return bonds, true
+}
+
+// handleBonds returns a page of the bonds collected from a source on a date, the total
+// number of bonds matching the filter is in the X-Total-Count header.
+//
+// Query parameters:
+//
+//	date:             The collection date (YYYY-MM-DD), required.
+//	source:           The collector name, dmo (default) or dividenddata.
+//	sort:             The sort order, maturity (default), yield, coupon or isin.
+//	maturityFrom:     The earliest maturity date (YYYY-MM-DD), inclusive.
+//	maturityTo:       The latest maturity date (YYYY-MM-DD), inclusive.
+//	minCoupon:        The minimum coupon rate (as a percentage), inclusive.
+//	maxCoupon:        The maximum coupon rate (as a percentage), inclusive.
+//	minYield:         The minimum yield to maturity (as a percentage), inclusive.
+//	minAmountInIssue: The minimum nominal amount in issue (£ million), inclusive.
+//	limit, offset:    The page, defaults to the first 100 bonds.
+func (s *server) handleBonds(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit, err := queryInt(q, "limit", 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit < 1 || limit > maxLimit {
+		http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxLimit), http.StatusBadRequest)
+		return
+	}
+
+	offset, err := queryInt(q, "offset", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if offset < 0 {
+		http.Error(w, "offset must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	sortKey, err := analytics.ParseSortKey(q.Get("sort"))
+	if err != nil {
+		http.Error(w, "sort must be maturity, yield, coupon or isin", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := queryFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bonds, ok := s.loadBonds(w, r)
+	if !ok {
+		return
+	}
+
+	bonds = analytics.FilterBonds(bonds, filter)
+
+	analytics.SortBonds(bonds, sortKey)
+
+	total := len(bonds)
+
+	// an offset past the end is an empty page rather than an error
+	start := min(offset, total)
+	end := min(start+limit, total)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeJSON(w, bonds[start:end])
+}
+
+// handleSearch returns the bonds collected from a source on a date matching a rough
+// description, e.g. "4% 2030", closest first.
+//
+// Query parameters:
+//
+//	q:      The description, the coupon percentage and/or maturity year.
+//	date:   The collection date (YYYY-MM-DD), required.
+//	source: The collector name, dmo (default) or dividenddata.
+func (s *server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	bonds, ok := s.loadBonds(w, r)
+	if !ok {
+		return
+	}
+
+	found := analytics.FindByDescription(bonds, query)
+	if found == nil {
+		http.Error(w, "q must have a coupon percentage or maturity year", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, found)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+	"benritz/gilts/internal/types"
+
+	"errors"
+	"net/http"
+)
+
+// curvePoint is a point of the spot curve.
+type curvePoint struct {
+	Maturity float64 `json:"maturity"`
+	Rate     float64 `json:"rate"`
+}
+
+// handleCurve returns the spot curve bootstrapped from the bonds collected from a source on a
+// date, the maturity of each point in years from the settlement date and the semi-annually
+// compounded spot rate (as a percentage).
+//
+// Query parameters:
+//
+//	date:   The collection date (YYYY-MM-DD), required.
+//	source: The collector name, dmo (default) or dividenddata.
+func (s *server) handleCurve(w http.ResponseWriter, r *http.Request) {
+	bonds, ok := s.loadBonds(w, r)
+	if !ok {
+		return
+	}
+
+	// the yields and coupon schedules are recalculated from the prices, bonds which fail
+	// are left off the curve
+	enriched, _ := collect.EnrichBonds(bonds)
+
+	curve, err := types.BootstrapSpotCurve(enriched)
+	if err != nil {
+		if errors.Is(err, types.ErrNoBootstrapBonds) {
+			http.Error(w, "no bonds to bootstrap the curve from for the source and date", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	points := make([]curvePoint, len(curve.Maturities))
+	for i := range points {
+		points[i] = curvePoint{Maturity: curve.Maturities[i], Rate: curve.Rates[i]}
+	}
+
+	writeJSON(w, points)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+	"benritz/gilts/internal/types"
+
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// server serves the gilt data and analytics as JSON for the frontend.
+type server struct {
+	store collect.Store
+}
+
+func newServer(store collect.Store) *server {
+	return &server{
+		store: store,
+	}
+}
+
+// routes returns the handler for the server's routes.
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /bonds", s.handleBonds)
+	mux.HandleFunc("GET /curve", s.handleCurve)
+	mux.HandleFunc("GET /grid", s.handleGrid)
+	mux.HandleFunc("GET /search", s.handleSearch)
+	return mux
+}
+
+// writeJSON writes the value as a JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Printf("Error: failed to write response: %v\n", err)
+	}
+}
+
+// queryFloat parses an optional float query parameter.
+func queryFloat(q url.Values, name string, defaultValue float64) (float64, error) {
+	value := q.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+
+	return f, nil
+}
+
+// queryInt parses an optional int query parameter.
+func queryInt(q url.Values, name string, defaultValue int) (int, error) {
+	value := q.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+
+	return i, nil
+}
+
+// queryDate parses an optional YYYY-MM-DD date query parameter.
+func queryDate(q url.Values, name string, defaultValue time.Time) (time.Time, error) {
+	value := q.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	ts, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, expected YYYY-MM-DD", name, value)
+	}
+
+	return ts, nil
+}
+
+// gridBond creates the bond for the grid from the query, either a known gilt by its isin
+// or ticker, or a coupon and maturity. The coupon schedule is completed at par.
+func gridBond(q url.Values) (*types.Bond, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	settlement, err := queryDate(q, "settlement", today)
+	if err != nil {
+		return nil, err
+	}
+
+	maturity, err := queryDate(q, "maturity", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	coupon, err := queryFloat(q, "coupon", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	b := types.NewUKGiltWithMaturity("", settlement, coupon, maturity)
+	b.ISIN = q.Get("isin")
+	b.Ticker = q.Get("ticker")
+
+	if b.ISIN == "" && b.Ticker == "" && (coupon <= 0 || maturity.IsZero()) {
+		return nil, errors.New("isin, ticker or coupon and maturity are required")
+	}
+
+	if (b.ISIN != "" || b.Ticker != "") && !types.MergeGiltRef(b) {
+		return nil, errors.New("unknown gilt")
+	}
+
+	b.CleanPrice = 100
+
+	if err := types.CompleteBond(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// handleGrid returns the clean price of a bond across a range of yields.
+//
+// Query parameters:
+//
+//	isin, ticker:     A gilt in the gilt reference.
+//	coupon, maturity: The coupon rate and maturity date (YYYY-MM-DD) of any other gilt.
+//	settlement:       The settlement date (YYYY-MM-DD), defaults to today.
+//	min, max, step:   The yield range (as percentages), defaults to 0 to 10 in steps of 0.25.
+func (s *server) handleGrid(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	minYield, err := queryFloat(q, "min", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	maxYield, err := queryFloat(q, "max", 10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	step, err := queryFloat(q, "step", 0.25)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if step <= 0 || maxYield < minYield {
+		http.Error(w, "step must be greater than 0 and max must not be less than min", http.StatusBadRequest)
+		return
+	}
+
+	// limit the size of the response
+	if (maxYield-minYield)/step > 10_000 {
+		http.Error(w, "too many points, increase the step", http.StatusBadRequest)
+		return
+	}
+
+	b, err := gridBond(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, types.PriceYieldGrid(b, minYield, maxYield, step))
+}
+
+func main() {
+	ctx := context.Background()
+
+	addr := flag.String("addr", ":8080", "the address to listen on")
+	profile := flag.String("profile", "default", "the AWS profile to use")
+	layoutFlag := flag.String("layout", "date", "the storage layout, date (YYYY/MM/DD/source) or hive (source=/year=/month=/day=)")
+	helpFlag := flag.Bool("help", false, "print this help message")
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) != 1 || *helpFlag {
+		fmt.Printf("Usage: %s <flags> <data>\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	layout, err := collect.ParseKeyLayout(*layoutFlag)
+	if err != nil {
+		fmt.Printf("Invalid layout: %s\n", *layoutFlag)
+		os.Exit(1)
+	}
+
+	store, err := collect.NewStore(ctx, args[0], *profile, layout, collect.StoreOptions{})
+	if err != nil {
+		fmt.Printf("Failed to create store: %v\n", err)
+		os.Exit(1)
+	}
+
+	s := newServer(store)
+
+	fmt.Printf("Listening on %s\n", *addr)
+	if err := http.ListenAndServe(*addr, s.routes()); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
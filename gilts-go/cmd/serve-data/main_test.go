@@ -0,0 +1,283 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+	"benritz/gilts/internal/types"
+
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeS3 is an in-memory S3 bucket.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}}
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[*params.Key] = data
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, ok := f.objects[*params.Key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.objects[*params.Key]; !ok {
+		return nil, &s3types.NotFound{}
+	}
+
+	return &s3.HeadObjectOutput{}, nil
+}
+
+var testDate = time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+// testGilt is a completed gilt settling on the test date.
+func testGilt(t *testing.T, isin string, coupon float64, maturity time.Time, cleanPrice float64) *types.Bond {
+	t.Helper()
+
+	b := types.NewUKGiltWithMaturity(collect.SourceDMO, testDate, coupon, maturity)
+	b.ISIN = isin
+	b.CleanPrice = cleanPrice
+	if err := types.CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	return b
+}
+
+// newTestServer returns a server over a fake S3 bucket with gilts collected from the DMO
+// on the test date.
+func newTestServer(t *testing.T) *server {
+	t.Helper()
+
+	store := collect.NewS3Store(newFakeS3(), &collect.S3Path{Bucket: "gilts"}, collect.DatePrefixed, collect.StoreOptions{})
+
+	collected := &collect.CollectedBonds{
+		// not in maturity, yield or coupon order
+		Bonds: []*types.Bond{
+			testGilt(t, "GB00BMBL1G81", 4.25, time.Date(2040, 12, 7, 0, 0, 0, 0, time.UTC), 95),
+			testGilt(t, "GB00BTHH2R79", 3.5, time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC), 99.5),
+			testGilt(t, "GB00BL68HJ26", 4, time.Date(2030, 10, 22, 0, 0, 0, 0, time.UTC), 99),
+			testGilt(t, "GB0002404191", 6, time.Date(2028, 12, 7, 0, 0, 0, 0, time.UTC), 105),
+		},
+		Quality:        collect.NewQualityReport(),
+		Source:         collect.SourceDMO,
+		SettlementDate: testDate,
+	}
+
+	if _, err := store.Store(context.Background(), collected); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	return newServer(store)
+}
+
+func TestHandleBonds(t *testing.T) {
+	s := newTestServer(t)
+
+	const (
+		tr25 = "GB00BTHH2R79" // 3½% 2025 yielding 4.31%
+		tr28 = "GB0002404191" // 6% 2028 yielding 4.53%
+		tr30 = "GB00BL68HJ26" // 4% 2030 yielding 4.20%
+		tr40 = "GB00BMBL1G81" // 4¼% 2040 yielding 4.70%
+	)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		isins  []string
+		total  int
+	}{
+		{name: "default source and maturity order", query: "?date=2025-03-07", status: http.StatusOK, isins: []string{tr25, tr28, tr30, tr40}, total: 4},
+		{name: "source name", query: "?date=2025-03-07&source=dmo", status: http.StatusOK, isins: []string{tr25, tr28, tr30, tr40}, total: 4},
+		{name: "sort by yield", query: "?date=2025-03-07&sort=yield", status: http.StatusOK, isins: []string{tr30, tr25, tr28, tr40}, total: 4},
+		{name: "sort by coupon", query: "?date=2025-03-07&sort=coupon", status: http.StatusOK, isins: []string{tr25, tr30, tr40, tr28}, total: 4},
+		{name: "filtered", query: "?date=2025-03-07&minCoupon=4", status: http.StatusOK, isins: []string{tr28, tr30, tr40}, total: 3},
+		// the total is of all the bonds matching the filter, not the page
+		{name: "paged", query: "?date=2025-03-07&limit=2&offset=1", status: http.StatusOK, isins: []string{tr28, tr30}, total: 4},
+		{name: "filtered and paged", query: "?date=2025-03-07&minCoupon=4&sort=yield&limit=1", status: http.StatusOK, isins: []string{tr30}, total: 3},
+		{name: "offset past the end", query: "?date=2025-03-07&offset=10", status: http.StatusOK, isins: []string{}, total: 4},
+		{name: "missing date", query: "", status: http.StatusBadRequest},
+		{name: "unknown source", query: "?date=2025-03-07&source=lse", status: http.StatusBadRequest},
+		{name: "no bonds", query: "?date=2025-03-06", status: http.StatusNotFound},
+		{name: "invalid limit", query: "?date=2025-03-07&limit=0", status: http.StatusBadRequest},
+		{name: "negative offset", query: "?date=2025-03-07&offset=-1", status: http.StatusBadRequest},
+		{name: "invalid sort", query: "?date=2025-03-07&sort=price", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/bonds"+tt.query, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if total := rec.Header().Get("X-Total-Count"); total != strconv.Itoa(tt.total) {
+				t.Errorf("X-Total-Count = %q, want %d", total, tt.total)
+			}
+
+			var bonds []types.Bond
+			if err := json.NewDecoder(rec.Body).Decode(&bonds); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			// an empty page is an empty array rather than null
+			if bonds == nil {
+				t.Fatalf("got null, want an array")
+			}
+
+			isins := []string{}
+			for _, b := range bonds {
+				isins = append(isins, b.ISIN)
+			}
+			if !slices.Equal(isins, tt.isins) {
+				t.Errorf("got %v, want %v", isins, tt.isins)
+			}
+		})
+	}
+}
+
+func TestHandleSearch(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?date=2025-03-07&q=4%25+2030", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	var bonds []types.Bond
+	if err := json.NewDecoder(rec.Body).Decode(&bonds); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(bonds) == 0 || bonds[0].ISIN != "GB00BL68HJ26" {
+		t.Errorf("closest bond is not the 4%% 2030 gilt: %+v", bonds)
+	}
+}
+
+func TestHandleGrid(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		count  int
+	}{
+		{name: "coupon and maturity", query: "?coupon=4&maturity=2030-10-22&settlement=2025-03-07&min=3&max=5&step=0.5", status: http.StatusOK, count: 5},
+		{name: "missing bond", query: "?min=3&max=5", status: http.StatusBadRequest},
+		{name: "invalid step", query: "?coupon=4&maturity=2030-10-22&step=0", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/grid"+tt.query, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var points []types.YieldPricePoint
+			if err := json.NewDecoder(rec.Body).Decode(&points); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(points) != tt.count {
+				t.Errorf("got %d points, want %d", len(points), tt.count)
+			}
+		})
+	}
+}
+
+func TestHandleCurve(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		count  int
+	}{
+		{name: "bootstrapped", query: "?date=2025-03-07", status: http.StatusOK, count: 4},
+		{name: "missing date", query: "", status: http.StatusBadRequest},
+		{name: "no bonds", query: "?date=2025-03-06", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/curve"+tt.query, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var points []curvePoint
+			if err := json.NewDecoder(rec.Body).Decode(&points); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(points) != tt.count {
+				t.Fatalf("got %d points, want %d", len(points), tt.count)
+			}
+
+			for i, p := range points {
+				if i > 0 && p.Maturity <= points[i-1].Maturity {
+					t.Errorf("maturities are not ascending: %+v", points)
+				}
+				if p.Rate < 0 || p.Rate > 10 {
+					t.Errorf("rate at %.4f years = %.6f, want a plausible rate", p.Maturity, p.Rate)
+				}
+			}
+		})
+	}
+}
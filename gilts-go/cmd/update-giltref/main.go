@@ -0,0 +1,87 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+	"benritz/gilts/internal/types"
+
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// mergeCurrent keeps the fields of the current reference data the gilts in issue report
+// doesn't have, the ticker, first coupon date and coupon frequency.
+func mergeCurrent(refs []types.GiltRef) {
+	for i := range refs {
+		ref := &refs[i]
+
+		current, ok := types.LookupGilt(ref.ISIN)
+		if !ok {
+			continue
+		}
+
+		if ref.Ticker == "" {
+			ref.Ticker = current.Ticker
+		}
+		if ref.FirstCouponDate.IsZero() {
+			ref.FirstCouponDate = current.FirstCouponDate
+		}
+		if ref.CouponFrequency == 0 {
+			ref.CouponFrequency = current.CouponFrequency
+		}
+	}
+}
+
+func main() {
+	ctx := context.Background()
+
+	file := flag.String("file", "", "read a gilts in issue report XLS file already downloaded rather than the DMO website")
+	baseURL := flag.String("baseurl", "", "the base URL of the DMO website, defaults to https://www.dmo.gov.uk")
+	helpFlag := flag.Bool("help", false, "print this help message")
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) != 1 || *helpFlag {
+		fmt.Printf("Usage: %s <flags> <gilts.json|->\n", filepath.Base(os.Args[0]))
+		fmt.Println("Regenerates the gilt reference data from the DMO gilts in issue report, e.g. internal/types/gilts.json")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	collector := collect.NewDMOReferenceCollector(*baseURL)
+
+	var refs []types.GiltRef
+	var err error
+
+	if *file != "" {
+		refs, err = collector.CollectFromFile(*file)
+	} else {
+		refs, err = collector.Collect(ctx)
+	}
+	if err != nil {
+		fmt.Printf("Error: failed to collect the gilts in issue: %v\n", err)
+		os.Exit(1)
+	}
+
+	mergeCurrent(refs)
+
+	data, err := types.MarshalGiltRefs(refs)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args[0] == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+
+	if err := os.WriteFile(args[0], data, 0o644); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Wrote %d gilts to %s\n", len(refs), args[0])
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+	"benritz/gilts/internal/types"
+
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errUsage = errors.New("usage")
+)
+
+// missingFields returns the names of the required fields the stored bond is missing, the
+// fields every collector sets so a zero value means the column wasn't decoded.
+func missingFields(b *types.Bond) []string {
+	missing := []string{}
+
+	if b.Source == "" {
+		missing = append(missing, "Source")
+	}
+	if b.ISIN == "" && b.Ticker == "" {
+		missing = append(missing, "ISIN or Ticker")
+	}
+	if b.SettlementDate.IsZero() {
+		missing = append(missing, "SettlementDate")
+	}
+	if b.MaturityDate.IsZero() {
+		missing = append(missing, "MaturityDate")
+	}
+	if b.Coupon == 0 && !b.Strip {
+		missing = append(missing, "Coupon")
+	}
+
+	return missing
+}
+
+// run validates the parquet file in the arguments decodes and has the required fields, writing
+// the row count and any invalid rows to stdout.
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	file := flags.String("file", "", "the parquet file to validate, a local path or s3://bucket/key")
+	profile := flags.String("profile", "default", "the AWS profile to use")
+	helpFlag := flags.Bool("help", false, "print this help message")
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *file == "" || *helpFlag {
+		fmt.Fprintf(flags.Output(), "Usage: %s -file <path> <flags>\n", flags.Name())
+		flags.PrintDefaults()
+		return errUsage
+	}
+
+	bonds, err := collect.LoadBonds(ctx, *file, *profile)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", *file, err)
+	}
+
+	fmt.Fprintf(stdout, "Rows: %d\n", len(bonds))
+
+	invalid := 0
+	for i, b := range bonds {
+		if missing := missingFields(b); len(missing) > 0 {
+			invalid++
+			fmt.Fprintf(stdout, "\trow %d %s: missing %s\n", i, b.ISIN, strings.Join(missing, ", "))
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d rows are missing required fields", invalid, len(bonds))
+	}
+
+	fmt.Fprintln(stdout, "Valid")
+
+	return nil
+}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Printf("Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
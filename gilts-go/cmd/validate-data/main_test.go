@@ -0,0 +1,93 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+	"benritz/gilts/internal/types"
+
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes the bonds to a parquet file of the current schema version.
+func writeFile(t *testing.T, bonds ...*types.Bond) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := collect.NewBondWriter(&buf)
+	for _, b := range bonds {
+		if err := w.Write(b); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestRun(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	gilt := func(isin string, coupon float64, maturity time.Time) *types.Bond {
+		b := types.NewUKGiltWithMaturity(collect.SourceDMO, date, coupon, maturity)
+		b.ISIN = isin
+		return b
+	}
+
+	current := writeFile(t,
+		gilt("GB00B24FF097", 4.75, time.Date(2030, 12, 7, 0, 0, 0, 0, time.UTC)),
+		gilt("GB0004893086", 4.25, time.Date(2032, 6, 7, 0, 0, 0, 0, time.UTC)),
+	)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantOutput []string
+		wantErr    string
+	}{
+		{name: "current version", data: current, wantOutput: []string{"Rows: 2", "Valid"}},
+		// the footer is lost from the end of the file
+		{name: "truncated", data: current[:len(current)/2], wantErr: "failed to decode"},
+		{
+			name: "missing fields",
+			data: writeFile(t,
+				gilt("GB00B84Z9V04", 3.25, time.Date(2044, 1, 22, 0, 0, 0, 0, time.UTC)),
+				gilt("", 0, time.Date(2044, 1, 22, 0, 0, 0, 0, time.UTC)),
+			),
+			wantOutput: []string{"Rows: 2", "row 1 : missing ISIN or Ticker, Coupon"},
+			wantErr:    "1 of 2 rows are missing required fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "DMO.parquet")
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			var stdout bytes.Buffer
+			err := run(t.Context(), []string{"-file", path}, &stdout)
+
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("run() error = %v, want %q", err, tt.wantErr)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("output = %q, want %q", stdout.String(), want)
+				}
+			}
+		})
+	}
+
+	var stdout bytes.Buffer
+	if err := run(t.Context(), nil, &stdout); !errors.Is(err, errUsage) {
+		t.Errorf("run() without a file error = %v, want %v", err, errUsage)
+	}
+}
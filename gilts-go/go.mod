@@ -10,6 +10,7 @@ require (
 	github.com/gocolly/colly/v2 v2.1.0
 	github.com/parquet-go/parquet-go v0.25.0
 	github.com/pbnjay/grate v0.0.0-20231006022435-3f8e65d74a14
+	golang.org/x/sync v0.16.0
 )
 
 require (
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+	"time"
+)
+
+// BondFilter are the constraints to filter bonds by. The zero value of a field means no
+// constraint and all the bounds are inclusive.
+type BondFilter struct {
+	// MaturityFrom is the earliest maturity date.
+	MaturityFrom time.Time
+	// MaturityTo is the latest maturity date.
+	MaturityTo time.Time
+	// MinCoupon is the minimum coupon rate (as a percentage).
+	MinCoupon float64
+	// MaxCoupon is the maximum coupon rate (as a percentage), zero is no constraint so
+	// strips can't be selected with a maximum coupon of zero.
+	MaxCoupon float64
+	// MinYield is the minimum yield to maturity (as a percentage), zero is no constraint
+	// so bonds with negative yields aren't excluded by a minimum yield of zero.
+	MinYield float64
+	// MinAmountInIssue is the minimum nominal amount in issue (£ million), e.g. to select
+	// the liquid gilts. Bonds without an amount in issue don't match a minimum.
+	MinAmountInIssue float64
+}
+
+// Match checks if a bond satisfies all the constraints of the filter.
+func (f *BondFilter) Match(b *types.Bond) bool {
+	if !f.MaturityFrom.IsZero() && b.MaturityDate.Before(f.MaturityFrom) {
+		return false
+	}
+
+	if !f.MaturityTo.IsZero() && b.MaturityDate.After(f.MaturityTo) {
+		return false
+	}
+
+	if f.MinCoupon != 0 && b.Coupon < f.MinCoupon {
+		return false
+	}
+
+	if f.MaxCoupon != 0 && b.Coupon > f.MaxCoupon {
+		return false
+	}
+
+	if f.MinYield != 0 && b.YieldToMaturity < f.MinYield {
+		return false
+	}
+
+	if f.MinAmountInIssue != 0 && b.AmountInIssue < f.MinAmountInIssue {
+		return false
+	}
+
+	return true
+}
+
+// FilterBonds selects the bonds which satisfy all the constraints of the filter.
+//
+// Parameters:
+//
+//	bonds: The bonds to filter.
+//	f:     The filter.
+//
+// Returns:
+//
+//	The matching bonds in their original order.
+func FilterBonds(bonds []*types.Bond, f BondFilter) []*types.Bond {
+	filtered := []*types.Bond{}
+
+	for _, b := range bonds {
+		if f.Match(b) {
+			filtered = append(filtered, b)
+		}
+	}
+
+	return filtered
+}
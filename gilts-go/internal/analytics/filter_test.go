@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFilterBonds(t *testing.T) {
+	bond := func(isin string, coupon float64, year int, ytm float64, amount float64) *types.Bond {
+		b := gilt(isin, coupon, year)
+		b.YieldToMaturity = ytm
+		b.AmountInIssue = amount
+		return b
+	}
+
+	bonds := []*types.Bond{
+		bond("GB1", 0, 2028, -0.1, 0),
+		bond("GB2", 0.5, 2029, 4.1, 30000),
+		bond("GB3", 4.25, 2030, 4.3, 40000),
+		bond("GB4", 6, 2032, 4.6, 20000),
+	}
+
+	maturity := func(year int) time.Time {
+		return time.Date(year, 12, 7, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		filter BondFilter
+		want   []string
+	}{
+		{name: "no constraints", want: []string{"GB1", "GB2", "GB3", "GB4"}},
+		{name: "maturity from is inclusive", filter: BondFilter{MaturityFrom: maturity(2029)}, want: []string{"GB2", "GB3", "GB4"}},
+		{name: "maturity to is inclusive", filter: BondFilter{MaturityTo: maturity(2029)}, want: []string{"GB1", "GB2"}},
+		{name: "maturity range", filter: BondFilter{MaturityFrom: maturity(2029), MaturityTo: maturity(2030)}, want: []string{"GB2", "GB3"}},
+		{name: "min coupon is inclusive", filter: BondFilter{MinCoupon: 4.25}, want: []string{"GB3", "GB4"}},
+		{name: "max coupon is inclusive", filter: BondFilter{MaxCoupon: 4.25}, want: []string{"GB1", "GB2", "GB3"}},
+		{name: "min yield is inclusive", filter: BondFilter{MinYield: 4.3}, want: []string{"GB3", "GB4"}},
+		// a zero minimum yield is no constraint, the negative yield isn't excluded
+		{name: "zero min yield", filter: BondFilter{MinYield: 0}, want: []string{"GB1", "GB2", "GB3", "GB4"}},
+		// the strip has no amount in issue so doesn't match a minimum
+		{name: "min amount in issue", filter: BondFilter{MinAmountInIssue: 25000}, want: []string{"GB2", "GB3"}},
+		{name: "all constraints", filter: BondFilter{MaturityTo: maturity(2031), MinCoupon: 0.5, MinYield: 4.2}, want: []string{"GB3"}},
+		{name: "no match", filter: BondFilter{MinYield: 5}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, b := range FilterBonds(bonds, tt.filter) {
+				got = append(got, b.ISIN)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FilterBonds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
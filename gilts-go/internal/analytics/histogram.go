@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+	"math"
+)
+
+// bucket returns the lower bound of the bucket a value falls in. A value on a boundary is in
+// the bucket above, the small tolerance stops e.g. 0.3 / 0.1 = 2.9999999999999996 putting a
+// boundary value in the bucket below.
+func bucket(value, width float64) float64 {
+	lower := math.Floor(value/width+1e-9) * width
+
+	// round away the floating point error so the keys are the exact bounds
+	return math.Round(lower*1e9) / 1e9
+}
+
+// YieldHistogram counts the bonds in each yield to maturity bucket, e.g. to chart an overview
+// of the market or spot outliers. The bonds must be completed.
+//
+// Parameters:
+//
+//	bonds:       The bonds.
+//	bucketWidth: The width of the buckets (as a percentage).
+//
+// Returns:
+//
+//	The number of bonds by the lower bound of the bucket (inclusive), nil if the width isn't positive.
+func YieldHistogram(bonds []*types.Bond, bucketWidth float64) map[float64]int {
+	if bucketWidth <= 0 {
+		return nil
+	}
+
+	histogram := map[float64]int{}
+	for _, b := range bonds {
+		histogram[bucket(b.YieldToMaturity, bucketWidth)]++
+	}
+
+	return histogram
+}
+
+// MaturityHistogram counts the bonds in each bucket of years to maturity from the settlement
+// date. Bonds which have matured are excluded.
+//
+// Parameters:
+//
+//	bonds:       The bonds.
+//	bucketWidth: The width of the buckets in years.
+//
+// Returns:
+//
+//	The number of bonds by the lower bound of the bucket (inclusive), nil if the width isn't positive.
+func MaturityHistogram(bonds []*types.Bond, bucketWidth float64) map[float64]int {
+	if bucketWidth <= 0 {
+		return nil
+	}
+
+	histogram := map[float64]int{}
+	for _, b := range bonds {
+		years, err := types.MaturityYearFraction(b.SettlementDate, b.MaturityDate)
+		if err != nil {
+			continue
+		}
+		histogram[bucket(years, bucketWidth)]++
+	}
+
+	return histogram
+}
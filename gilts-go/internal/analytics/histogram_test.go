@@ -0,0 +1,63 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestYieldHistogram(t *testing.T) {
+	bonds := []*types.Bond{}
+	for _, ytm := range []float64{3.9, 4.0, 4.3, 4.6, 5.2, -0.1} {
+		bonds = append(bonds, &types.Bond{YieldToMaturity: ytm})
+	}
+
+	tests := []struct {
+		name  string
+		width float64
+		want  map[float64]int
+	}{
+		// 4.0 is on the boundary so in the bucket above
+		{name: "half point", width: 0.5, want: map[float64]int{-0.5: 1, 3.5: 1, 4: 2, 4.5: 1, 5: 1}},
+		// 4.3 / 0.1 is 42.99999999999999 but is the lower bound of its bucket
+		{name: "tenth point", width: 0.1, want: map[float64]int{-0.1: 1, 3.9: 1, 4: 1, 4.3: 1, 4.6: 1, 5.2: 1}},
+		{name: "zero width", width: 0, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := YieldHistogram(bonds, tt.width); !maps.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("YieldHistogram() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaturityHistogram(t *testing.T) {
+	settlement := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	bonds := []*types.Bond{}
+	for _, maturity := range []time.Time{
+		time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC),
+		// exactly 1 year, on the boundary
+		time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC),
+		time.Date(2027, 9, 7, 0, 0, 0, 0, time.UTC),
+		time.Date(2035, 1, 31, 0, 0, 0, 0, time.UTC),
+		// matured, excluded
+		time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+	} {
+		bonds = append(bonds, &types.Bond{SettlementDate: settlement, MaturityDate: maturity})
+	}
+
+	want := map[float64]int{0: 1, 1: 1, 2: 1, 9: 1}
+	if got := MaturityHistogram(bonds, 1); !maps.Equal(got, want) {
+		t.Errorf("MaturityHistogram() = %v, want %v", got, want)
+	}
+
+	want = map[float64]int{0: 3, 5: 1}
+	if got := MaturityHistogram(bonds, 5); !maps.Equal(got, want) {
+		t.Errorf("MaturityHistogram() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrMaturityMismatch = fmt.Errorf("maturity dates do not match")
+)
+
+// BreakEvenMaturityTolerance is the maximum difference between the maturity dates
+// of a conventional and index-linked gilt for them to be compared.
+const BreakEvenMaturityTolerance = 183 * 24 * time.Hour
+
+// BreakEvenInflation calculates the approximate break-even inflation rate, the difference
+// between the nominal yield of a conventional gilt and the real yield of an index-linked
+// gilt with a similar maturity.
+//
+// Both bonds must have their yields to maturity calculated.
+//
+// Parameters:
+//
+//	conventional: The conventional gilt.
+//	indexLinked:  The index-linked gilt, its yield to maturity is the real yield.
+//
+// Returns:
+//
+//	Break-even inflation rate as a percentage.
+func BreakEvenInflation(conventional, indexLinked *types.Bond) (float64, error) {
+	if conventional == nil || indexLinked == nil {
+		return 0, types.ErrNilBond
+	}
+
+	if conventional.Type == types.UKIndexLinkedGilt || indexLinked.Type != types.UKIndexLinkedGilt {
+		return 0, types.ErrUnsupportedBond
+	}
+
+	if conventional.MaturityDate.IsZero() || indexLinked.MaturityDate.IsZero() {
+		return 0, types.ErrInvalidMaturityDate
+	}
+
+	diff := conventional.MaturityDate.Sub(indexLinked.MaturityDate)
+	if math.Abs(float64(diff)) > float64(BreakEvenMaturityTolerance) {
+		return 0, ErrMaturityMismatch
+	}
+
+	return conventional.YieldToMaturity - indexLinked.YieldToMaturity, nil
+}
@@ -0,0 +1,44 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBreakEvenInflation(t *testing.T) {
+	bond := func(bondType types.BondType, maturity time.Time, ytm float64) *types.Bond {
+		return &types.Bond{Type: bondType, MaturityDate: maturity, YieldToMaturity: ytm}
+	}
+
+	conventional := bond(types.UKGilt, time.Date(2033, 1, 31, 0, 0, 0, 0, time.UTC), 4.45)
+
+	tests := []struct {
+		name        string
+		indexLinked *types.Bond
+		want        float64
+		wantErr     error
+	}{
+		{name: "same maturity", indexLinked: bond(types.UKIndexLinkedGilt, conventional.MaturityDate, 0.95), want: 3.5},
+		{name: "within tolerance", indexLinked: bond(types.UKIndexLinkedGilt, time.Date(2032, 11, 22, 0, 0, 0, 0, time.UTC), 1.2), want: 3.25},
+		{name: "outside tolerance", indexLinked: bond(types.UKIndexLinkedGilt, time.Date(2032, 3, 22, 0, 0, 0, 0, time.UTC), 1.2), wantErr: ErrMaturityMismatch},
+		{name: "not index-linked", indexLinked: bond(types.UKGilt, conventional.MaturityDate, 0.95), wantErr: types.ErrUnsupportedBond},
+		{name: "no maturity", indexLinked: bond(types.UKIndexLinkedGilt, time.Time{}, 0.95), wantErr: types.ErrInvalidMaturityDate},
+		{name: "nil", wantErr: types.ErrNilBond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BreakEvenInflation(conventional, tt.indexLinked)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BreakEvenInflation() error = %v, want %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("BreakEvenInflation() = %.4f, want %.4f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	ErrMaturityOutOfRange = fmt.Errorf("target maturity is outside the range of the bonds")
+)
+
+// InterpolatedYield estimates the market yield at a maturity date, e.g. exactly 10 years, by
+// linear interpolation between the yields of the bonds with the nearest maturities either side.
+// The interpolation is linear in the time to maturity. A bond maturing on the target date
+// gives its yield. The bonds must be completed.
+//
+// Parameters:
+//
+//	bonds:          The bonds, in any order.
+//	targetMaturity: The maturity date to estimate the yield at.
+//
+// Returns:
+//
+//	The interpolated yield to maturity (as a percentage), ErrMaturityOutOfRange if no bonds
+//	mature on or either side of the target.
+func InterpolatedYield(bonds []*types.Bond, targetMaturity time.Time) (float64, error) {
+	sorted := make([]*types.Bond, 0, len(bonds))
+	for _, b := range bonds {
+		if b != nil && !b.MaturityDate.IsZero() {
+			sorted = append(sorted, b)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MaturityDate.Before(sorted[j].MaturityDate)
+	})
+
+	// the first bond maturing on or after the target
+	i := sort.Search(len(sorted), func(i int) bool {
+		return !sorted[i].MaturityDate.Before(targetMaturity)
+	})
+
+	if i == len(sorted) {
+		return 0, ErrMaturityOutOfRange
+	}
+
+	hi := sorted[i]
+	if hi.MaturityDate.Equal(targetMaturity) {
+		return hi.YieldToMaturity, nil
+	}
+
+	if i == 0 {
+		return 0, ErrMaturityOutOfRange
+	}
+
+	lo := sorted[i-1]
+
+	// the fraction of the time between the maturities, the same in years as in any other unit
+	w := float64(targetMaturity.Sub(lo.MaturityDate)) / float64(hi.MaturityDate.Sub(lo.MaturityDate))
+
+	return lo.YieldToMaturity + w*(hi.YieldToMaturity-lo.YieldToMaturity), nil
+}
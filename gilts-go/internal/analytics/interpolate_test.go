@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestInterpolatedYield(t *testing.T) {
+	date := func(year int) time.Time {
+		return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	// in any order
+	bonds := []*types.Bond{
+		{MaturityDate: date(2036), YieldToMaturity: 4.6},
+		{MaturityDate: date(2030), YieldToMaturity: 4.0},
+		{MaturityDate: date(2040), YieldToMaturity: 5.0},
+	}
+
+	tests := []struct {
+		name    string
+		target  time.Time
+		want    float64
+		wantErr error
+	}{
+		// 1096 of the 2191 days from 2030 to 2036
+		{name: "between the first two", target: date(2033), want: 4.0 + 0.6*1096/2191},
+		{name: "between the last two", target: date(2038), want: 4.6 + 0.4*731/1461},
+		{name: "on a maturity", target: date(2036), want: 4.6},
+		{name: "first maturity", target: date(2030), want: 4.0},
+		{name: "before the first", target: date(2029), wantErr: ErrMaturityOutOfRange},
+		{name: "after the last", target: date(2041), wantErr: ErrMaturityOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InterpolatedYield(bonds, tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InterpolatedYield() error = %v, want %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("InterpolatedYield() = %.8f, want %.8f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrInvalidLadder = fmt.Errorf("invalid ladder")
+)
+
+// LadderRung is the gilt bought for a year of a ladder.
+type LadderRung struct {
+	Year    int
+	Bond    *types.Bond
+	Nominal float64
+	// Cost is the settlement cost of the nominal including accrued interest.
+	Cost float64
+}
+
+// Ladder is a gilt ladder, a gilt maturing in each year so the redemptions and coupons
+// provide an income in each year of the range.
+type Ladder struct {
+	Income float64
+	Rungs  []LadderRung
+	// MissingYears are the years with no gilt maturing, the income isn't met in these years.
+	MissingYears []int
+	// TotalCost is the settlement cost of all the rungs.
+	TotalCost float64
+	// BlendedYield is the cost weighted yield to maturity of the rungs (as a percentage).
+	BlendedYield float64
+}
+
+// SelectLadderGilts selects a gilt maturing in each year of the range. The heuristic is the
+// highest yield to maturity gilt maturing in the year, ties are broken by the earliest maturity.
+// Strips are excluded as they pay no coupons.
+//
+// Parameters:
+//
+//	bonds:    The completed bonds to select from.
+//	fromYear: The first year of the ladder.
+//	toYear:   The last year of the ladder (inclusive).
+//
+// Returns:
+//
+//	The selected gilt for each year, years without a maturing gilt are missing.
+func SelectLadderGilts(bonds []*types.Bond, fromYear, toYear int) map[int]*types.Bond {
+	selected := map[int]*types.Bond{}
+
+	for _, b := range bonds {
+		if b.Strip || b.YieldToMaturity == 0 || !b.MaturityDate.After(b.SettlementDate) {
+			continue
+		}
+
+		year := b.MaturityDate.Year()
+		if year < fromYear || year > toYear {
+			continue
+		}
+
+		curr, ok := selected[year]
+		if !ok ||
+			b.YieldToMaturity > curr.YieldToMaturity ||
+			(b.YieldToMaturity == curr.YieldToMaturity && b.MaturityDate.Before(curr.MaturityDate)) {
+			selected[year] = b
+		}
+	}
+
+	return selected
+}
+
+// cashInYear returns the cash paid in a calendar year per unit of nominal of a bond, the
+// coupons paid after the settlement date plus the redemption in the maturity year.
+func cashInYear(b *types.Bond, year int) float64 {
+	cash := 0.0
+
+	if b.MaturityDate.Year() == year {
+		cash += 1
+	}
+
+	freq := b.Frequency()
+	coupon := b.Coupon / 100 / float64(freq)
+
+	for i := 0; ; i++ {
+		d := types.AddMonthsClamped(b.MaturityDate, -12/freq*i, b.MaturityDate.Day())
+		if !d.After(b.SettlementDate) || d.Year() < year {
+			break
+		}
+		if d.Year() == year {
+			cash += coupon
+		}
+	}
+
+	return cash
+}
+
+// BuildLadder builds a gilt ladder providing an annual income from the redemptions and
+// coupons of a gilt maturing in each year of the range, selected with SelectLadderGilts.
+// The nominals are calculated from the last year back so the coupons of the later rungs
+// reduce the nominal needed in the earlier years.
+//
+// Parameters:
+//
+//	bonds:    The completed bonds to select from.
+//	income:   The income required in each year.
+//	fromYear: The first year of the ladder.
+//	toYear:   The last year of the ladder (inclusive).
+//
+// Returns:
+//
+//	The ladder.
+func BuildLadder(bonds []*types.Bond, income float64, fromYear, toYear int) (*Ladder, error) {
+	if income <= 0 || toYear < fromYear {
+		return nil, ErrInvalidLadder
+	}
+
+	selected := SelectLadderGilts(bonds, fromYear, toYear)
+
+	ladder := &Ladder{Income: income}
+
+	for year := toYear; year >= fromYear; year-- {
+		b, ok := selected[year]
+		if !ok {
+			ladder.MissingYears = append(ladder.MissingYears, year)
+			continue
+		}
+
+		// the cash already provided in the year by the later rungs
+		provided := 0.0
+		for _, r := range ladder.Rungs {
+			provided += r.Nominal * cashInYear(r.Bond, year)
+		}
+
+		nominal := 0.0
+		if need := income - provided; need > 0 {
+			nominal = need / cashInYear(b, year)
+		}
+
+		ladder.Rungs = append(ladder.Rungs, LadderRung{
+			Year:    year,
+			Bond:    b,
+			Nominal: nominal,
+			Cost:    types.SettlementCost(b, nominal),
+		})
+	}
+
+	sort.Slice(ladder.Rungs, func(i, j int) bool { return ladder.Rungs[i].Year < ladder.Rungs[j].Year })
+	sort.Ints(ladder.MissingYears)
+
+	weighted := 0.0
+	for _, r := range ladder.Rungs {
+		ladder.TotalCost += r.Cost
+		weighted += r.Cost * r.Bond.YieldToMaturity
+	}
+
+	if ladder.TotalCost > 0 {
+		ladder.BlendedYield = weighted / ladder.TotalCost
+	}
+
+	return ladder, nil
+}
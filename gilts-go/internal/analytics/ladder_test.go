@@ -0,0 +1,97 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBuildLadder(t *testing.T) {
+	settlement := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	bond := func(isin string, coupon float64, maturity time.Time, ytm, cleanPrice, accrued float64) *types.Bond {
+		b := types.NewUKGiltWithMaturity("test", settlement, coupon, maturity)
+		b.ISIN = isin
+		b.YieldToMaturity = ytm
+		b.CleanPrice = cleanPrice
+		b.AccruedAmount = accrued
+		return b
+	}
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	bonds := []*types.Bond{
+		bond("GB1", 2, date(2026, 6, 7), 4.0, 97, 0.5),
+		// the highest yield maturing in 2026, paying coupons on 7 March and 7 September
+		bond("GB2", 4, date(2026, 9, 7), 4.2, 99, 1),
+		// the same yield as GB2 but a later maturity
+		bond("GB3", 4.5, date(2026, 12, 7), 4.2, 100.5, 1.2),
+		// the only gilt maturing in 2027, paying coupons on 7 June and 7 December
+		bond("GB4", 3, date(2027, 12, 7), 4.1, 98, 0.75),
+		// strips pay no coupons
+		bond("GB5", 0, date(2027, 3, 7), 5.0, 90, 0),
+		// nothing matures in 2028
+		bond("GB6", 4.75, date(2030, 12, 7), 4.5, 101, 1.17),
+	}
+	bonds[4].Strip = true
+
+	selected := SelectLadderGilts(bonds, 2026, 2028)
+	if len(selected) != 2 || selected[2026] != bonds[1] || selected[2027] != bonds[3] {
+		t.Errorf("SelectLadderGilts() = %v, want GB2 in 2026 and GB4 in 2027", selected)
+	}
+
+	ladder, err := BuildLadder(bonds, 5_000, 2026, 2028)
+	if err != nil {
+		t.Fatalf("BuildLadder() error = %v", err)
+	}
+
+	// 2027 is GB4's redemption and two 1.5 coupons per unit, 2026 is GB2's redemption and
+	// two 2 coupons less the GB4 coupons paid in 2026
+	gb4 := 5_000 / 1.03
+	gb2 := (5_000 - gb4*0.03) / 1.04
+
+	want := []LadderRung{
+		{Year: 2026, Bond: bonds[1], Nominal: gb2, Cost: gb2 / 100 * 100},
+		{Year: 2027, Bond: bonds[3], Nominal: gb4, Cost: gb4 / 100 * 98.75},
+	}
+	if len(ladder.Rungs) != len(want) {
+		t.Fatalf("got %d rungs, want %d", len(ladder.Rungs), len(want))
+	}
+	for i, w := range want {
+		r := ladder.Rungs[i]
+		if r.Year != w.Year || r.Bond != w.Bond || math.Abs(r.Nominal-w.Nominal) > 1e-9 || math.Abs(r.Cost-w.Cost) > 1e-9 {
+			t.Errorf("rung %d = %d %s nominal %v cost %v, want %d %s nominal %v cost %v",
+				i, r.Year, r.Bond.ISIN, r.Nominal, r.Cost, w.Year, w.Bond.ISIN, w.Nominal, w.Cost)
+		}
+	}
+
+	if !slices.Equal(ladder.MissingYears, []int{2028}) {
+		t.Errorf("MissingYears = %v, want [2028]", ladder.MissingYears)
+	}
+
+	totalCost := want[0].Cost + want[1].Cost
+	blendedYield := (want[0].Cost*4.2 + want[1].Cost*4.1) / totalCost
+	if math.Abs(ladder.TotalCost-totalCost) > 1e-9 || math.Abs(ladder.BlendedYield-blendedYield) > 1e-12 {
+		t.Errorf("TotalCost = %v, BlendedYield = %v, want %v, %v", ladder.TotalCost, ladder.BlendedYield, totalCost, blendedYield)
+	}
+
+	invalid := []struct {
+		income float64
+		from   int
+		to     int
+	}{
+		{income: 0, from: 2026, to: 2028},
+		{income: 5_000, from: 2028, to: 2026},
+	}
+	for _, tt := range invalid {
+		if _, err := BuildLadder(bonds, tt.income, tt.from, tt.to); !errors.Is(err, ErrInvalidLadder) {
+			t.Errorf("BuildLadder(%v, %d, %d) error = %v, want %v", tt.income, tt.from, tt.to, err, ErrInvalidLadder)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxDescriptionDistance is the maximum distance of a bond from a description query for
+// it to match, the difference in coupon (as a percentage) plus the difference in maturity years.
+const MaxDescriptionDistance = 2.0
+
+// yearRe matches a maturity year in a description query.
+var yearRe = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+// FindByDescription finds the bonds matching a rough description such as "4% 2030" or
+// "4¼% Treasury 2036". The coupon and maturity year are extracted from the query, either
+// can be omitted, and the bonds are ranked by their distance from them, the difference in
+// coupon plus the difference in maturity years. Bonds at the same distance keep their order.
+//
+// Parameters:
+//
+//	bonds: The bonds to search.
+//	query: The description, the coupon percentage and/or maturity year.
+//
+// Returns:
+//
+//	The matching bonds closest first, nil if the query has no coupon or year.
+func FindByDescription(bonds []*types.Bond, query string) []*types.Bond {
+	year := 0
+	if match := yearRe.FindString(query); match != "" {
+		year, _ = strconv.Atoi(match)
+		query = strings.Replace(query, match, "", 1)
+	}
+
+	// the coupon is parsed in the same way as the gilt descriptions so fractions like 4¼% match
+	coupon, err := types.ParseCouponPercentage(strings.TrimSpace(query))
+	hasCoupon := err == nil
+
+	if year == 0 && !hasCoupon {
+		return nil
+	}
+
+	type candidate struct {
+		bond     *types.Bond
+		distance float64
+	}
+
+	matches := []candidate{}
+
+	for _, b := range bonds {
+		distance := 0.0
+		if hasCoupon {
+			distance += math.Abs(b.Coupon - coupon)
+		}
+		if year != 0 {
+			distance += math.Abs(float64(b.MaturityDate.Year() - year))
+		}
+
+		if distance <= MaxDescriptionDistance {
+			matches = append(matches, candidate{bond: b, distance: distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+
+	found := make([]*types.Bond, len(matches))
+	for i, m := range matches {
+		found[i] = m.bond
+	}
+
+	return found
+}
@@ -0,0 +1,60 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+
+	"testing"
+	"time"
+)
+
+// gilt returns a bond with the coupon maturing on 7 December of the year.
+func gilt(isin string, coupon float64, year int) *types.Bond {
+	return &types.Bond{
+		ISIN:         isin,
+		Coupon:       coupon,
+		MaturityDate: time.Date(year, 12, 7, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFindByDescription(t *testing.T) {
+	bonds := []*types.Bond{
+		gilt("GB3", 3.75, 2030),
+		gilt("GB4", 4, 2030),
+		gilt("GB5", 4.25, 2036),
+		gilt("GB6", 0.5, 2061),
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// the 3¾% 2030 is a quarter point away, the 4¼% 2036 is too far
+		{query: "4% 2030", want: []string{"GB4", "GB3"}},
+		{query: "4¼% Treasury 2036", want: []string{"GB5"}},
+		{query: "2030", want: []string{"GB3", "GB4"}},
+		{query: "4%", want: []string{"GB4", "GB3", "GB5"}},
+		{query: "0½% 2061", want: []string{"GB6"}},
+		{query: "Treasury", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			found := FindByDescription(bonds, tt.query)
+
+			got := []string{}
+			for _, b := range found {
+				got = append(got, b.ISIN)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("FindByDescription(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("FindByDescription(%q) = %v, want %v", tt.query, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
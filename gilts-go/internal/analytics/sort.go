@@ -0,0 +1,70 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKey is the key bonds are sorted by.
+type SortKey int
+
+const (
+	// SortByMaturity sorts by the maturity date, earliest first.
+	SortByMaturity SortKey = iota
+	// SortByYield sorts by the yield to maturity, lowest first.
+	SortByYield
+	// SortByCoupon sorts by the coupon rate, lowest first.
+	SortByCoupon
+	// SortByISIN sorts by the ISIN.
+	SortByISIN
+)
+
+var (
+	ErrInvalidSortKey = fmt.Errorf("invalid sort key")
+)
+
+// ParseSortKey parses a sort key name, either "maturity", "yield", "coupon" or "isin".
+// An empty name is the maturity date.
+func ParseSortKey(s string) (SortKey, error) {
+	switch strings.ToLower(s) {
+	case "", "maturity":
+		return SortByMaturity, nil
+	case "yield":
+		return SortByYield, nil
+	case "coupon":
+		return SortByCoupon, nil
+	case "isin":
+		return SortByISIN, nil
+	}
+	return SortByMaturity, ErrInvalidSortKey
+}
+
+// SortBonds sorts the bonds in place by the key. The sort is stable so bonds with equal
+// keys keep their order, sorting by one key then another orders by the second key then
+// the first. The bonds are unchanged if the key is invalid, callers validate the key
+// with ParseSortKey.
+//
+// Parameters:
+//
+//	bonds: The bonds to sort.
+//	by:    The key to sort by.
+func SortBonds(bonds []*types.Bond, by SortKey) {
+	var less func(a, b *types.Bond) bool
+
+	switch by {
+	case SortByMaturity:
+		less = func(a, b *types.Bond) bool { return a.MaturityDate.Before(b.MaturityDate) }
+	case SortByYield:
+		less = func(a, b *types.Bond) bool { return a.YieldToMaturity < b.YieldToMaturity }
+	case SortByCoupon:
+		less = func(a, b *types.Bond) bool { return a.Coupon < b.Coupon }
+	case SortByISIN:
+		less = func(a, b *types.Bond) bool { return a.ISIN < b.ISIN }
+	default:
+		return
+	}
+
+	sort.SliceStable(bonds, func(i, j int) bool { return less(bonds[i], bonds[j]) })
+}
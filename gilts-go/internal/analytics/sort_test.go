@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"benritz/gilts/internal/types"
+
+	"errors"
+	"slices"
+	"testing"
+)
+
+func isins(bonds []*types.Bond) []string {
+	s := []string{}
+	for _, b := range bonds {
+		s = append(s, b.ISIN)
+	}
+	return s
+}
+
+func TestSortBonds(t *testing.T) {
+	bond := func(isin string, coupon float64, year int, ytm float64) *types.Bond {
+		b := gilt(isin, coupon, year)
+		b.YieldToMaturity = ytm
+		return b
+	}
+
+	// GB2 and GB4 have the same yield, GB1 and GB3 the same maturity
+	newBonds := func() []*types.Bond {
+		return []*types.Bond{
+			bond("GB4", 4, 2032, 4.5),
+			bond("GB1", 0.5, 2030, 4.3),
+			bond("GB2", 6, 2029, 4.5),
+			bond("GB3", 4.25, 2030, 4.1),
+		}
+	}
+
+	tests := []struct {
+		name string
+		keys []SortKey
+		want []string
+	}{
+		{name: "maturity", keys: []SortKey{SortByMaturity}, want: []string{"GB2", "GB1", "GB3", "GB4"}},
+		{name: "yield", keys: []SortKey{SortByYield}, want: []string{"GB3", "GB1", "GB4", "GB2"}},
+		{name: "coupon", keys: []SortKey{SortByCoupon}, want: []string{"GB1", "GB4", "GB3", "GB2"}},
+		{name: "isin", keys: []SortKey{SortByISIN}, want: []string{"GB1", "GB2", "GB3", "GB4"}},
+		// the equal yields stay in maturity order
+		{name: "maturity then yield", keys: []SortKey{SortByMaturity, SortByYield}, want: []string{"GB3", "GB1", "GB2", "GB4"}},
+		// the equal maturities stay in yield order
+		{name: "yield then maturity", keys: []SortKey{SortByYield, SortByMaturity}, want: []string{"GB2", "GB3", "GB1", "GB4"}},
+		{name: "invalid key", keys: []SortKey{SortKey(-1)}, want: []string{"GB4", "GB1", "GB2", "GB3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// sorting the same input always gives the same order
+			for range 3 {
+				bonds := newBonds()
+				for _, key := range tt.keys {
+					SortBonds(bonds, key)
+				}
+
+				if got := isins(bonds); !slices.Equal(got, tt.want) {
+					t.Fatalf("SortBonds() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	for name, want := range map[string]SortKey{"": SortByMaturity, "maturity": SortByMaturity, "Yield": SortByYield, "coupon": SortByCoupon, "isin": SortByISIN} {
+		if got, err := ParseSortKey(name); err != nil || got != want {
+			t.Errorf("ParseSortKey(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseSortKey("price"); !errors.Is(err, ErrInvalidSortKey) {
+		t.Errorf("ParseSortKey(price) error = %v, want %v", err, ErrInvalidSortKey)
+	}
+}
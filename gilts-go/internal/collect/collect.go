@@ -6,13 +6,19 @@ import (
 	"time"
 
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/parquet-go/parquet-go"
 )
 
@@ -23,22 +29,28 @@ var (
 type CollectedBond struct {
 	Bond *types.Bond
 	Err  error
+
+	// publishedAccrued is the accrued interest published by the source, zero if not published
+	publishedAccrued float64
 }
 
+// SetError records an error for the bond. Errors for multiple fields are accumulated
+// with errors.Join so each can still be matched with errors.Is.
 func (c *CollectedBond) SetError(err error) {
-	if c.Err != nil {
-		c.Err = err
-	}
+	c.Err = errors.Join(c.Err, err)
 }
 
 type CollectedBonds struct {
 	Bonds          []*types.Bond
 	Failures       []*CollectedBond
+	Quality        *QualityReport
 	Source         string
 	SettlementDate time.Time
 }
 
 func (c *CollectedBonds) AddBond(cb *CollectedBond) {
+	c.Quality.AddBond(cb)
+
 	if cb.Err == nil {
 		c.Bonds = append(c.Bonds, cb.Bond)
 	} else {
@@ -52,6 +64,7 @@ func NewCollectedBonds(source string, date time.Time) *CollectedBonds {
 		SettlementDate: date,
 		Bonds:          []*types.Bond{},
 		Failures:       []*CollectedBond{},
+		Quality:        NewQualityReport(),
 	}
 }
 
@@ -60,36 +73,214 @@ type Collector interface {
 	Source() string
 }
 
-func writeBonds(bonds []*types.Bond, output io.Writer) error {
-	writer := parquet.NewGenericWriter[*types.Bond](output)
-	defer writer.Close()
+// SchemaVersion is the version of the stored bond schema (the fields of types.Bond),
+// it is written to the parquet key/value metadata under SchemaVersionKey.
+//
+// Evolution policy: the version is incremented whenever a field is added to types.Bond.
+// Fields are only ever added, never renamed or removed, so files written with an older
+// version can still be read, the missing columns are read as zero values. Files written
+// before versioning was introduced have no version metadata and are treated as version 0.
+const SchemaVersion = 14
+
+// SchemaVersionKey is the parquet key/value metadata key of the schema version.
+const SchemaVersionKey = "gilts.schema_version"
+
+// BondWriter writes bonds to parquet one at a time so large datasets can be
+// streamed without holding all the bonds in memory.
+type BondWriter struct {
+	writer *parquet.GenericWriter[*types.Bond]
+}
+
+func NewBondWriter(output io.Writer) *BondWriter {
+	return &BondWriter{
+		writer: parquet.NewGenericWriter[*types.Bond](
+			output,
+			parquet.KeyValueMetadata(SchemaVersionKey, strconv.Itoa(SchemaVersion)),
+		),
+	}
+}
+
+func (w *BondWriter) Write(b *types.Bond) error {
+	if _, err := w.writer.Write([]*types.Bond{b}); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	return nil
+}
 
-	if _, err := writer.Write(bonds); err != nil {
-		return fmt.Errorf("failed to write records: %w", err)
+// Close flushes any buffered bonds and writes the parquet footer.
+// It does not close the underlying writer.
+func (w *BondWriter) Close() error {
+	if err := w.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
 	}
 
 	return nil
 }
 
-func StoreToPath(ctx context.Context, collected *CollectedBonds, basepath string) (string, error) {
-	date := collected.SettlementDate
+func writeBonds(bonds []*types.Bond, output io.Writer) error {
+	writer := NewBondWriter(output)
 
-	path := fmt.Sprintf(
-		"%s%c%04d%c%02d%c%02d",
-		basepath,
-		filepath.Separator,
-		date.UTC().Year(),
-		filepath.Separator,
-		date.UTC().Month(),
-		filepath.Separator,
-		date.UTC().Day(),
-	)
+	for _, b := range bonds {
+		if err := writer.Write(b); err != nil {
+			writer.Close()
+			return err
+		}
+	}
 
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
-		return "", err
+	return writer.Close()
+}
+
+// KeyLayout is the layout of the directories/keys the collected bonds are stored under.
+type KeyLayout int
+
+const (
+	// DatePrefixed stores bonds under YYYY/MM/DD/source.parquet
+	DatePrefixed KeyLayout = iota
+	// HivePartitioned stores bonds under source=.../year=YYYY/month=MM/day=DD/source.parquet
+	// which can be used directly as partitions by Athena/Glue.
+	HivePartitioned
+)
+
+var (
+	ErrInvalidKeyLayout = fmt.Errorf("invalid key layout")
+	ErrInvalidKey       = fmt.Errorf("invalid key")
+)
+
+// ParseKeyLayout parses a key layout name, either "date" or "hive".
+func ParseKeyLayout(s string) (KeyLayout, error) {
+	switch strings.ToLower(s) {
+	case "", "date":
+		return DatePrefixed, nil
+	case "hive":
+		return HivePartitioned, nil
+	}
+	return DatePrefixed, ErrInvalidKeyLayout
+}
+
+// fileName returns the name of the parquet file for a source, with the variant (e.g. raw or
+// enriched) before the extension if not empty.
+func fileName(source string, variant string) string {
+	if variant == "" {
+		return fmt.Sprintf("%s.parquet", source)
+	}
+	return fmt.Sprintf("%s.%s.parquet", source, variant)
+}
+
+// keyParts returns the directory and file name parts of the key the collected bonds are stored under.
+func keyParts(source string, date time.Time, layout KeyLayout, variant string) []string {
+	date = date.UTC()
+
+	switch layout {
+	case HivePartitioned:
+		return []string{
+			fmt.Sprintf("source=%s", source),
+			fmt.Sprintf("year=%04d", date.Year()),
+			fmt.Sprintf("month=%02d", date.Month()),
+			fmt.Sprintf("day=%02d", date.Day()),
+			fileName(source, variant),
+		}
+	default:
+		return []string{
+			fmt.Sprintf("%04d", date.Year()),
+			fmt.Sprintf("%02d", date.Month()),
+			fmt.Sprintf("%02d", date.Day()),
+			fileName(source, variant),
+		}
+	}
+}
+
+// localPath returns the path of the file the bonds for a source and date are stored to under the base path.
+func localPath(basepath string, source string, date time.Time, layout KeyLayout, variant string) string {
+	parts := keyParts(source, date, layout, variant)
+	return filepath.Join(append([]string{basepath}, parts...)...)
+}
+
+// s3Key returns the key of the object the bonds for a source and date are stored to.
+func s3Key(dst *S3Path, source string, date time.Time, layout KeyLayout, variant string) string {
+	key := strings.Join(keyParts(source, date, layout, variant), "/")
+
+	if dst.Prefix != "" {
+		key = fmt.Sprintf("%s/%s", dst.Prefix, key)
 	}
 
-	outPath := fmt.Sprintf("%s%c%s.parquet", path, filepath.Separator, collected.Source)
+	return key
+}
+
+// StoredKey is the location of stored bonds parsed from their key.
+type StoredKey struct {
+	// Prefix is the prefix the bonds are stored under, empty if none.
+	Prefix string
+	Source string
+	Date   time.Time
+	Layout KeyLayout
+	// Variant is the variant of the bonds, e.g. raw or enriched, empty for the source's file.
+	Variant string
+}
+
+// ParseKey parses the key of bonds stored in either layout, the inverse of s3Key, so a variant
+// can be stored alongside bonds stored by another process.
+//
+// Parameters:
+//
+//	key: The key, e.g. data/2025/03/07/dmo.parquet.
+//
+// Returns:
+//
+//	The location of the bonds, ErrInvalidKey if the key isn't in either layout.
+func ParseKey(key string) (*StoredKey, error) {
+	parts := strings.Split(key, "/")
+
+	name, ok := strings.CutSuffix(parts[len(parts)-1], ".parquet")
+	source, variant, _ := strings.Cut(name, ".")
+	if !ok || source == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, key)
+	}
+
+	layouts := []struct {
+		layout KeyLayout
+		format string
+	}{
+		{DatePrefixed, "2006/01/02"},
+		{HivePartitioned, fmt.Sprintf("source=%s/year=2006/month=01/day=02", source)},
+	}
+
+	for _, l := range layouts {
+		n := strings.Count(l.format, "/") + 1
+		if len(parts) <= n {
+			continue
+		}
+
+		dir := parts[len(parts)-1-n : len(parts)-1]
+		date, err := time.Parse(l.format, strings.Join(dir, "/"))
+		if err != nil {
+			continue
+		}
+
+		return &StoredKey{
+			Prefix:  strings.Join(parts[:len(parts)-1-n], "/"),
+			Source:  source,
+			Date:    date,
+			Layout:  l.layout,
+			Variant: variant,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrInvalidKey, key)
+}
+
+func StoreToPath(ctx context.Context, collected *CollectedBonds, basepath string, layout KeyLayout) (string, error) {
+	return storeVariantToPath(ctx, collected, basepath, layout, "")
+}
+
+// storeVariantToPath stores the collected bonds to the file for the variant, the file
+// for the source if the variant is empty.
+func storeVariantToPath(ctx context.Context, collected *CollectedBonds, basepath string, layout KeyLayout, variant string) (string, error) {
+	outPath := localPath(basepath, collected.Source, collected.SettlementDate, layout, variant)
+
+	if err := os.MkdirAll(filepath.Dir(outPath), os.ModePerm); err != nil {
+		return "", err
+	}
 
 	file, err := os.Create(outPath)
 	if err != nil {
@@ -97,13 +288,21 @@ func StoreToPath(ctx context.Context, collected *CollectedBonds, basepath string
 	}
 	defer file.Close()
 
-	if err := writeBonds(collected.Bonds, file); err != nil {
+	if err := WriteTo(file, collected, FormatParquet); err != nil {
 		return "", err
 	}
 
 	return outPath, nil
 }
 
+// S3API is the subset of the S3 client used to store and load bonds.
+// It is satisfied by *s3.Client and can be faked in tests.
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+}
+
 type S3Path struct {
 	Bucket string
 	Prefix string
@@ -134,7 +333,78 @@ func ParseS3(path string) (*S3Path, error) {
 	}, nil
 }
 
-func StoreToS3(ctx context.Context, collected *CollectedBonds, s3Client *s3.Client, dst *S3Path) (string, error) {
+// StoreOptions are the options used when storing collected bonds to S3.
+type StoreOptions struct {
+	// SkipUnchanged skips the upload if the existing object was stored from the same bonds.
+	SkipUnchanged bool
+	// SSEKMSKeyID is the KMS key used for server-side encryption, the bucket's default encryption is used if empty.
+	SSEKMSKeyID string
+	// StorageClass is the S3 storage class, e.g. STANDARD_IA, the bucket's default is used if empty.
+	StorageClass string
+}
+
+// contentHashMetadataKey is the S3 object metadata key holding the hash of the stored bonds.
+const contentHashMetadataKey = "content-sha256"
+
+// contentHash returns a hash of the bonds.
+//
+// Parquet files are not byte-for-byte deterministic (e.g. writer version and page layout),
+// so the hash of the parquet body can't be used to detect unchanged data. Instead the bonds
+// are hashed using their canonical JSON encoding, which is deterministic as struct fields
+// are always encoded in declaration order.
+func contentHash(bonds []*types.Bond) (string, error) {
+	h := sha256.New()
+
+	if err := json.NewEncoder(h).Encode(bonds); err != nil {
+		return "", fmt.Errorf("failed to hash bonds: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func StoreToS3(
+	ctx context.Context,
+	collected *CollectedBonds,
+	s3Client S3API,
+	dst *S3Path,
+	layout KeyLayout,
+	opts StoreOptions,
+) (string, error) {
+	return storeVariantToS3(ctx, collected, s3Client, dst, layout, opts, "")
+}
+
+// storeVariantToS3 stores the collected bonds to the object for the variant, the object
+// for the source if the variant is empty.
+func storeVariantToS3(
+	ctx context.Context,
+	collected *CollectedBonds,
+	s3Client S3API,
+	dst *S3Path,
+	layout KeyLayout,
+	opts StoreOptions,
+	variant string,
+) (string, error) {
+	key := s3Key(dst, collected.Source, collected.SettlementDate, layout, variant)
+
+	outPath := fmt.Sprintf("s3://%s/%s", dst.Bucket, key)
+
+	// the hash is only used to skip unchanged uploads, if it can't be
+	// calculated (e.g. NaN values) the bonds are always uploaded
+	hash, _ := contentHash(collected.Bonds)
+
+	if opts.SkipUnchanged && hash != "" {
+		head, err := s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(dst.Bucket),
+			Key:    aws.String(key),
+		})
+
+		// any error (not found, access denied) falls through to the upload
+		if err == nil && head.Metadata[contentHashMetadataKey] == hash {
+			fmt.Fprintf(os.Stderr, "Skipping upload, %s is unchanged\n", outPath)
+			return outPath, nil
+		}
+	}
+
 	tmp, err := os.CreateTemp("", "gilt-*.parquet")
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp file: %v", err)
@@ -150,31 +420,30 @@ func StoreToS3(ctx context.Context, collected *CollectedBonds, s3Client *s3.Clie
 		return "", fmt.Errorf("failed to seek to start of file: %w", err)
 	}
 
-	date := collected.SettlementDate
-
-	key := fmt.Sprintf(
-		"%04d/%02d/%02d/%s.parquet",
-		date.UTC().Year(),
-		date.UTC().Month(),
-		date.UTC().Day(),
-		collected.Source,
-	)
-
-	if dst.Prefix != "" {
-		key = fmt.Sprintf("%s/%s", dst.Prefix, key)
-	}
-
 	input := &s3.PutObjectInput{
 		Bucket: aws.String(dst.Bucket),
 		Key:    aws.String(key),
 		Body:   tmp,
 	}
 
+	if opts.SSEKMSKeyID != "" {
+		input.ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(opts.SSEKMSKeyID)
+	}
+
+	if opts.StorageClass != "" {
+		input.StorageClass = s3types.StorageClass(opts.StorageClass)
+	}
+
+	if hash != "" {
+		input.Metadata = map[string]string{
+			contentHashMetadataKey: hash,
+		}
+	}
+
 	if _, err := s3Client.PutObject(ctx, input); err != nil {
 		return "", fmt.Errorf("failed to upload file to s3://%s/%s: %w", dst.Bucket, key, err)
 	}
 
-	outPath := fmt.Sprintf("s3://%s/%s", dst.Bucket, key)
-
 	return outPath, nil
 }
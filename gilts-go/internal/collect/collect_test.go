@@ -0,0 +1,376 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeS3 is an in-memory S3 bucket which keeps the input of each object's upload.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	inputs  map[string]*s3.PutObjectInput
+	puts    int
+}
+
+// the S3 client and the fake both satisfy S3API
+var (
+	_ S3API = (*s3.Client)(nil)
+	_ S3API = (*fakeS3)(nil)
+)
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, inputs: map[string]*s3.PutObjectInput{}}
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[*params.Key] = data
+	f.inputs[*params.Key] = params
+	f.puts++
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, ok := f.objects[*params.Key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data)), Metadata: f.inputs[*params.Key].Metadata}, nil
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.objects[*params.Key]; !ok {
+		return nil, &s3types.NotFound{}
+	}
+
+	return &s3.HeadObjectOutput{Metadata: f.inputs[*params.Key].Metadata}, nil
+}
+
+var testDate = time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+// testCollected returns bonds collected from the DMO on the test date.
+func testCollected() *CollectedBonds {
+	collected := NewCollectedBonds(SourceDMO, testDate)
+
+	for i, coupon := range []float64{3.5, 4.75, 4.25} {
+		b := types.NewUKGiltWithMaturity(SourceDMO, testDate, coupon, time.Date(2025+5*i, 10, 22, 0, 0, 0, 0, time.UTC))
+		b.ISIN = fmt.Sprintf("GB000000000%d", i)
+		b.CleanPrice = 99.5 + float64(i)
+		collected.AddBond(&CollectedBond{Bond: b})
+	}
+
+	return collected
+}
+
+func TestKeyLayout(t *testing.T) {
+	tests := []struct {
+		layout  KeyLayout
+		variant string
+		want    string
+	}{
+		{layout: DatePrefixed, want: "2025/03/07/DMO.parquet"},
+		{layout: DatePrefixed, variant: EnrichedVariant, want: "2025/03/07/DMO.enriched.parquet"},
+		{layout: HivePartitioned, want: "source=DMO/year=2025/month=03/day=07/DMO.parquet"},
+		{layout: HivePartitioned, variant: RawVariant, want: "source=DMO/year=2025/month=03/day=07/DMO.raw.parquet"},
+	}
+
+	for _, tt := range tests {
+		if got := s3Key(&S3Path{Bucket: "gilts"}, SourceDMO, testDate, tt.layout, tt.variant); got != tt.want {
+			t.Errorf("s3Key(%v, %q) = %s, want %s", tt.layout, tt.variant, got, tt.want)
+		}
+
+		if got, want := s3Key(&S3Path{Bucket: "gilts", Prefix: "data"}, SourceDMO, testDate, tt.layout, tt.variant), "data/"+tt.want; got != want {
+			t.Errorf("s3Key(%v, %q) with a prefix = %s, want %s", tt.layout, tt.variant, got, want)
+		}
+
+		if got, want := localPath("out", SourceDMO, testDate, tt.layout, tt.variant), filepath.Join("out", filepath.FromSlash(tt.want)); got != want {
+			t.Errorf("localPath(%v, %q) = %s, want %s", tt.layout, tt.variant, got, want)
+		}
+	}
+
+	// the stores write to the same keys
+	for _, tt := range tests {
+		if tt.variant != "" {
+			continue
+		}
+
+		client := newFakeS3()
+		if _, err := NewS3Store(client, &S3Path{Bucket: "gilts", Prefix: "data"}, tt.layout, StoreOptions{}).Store(t.Context(), testCollected()); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+		if _, ok := client.objects["data/"+tt.want]; !ok || len(client.objects) != 1 {
+			t.Errorf("%v stored %d objects, want data/%s", tt.layout, len(client.objects), tt.want)
+		}
+
+		dir := t.TempDir()
+		path, err := NewPathStore(dir, tt.layout).Store(t.Context(), testCollected())
+		if err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+		if want := filepath.Join(dir, filepath.FromSlash(tt.want)); path != want {
+			t.Errorf("%v stored to %s, want %s", tt.layout, path, want)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	for _, layout := range []KeyLayout{DatePrefixed, HivePartitioned} {
+		for _, prefix := range []string{"", "data", "data/gilts"} {
+			for _, variant := range []string{"", RawVariant, EnrichedVariant} {
+				key := s3Key(&S3Path{Bucket: "gilts", Prefix: prefix}, SourceDMO, date, layout, variant)
+
+				got, err := ParseKey(key)
+				if err != nil {
+					t.Errorf("ParseKey(%s) error = %v", key, err)
+					continue
+				}
+
+				want := StoredKey{Prefix: prefix, Source: SourceDMO, Date: date, Layout: layout, Variant: variant}
+				if *got != want {
+					t.Errorf("ParseKey(%s) = %+v, want %+v", key, *got, want)
+				}
+			}
+		}
+	}
+
+	for _, key := range []string{
+		"dmo.parquet",
+		"data/2025/03/07/dmo.csv",
+		"data/2025/03/dmo.parquet",
+		"data/2025/13/07/dmo.parquet",
+		"data/2025/03/07/.parquet",
+		"source=dividenddata/year=2025/month=03/day=07/dmo.parquet",
+	} {
+		if _, err := ParseKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ParseKey(%s) error = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+}
+
+func TestS3StoreSkipUnchanged(t *testing.T) {
+	client := newFakeS3()
+	dst := &S3Path{Bucket: "gilts", Prefix: "data"}
+	store := NewS3Store(client, dst, DatePrefixed, StoreOptions{SkipUnchanged: true})
+
+	collected := testCollected()
+
+	for i, wantPuts := range []int{1, 1} {
+		path, err := store.Store(t.Context(), collected)
+		if err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+		if path != "s3://gilts/data/2025/03/07/DMO.parquet" || client.puts != wantPuts {
+			t.Errorf("store %d = %s with %d uploads, want %d", i, path, client.puts, wantPuts)
+		}
+	}
+
+	// a changed price is uploaded
+	collected.Bonds[0].CleanPrice = 99.75
+	if _, err := store.Store(t.Context(), collected); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if client.puts != 2 {
+		t.Errorf("got %d uploads, want the changed bonds uploaded", client.puts)
+	}
+
+	// unchanged bonds are uploaded again without the option
+	store = NewS3Store(client, dst, DatePrefixed, StoreOptions{})
+	if _, err := store.Store(t.Context(), collected); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if client.puts != 3 {
+		t.Errorf("got %d uploads, want the bonds uploaded without SkipUnchanged", client.puts)
+	}
+}
+
+func TestBondWriter(t *testing.T) {
+	const n = 100_000
+
+	var buf bytes.Buffer
+	writer := NewBondWriter(&buf)
+
+	// the maturities wrap within 50 years, parquet timestamps are nanoseconds which end in 2262
+	bond := func(i int) *types.Bond {
+		return &types.Bond{
+			Type:         types.UKGilt,
+			Source:       SourceDMO,
+			ISIN:         fmt.Sprintf("GB%010d", i),
+			Coupon:       float64(i%50) / 8,
+			MaturityDate: testDate.AddDate(0, 0, i%18_000),
+			CleanPrice:   50 + float64(i)/1000,
+		}
+	}
+
+	for i := range n {
+		if err := writer.Write(bond(i)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	bonds, err := ReadBonds(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadBonds() error = %v", err)
+	}
+	if len(bonds) != n {
+		t.Fatalf("got %d bonds, want %d", len(bonds), n)
+	}
+
+	for _, i := range []int{0, 1, 4_999, 50_000, 77_777, n - 1} {
+		got, want := bonds[i], bond(i)
+		if got.ISIN != want.ISIN || got.Coupon != want.Coupon || got.CleanPrice != want.CleanPrice ||
+			!got.MaturityDate.Equal(want.MaturityDate) {
+			t.Errorf("bond %d = %s %v %v %v, want %s %v %v %v", i,
+				got.ISIN, got.Coupon, got.CleanPrice, got.MaturityDate,
+				want.ISIN, want.Coupon, want.CleanPrice, want.MaturityDate)
+		}
+	}
+}
+
+func TestS3StoreOptions(t *testing.T) {
+	dst := &S3Path{Bucket: "gilts", Prefix: "data"}
+	key := "data/2025/03/07/DMO.parquet"
+
+	client := newFakeS3()
+	store := NewS3Store(client, dst, DatePrefixed, StoreOptions{SSEKMSKeyID: "alias/gilts", StorageClass: "STANDARD_IA"})
+	if _, err := store.Store(t.Context(), testCollected()); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	input := client.inputs[key]
+	if input.ServerSideEncryption != s3types.ServerSideEncryptionAwsKms ||
+		input.SSEKMSKeyId == nil || *input.SSEKMSKeyId != "alias/gilts" ||
+		input.StorageClass != s3types.StorageClassStandardIa {
+		t.Errorf("PutObject() encryption %q key %v storage class %q, want aws:kms alias/gilts STANDARD_IA",
+			input.ServerSideEncryption, input.SSEKMSKeyId, input.StorageClass)
+	}
+
+	// the bucket's defaults are used without the options
+	client = newFakeS3()
+	store = NewS3Store(client, dst, DatePrefixed, StoreOptions{})
+	if _, err := store.StoreVariant(t.Context(), testCollected(), ""); err != nil {
+		t.Fatalf("StoreVariant() error = %v", err)
+	}
+
+	input = client.inputs[key]
+	if input.ServerSideEncryption != "" || input.SSEKMSKeyId != nil || input.StorageClass != "" {
+		t.Errorf("PutObject() encryption %q key %v storage class %q, want the bucket's defaults",
+			input.ServerSideEncryption, input.SSEKMSKeyId, input.StorageClass)
+	}
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	client := newFakeS3()
+	store := NewS3Store(client, &S3Path{Bucket: "gilts", Prefix: "data"}, HivePartitioned, StoreOptions{})
+
+	exists, err := store.Exists(t.Context(), SourceDMO, testDate)
+	if err != nil || exists {
+		t.Errorf("Exists() before storing = %t, %v, want false", exists, err)
+	}
+
+	if _, err := store.Load(t.Context(), SourceDMO, testDate); !errors.Is(err, ErrBondsNotFound) {
+		t.Errorf("Load() before storing error = %v, want %v", err, ErrBondsNotFound)
+	}
+
+	collected := testCollected()
+	if _, err := store.Store(t.Context(), collected); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	exists, err = store.Exists(t.Context(), SourceDMO, testDate)
+	if err != nil || !exists {
+		t.Errorf("Exists() after storing = %t, %v, want true", exists, err)
+	}
+
+	bonds, err := store.Load(t.Context(), SourceDMO, testDate)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(bonds) != len(collected.Bonds) {
+		t.Fatalf("got %d bonds, want %d", len(bonds), len(collected.Bonds))
+	}
+	for i, b := range bonds {
+		if want := collected.Bonds[i]; b.ISIN != want.ISIN || b.CleanPrice != want.CleanPrice {
+			t.Errorf("bond %d = %s %v, want %s %v", i, b.ISIN, b.CleanPrice, want.ISIN, want.CleanPrice)
+		}
+	}
+
+	// another date isn't stored
+	if _, err := store.Load(t.Context(), SourceDMO, testDate.AddDate(0, 0, 1)); !errors.Is(err, ErrBondsNotFound) {
+		t.Errorf("Load() of another date error = %v, want %v", err, ErrBondsNotFound)
+	}
+}
+
+func TestCollectToLocalPath(t *testing.T) {
+	dir := t.TempDir()
+
+	// a local directory doesn't load the AWS config
+	store, err := NewStore(t.Context(), dir, "missing-profile", DatePrefixed, StoreOptions{})
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	collected, err := NewStubCollector(SourceDMO, testCollected()).Collect(t.Context(), testDate)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	path, err := store.Store(t.Context(), collected)
+	if err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	if want := filepath.Join(dir, "2025", "03", "07", "DMO.parquet"); path != want {
+		t.Errorf("Store() = %s, want %s", path, want)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		t.Fatalf("stored file %s = %v, %v, want a parquet file", path, info, err)
+	}
+
+	bonds, err := ReadBondsFromPath(path)
+	if err != nil {
+		t.Fatalf("ReadBondsFromPath() error = %v", err)
+	}
+	if len(bonds) != len(collected.Bonds) {
+		t.Errorf("got %d bonds, want %d", len(bonds), len(collected.Bonds))
+	}
+
+	exists, err := store.Exists(t.Context(), SourceDMO, testDate)
+	if err != nil || !exists {
+		t.Errorf("Exists() = %t, %v, want true", exists, err)
+	}
+}
@@ -0,0 +1,77 @@
+package collect
+
+import "benritz/gilts/internal/types"
+
+// BondChange is a bond present in both collections whose price or yield has moved.
+type BondChange struct {
+	Prev            *types.Bond
+	Curr            *types.Bond
+	CleanPriceDelta float64
+	YieldDelta      float64
+}
+
+// DiffReport is the difference between two collections of bonds.
+type DiffReport struct {
+	// Added are bonds in the current collection but not the previous, e.g. new issues.
+	Added []*types.Bond
+	// Removed are bonds in the previous collection but not the current, e.g. matured gilts.
+	Removed []*types.Bond
+	// Changed are bonds in both collections with a different clean price or yield.
+	Changed []*BondChange
+}
+
+// bondKey returns the key used to match bonds between collections, the ISIN if
+// available falling back to the ticker for sources without ISINs.
+func bondKey(b *types.Bond) string {
+	if b.ISIN != "" {
+		return b.ISIN
+	}
+	return b.Ticker
+}
+
+// Diff compares two collections of bonds, typically two days of collected data.
+// Added and changed bonds are in the order of the current collection and removed bonds
+// are in the order of the previous collection.
+func Diff(prev, curr *CollectedBonds) *DiffReport {
+	report := &DiffReport{
+		Added:   []*types.Bond{},
+		Removed: []*types.Bond{},
+		Changed: []*BondChange{},
+	}
+
+	prevBonds := make(map[string]*types.Bond, len(prev.Bonds))
+	for _, b := range prev.Bonds {
+		prevBonds[bondKey(b)] = b
+	}
+
+	currBonds := make(map[string]*types.Bond, len(curr.Bonds))
+	for _, b := range curr.Bonds {
+		key := bondKey(b)
+		currBonds[key] = b
+
+		p, ok := prevBonds[key]
+		if !ok {
+			report.Added = append(report.Added, b)
+			continue
+		}
+
+		change := &BondChange{
+			Prev:            p,
+			Curr:            b,
+			CleanPriceDelta: b.CleanPrice - p.CleanPrice,
+			YieldDelta:      b.YieldToMaturity - p.YieldToMaturity,
+		}
+
+		if change.CleanPriceDelta != 0 || change.YieldDelta != 0 {
+			report.Changed = append(report.Changed, change)
+		}
+	}
+
+	for _, b := range prev.Bonds {
+		if _, ok := currBonds[bondKey(b)]; !ok {
+			report.Removed = append(report.Removed, b)
+		}
+	}
+
+	return report
+}
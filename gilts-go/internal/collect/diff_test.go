@@ -0,0 +1,93 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	bond := func(isin, ticker string, cleanPrice, ytm float64) *types.Bond {
+		return &types.Bond{ISIN: isin, Ticker: ticker, CleanPrice: cleanPrice, YieldToMaturity: ytm}
+	}
+
+	prev := NewCollectedBonds(SourceDMO, testDate)
+	prev.Bonds = []*types.Bond{
+		bond("GB0000000001", "", 99.5, 4.25),    // moves
+		bond("GB0000000002", "", 101.25, 4.1),   // unchanged
+		bond("GB0000000003", "", 99.98, 4.6),    // matures
+		bond("", "TG26", 98.75, 4.0),            // matched by ticker, moves
+		bond("GB0000000005", "", 95.0, 4.45),    // only the yield moves
+		bond("GB0000000006", "", 100.125, 4.05), // removed
+	}
+
+	curr := NewCollectedBonds(SourceDMO, testDate.AddDate(0, 0, 1))
+	curr.Bonds = []*types.Bond{
+		bond("GB0000000007", "", 100.0, 4.5), // new issue
+		bond("GB0000000001", "", 99.25, 4.3),
+		bond("GB0000000002", "", 101.25, 4.1),
+		bond("", "TG26", 98.875, 3.95),
+		bond("GB0000000005", "", 95.0, 4.46),
+		bond("", "TG70", 60.5, 4.9), // new issue without an ISIN
+	}
+
+	report := Diff(prev, curr)
+
+	keys := func(bonds []*types.Bond) []string {
+		var keys []string
+		for _, b := range bonds {
+			keys = append(keys, bondKey(b))
+		}
+		return keys
+	}
+
+	wantAdded := []string{"GB0000000007", "TG70"}
+	if got := keys(report.Added); !slices.Equal(got, wantAdded) {
+		t.Errorf("Added = %v, want %v", got, wantAdded)
+	}
+
+	wantRemoved := []string{"GB0000000003", "GB0000000006"}
+	if got := keys(report.Removed); !slices.Equal(got, wantRemoved) {
+		t.Errorf("Removed = %v, want %v", got, wantRemoved)
+	}
+
+	wantChanged := []struct {
+		key        string
+		priceDelta float64
+		yieldDelta float64
+	}{
+		{key: "GB0000000001", priceDelta: -0.25, yieldDelta: 0.05},
+		{key: "TG26", priceDelta: 0.125, yieldDelta: -0.05},
+		{key: "GB0000000005", priceDelta: 0, yieldDelta: 0.01},
+	}
+	if len(report.Changed) != len(wantChanged) {
+		t.Fatalf("got %d changed bonds, want %d", len(report.Changed), len(wantChanged))
+	}
+	for i, want := range wantChanged {
+		got := report.Changed[i]
+		if bondKey(got.Curr) != want.key || bondKey(got.Prev) != want.key {
+			t.Errorf("change %d = %s from %s, want %s", i, bondKey(got.Curr), bondKey(got.Prev), want.key)
+		}
+		if math.Abs(got.CleanPriceDelta-want.priceDelta) > 1e-9 || math.Abs(got.YieldDelta-want.yieldDelta) > 1e-9 {
+			t.Errorf("change %s = %v price %v yield, want %v price %v yield",
+				want.key, got.CleanPriceDelta, got.YieldDelta, want.priceDelta, want.yieldDelta)
+		}
+	}
+
+	// the same collection has no differences
+	same := Diff(curr, curr)
+	if len(same.Added) != 0 || len(same.Removed) != 0 || len(same.Changed) != 0 {
+		t.Errorf("Diff() of the same bonds = %d added, %d removed, %d changed, want none",
+			len(same.Added), len(same.Removed), len(same.Changed))
+	}
+
+	// the first collection is all new issues
+	first := Diff(NewCollectedBonds(SourceDMO, time.Time{}), prev)
+	if len(first.Added) != len(prev.Bonds) || len(first.Removed) != 0 || len(first.Changed) != 0 {
+		t.Errorf("Diff() from no bonds = %d added, %d removed, %d changed, want %d added",
+			len(first.Added), len(first.Removed), len(first.Changed), len(prev.Bonds))
+	}
+}
@@ -3,6 +3,10 @@ package collect
 import (
 	"benritz/gilts/internal/types"
 	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -15,13 +19,54 @@ var (
 )
 
 type DividendDataCollector struct {
+	priceScale PriceScale
+	metrics    io.Writer
 }
 
-func NewDividendDataCollector() *DividendDataCollector {
-	return &DividendDataCollector{}
+type DividendDataOption func(*DividendDataCollector)
+
+// WithDividendDataPriceScale sets how the page quotes prices, defaults to PricePerHundred.
+func WithDividendDataPriceScale(scale PriceScale) DividendDataOption {
+	return func(c *DividendDataCollector) {
+		c.priceScale = scale
+	}
+}
+
+// WithDividendDataMetricsOutput sets where the collection metrics are written, defaults to
+// stderr so they aren't mixed into a command's output.
+func WithDividendDataMetricsOutput(w io.Writer) DividendDataOption {
+	return func(c *DividendDataCollector) {
+		c.metrics = w
+	}
+}
+
+func init() {
+	Register("dividenddata", func() Collector { return NewDividendDataCollector() })
+}
+
+func NewDividendDataCollector(opts ...DividendDataOption) *DividendDataCollector {
+	c := &DividendDataCollector{
+		metrics: os.Stderr,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (c *DividendDataCollector) Collect(ctx context.Context, date time.Time) (*CollectedBonds, error) {
+	start := time.Now()
+
+	collected, err := c.collect(ctx, date)
+
+	emitCollectMetrics(c.metrics, SourceDividendData, collected, err, start)
+
+	return collected, err
+}
+
+func (c *DividendDataCollector) collect(ctx context.Context, date time.Time) (*CollectedBonds, error) {
 	x := colly.NewCollector()
 
 	// check page date matches requested date
@@ -39,6 +84,7 @@ func (c *DividendDataCollector) Collect(ctx context.Context, date time.Time) (*C
 	collected := NewCollectedBonds(SourceDividendData, date)
 
 	x.OnHTML("#mainbody tr", func(e *colly.HTMLElement) {
+		collected.Quality.AddRow()
 		cb := c.readBond(e)
 		if cb != nil {
 			collected.AddBond(cb)
@@ -55,9 +101,65 @@ func (c *DividendDataCollector) Collect(ctx context.Context, date time.Time) (*C
 		return nil, types.ErrDataUnavailable
 	}
 
+	collected.Bonds = resolveTickerCollisions(collected.Bonds, collected.Quality)
+
 	return collected, nil
 }
 
+// resolveTickerCollisions keeps one bond per ticker, the page identifies the gilts by ticker
+// only and a reopened gilt can be listed more than once. The canonical bond is the one matching
+// the coupon and maturity of the reference data for the ticker, otherwise the first listed.
+// The other bonds are logged and recorded as duplicates in the quality report.
+func resolveTickerCollisions(bonds []*types.Bond, quality *QualityReport) []*types.Bond {
+	byTicker := map[string][]*types.Bond{}
+	for _, b := range bonds {
+		byTicker[b.Ticker] = append(byTicker[b.Ticker], b)
+	}
+
+	resolved := []*types.Bond{}
+
+	for _, b := range bonds {
+		dups := byTicker[b.Ticker]
+		if len(dups) == 1 {
+			resolved = append(resolved, b)
+			continue
+		}
+
+		// the ticker's bonds are resolved when its first bond is reached
+		if b != dups[0] {
+			continue
+		}
+
+		canonical := dups[0]
+		if ref, ok := types.LookupGiltByTicker(b.Ticker); ok {
+			for _, d := range dups {
+				if d.MaturityDate.Equal(ref.MaturityDate) && math.Abs(d.Coupon-ref.Coupon) < 1e-6 {
+					canonical = d
+					break
+				}
+			}
+		}
+
+		for _, d := range dups {
+			if d != canonical {
+				fmt.Fprintf(
+					os.Stderr,
+					"Warning: duplicate ticker %s, dropping %s maturing %s at %.3f\n",
+					d.Ticker,
+					d.Desc,
+					d.MaturityDate.Format("2006-01-02"),
+					d.CleanPrice,
+				)
+				quality.DropDuplicate()
+			}
+		}
+
+		resolved = append(resolved, canonical)
+	}
+
+	return resolved
+}
+
 func (d *DividendDataCollector) Source() string {
 	return SourceDividendData
 }
@@ -77,46 +179,58 @@ func (c *DividendDataCollector) readBond(e *colly.HTMLElement) *CollectedBond {
 
 	cb := &CollectedBond{Bond: b}
 
+	// wraps the field error with the bond and column so the failures can be traced back to the page
+	fieldErr := func(sentinel error, col int, err error) error {
+		if err == nil {
+			return fmt.Errorf("%w: ticker %s column %d", sentinel, b.Ticker, col)
+		}
+		return fmt.Errorf("%w: ticker %s column %d: %v", sentinel, b.Ticker, col, err)
+	}
+
 	e.ForEach("td", func(col int, el *colly.HTMLElement) {
 		switch col {
 		case DD_COL_TICKER:
 			b.Ticker = strings.TrimSpace(el.Text)
 			if b.Ticker == "" {
-				cb.SetError(types.ErrInvalidCoupon)
+				cb.SetError(fieldErr(types.ErrInvalidTicker, col, nil))
 			}
 		case DD_COL_DESC:
 			b.Desc = strings.TrimSpace(el.Text)
 			if b.Desc == "" {
-				cb.SetError(types.ErrInvalidDesc)
+				cb.SetError(fieldErr(types.ErrInvalidDesc, col, nil))
 			}
 		case DD_COL_COUPON:
-			s := strings.TrimSuffix(el.Text, "%")
-			if price, err := strconv.ParseFloat(s, 32); err == nil {
-				b.Coupon = float64(price)
+			// the coupon is a percentage, e.g. 3.5 for 3½%
+			s := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(el.Text), "%"))
+			if coupon, err := strconv.ParseFloat(s, 64); err == nil {
+				b.Coupon = coupon
 			} else {
-				cb.SetError(types.ErrInvalidCoupon)
+				cb.SetError(fieldErr(types.ErrInvalidCoupon, col, err))
 			}
 		case DD_COL_MATURITY_DATE:
 			if ts, err := time.Parse("02-Jan-2006", el.Text); err == nil {
 				b.MaturityDate = ts
 			} else {
-				cb.SetError(types.ErrInvalidMaturityDate)
+				cb.SetError(fieldErr(types.ErrInvalidMaturityDate, col, err))
 			}
 		case DD_COL_MATURITY_DURATION:
 			// ignore, calculated from maturity date
 		case DD_COL_PRICE:
-			s := strings.TrimPrefix(el.Text, "£")
-			if price, err := strconv.ParseFloat(s, 32); err == nil {
-				b.CleanPrice = float64(price)
+			if price, err := parsePounds(el.Text); err == nil {
+				b.CleanPrice = c.priceScale.Scale(price)
+				if err := checkCleanPrice(b.CleanPrice); err != nil {
+					cb.SetError(fmt.Errorf("%w: ticker %s column %d", err, b.Ticker, col))
+				}
 			} else {
-				cb.SetError(types.ErrInvalidCleanPrice)
+				cb.SetError(fieldErr(types.ErrInvalidCleanPrice, col, err))
 			}
 		case DD_COL_MATURITY_YIELD:
-			s := strings.TrimSuffix(el.Text, "%")
-			if price, err := strconv.ParseFloat(s, 32); err == nil {
-				b.YieldToMaturity = float64(price)
+			s := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(el.Text), "%"))
+			if ytm, err := strconv.ParseFloat(s, 64); err == nil {
+				// the published yield is compared with the yield solved from the price
+				b.SourceYield = ytm
 			} else {
-				cb.SetError(types.ErrInvalidYieldToMaturity)
+				cb.SetError(fieldErr(types.ErrInvalidYieldToMaturity, col, err))
 			}
 		}
 	})
@@ -0,0 +1,40 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+
+	"testing"
+	"time"
+)
+
+func TestResolveTickerCollisions(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	bond := func(ticker string, coupon float64, maturity time.Time) *CollectedBond {
+		b := types.NewUKGiltWithMaturity(SourceDividendData, date, coupon, maturity)
+		b.Ticker = ticker
+		return &CollectedBond{Bond: b}
+	}
+
+	// TR30 is listed twice, the reference data is the 4¾% 2030 gilt
+	listed := []*CollectedBond{
+		bond("TR30", 4.5, time.Date(2030, 6, 7, 0, 0, 0, 0, time.UTC)),
+		bond("TR28", 6, time.Date(2028, 12, 7, 0, 0, 0, 0, time.UTC)),
+		bond("TR30", 4.75, time.Date(2030, 12, 7, 0, 0, 0, 0, time.UTC)),
+	}
+
+	collected := NewCollectedBonds(SourceDividendData, date)
+	for _, cb := range listed {
+		collected.AddBond(cb)
+	}
+
+	collected.Bonds = resolveTickerCollisions(collected.Bonds, collected.Quality)
+
+	if len(collected.Bonds) != 2 || collected.Bonds[0] != listed[2].Bond || collected.Bonds[1] != listed[1].Bond {
+		t.Errorf("resolveTickerCollisions() = %v, want the reference TR30 then TR28", collected.Bonds)
+	}
+
+	if q := collected.Quality; q.Parsed != len(collected.Bonds) || q.Duplicates != 1 {
+		t.Errorf("Parsed = %d Duplicates = %d, want %d and 1", q.Parsed, q.Duplicates, len(collected.Bonds))
+	}
+}
@@ -2,13 +2,15 @@ package collect
 
 import (
 	"benritz/gilts/internal/types"
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -18,94 +20,374 @@ import (
 
 var SourceDMO = "DMO"
 
+// dmoColumns are the column indices of the bond fields in a DMO report export.
+type dmoColumns struct {
+	ISIN         int
+	Desc         int
+	CleanPrice   int
+	DirtyPrice   int
+	MaturityDate int
+	// Yield is the published yield column, -1 if the report has no yield.
+	Yield int
+	// Coupon is the numeric coupon column, -1 if the report has no coupon and it is
+	// parsed from the description. It is detected from the header row, see withHeader.
+	Coupon int
+	// Accrued is the published accrued interest column, -1 if the report has no accrued
+	// interest and it is the difference between the dirty and clean prices. It is detected
+	// from the header row, see withHeader.
+	Accrued int
+}
+
+// max returns the largest column index, rows must have at least this many columns. The
+// optional columns are checked when parsed so a short row without them isn't skipped.
+func (c dmoColumns) max() int {
+	return max(c.ISIN, c.Desc, c.CleanPrice, c.DirtyPrice, c.MaturityDate, c.Yield)
+}
+
+// withHeader returns the columns with the optional columns the layout doesn't fix found by
+// their labels in a header row, the exports have added columns over time so their position
+// isn't relied on.
+//
+// Returns:
+//
+//	The columns and true if the row is a header with any of the optional columns.
+func (c dmoColumns) withHeader(row []string) (dmoColumns, bool) {
+	found := false
+
+	for i, cell := range row {
+		label := strings.ToLower(strings.TrimSpace(cell))
+
+		// e.g. "Coupon" or "Coupon (%)" but not "Coupon Dates"
+		if c.Coupon < 0 && strings.Contains(label, "coupon") && !strings.Contains(label, "date") {
+			c.Coupon = i
+			found = true
+		}
+
+		// e.g. "Accrued Interest" or "Accrued Interest (£)"
+		if c.Accrued < 0 && strings.Contains(label, "accrued") {
+			c.Accrued = i
+			found = true
+		}
+	}
+
+	return c, found
+}
+
+// optionalCell returns the cell of an optional column, empty if the report has no such
+// column or the row is too short.
+func optionalCell(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[col])
+}
+
+// dmoReport is the layout of a DMO report export.
+type dmoReport struct {
+	Columns dmoColumns
+	// DateFormats are the accepted formats of the date columns.
+	DateFormats []string
+}
+
+// DMOReportD10B is the DMO gilt prices and yields report, the default report of DMOCollector.
+const DMOReportD10B = "D10B"
+
+var (
+	// dmoReports are the supported DMO report layouts by report code.
+	// D1A is not included, it is the gilts in issue reference report rather than prices
+	// and is collected by DMOReferenceCollector.
+	dmoReports = map[string]dmoReport{
+		DMOReportD10B: {
+			Columns: dmoColumns{
+				ISIN:         0,
+				Desc:         1,
+				CleanPrice:   2,
+				DirtyPrice:   3,
+				MaturityDate: 7,
+				Yield:        4,
+				Coupon:       -1,
+				Accrued:      -1,
+			},
+			DateFormats: []string{"02-Jan-2006"},
+		},
+	}
+)
+
+var (
+	ErrUnsupportedReport = fmt.Errorf("unsupported report")
+)
+
+// dmoBaseURL is the default base URL of the DMO website the reports are downloaded from.
+const dmoBaseURL = "https://www.dmo.gov.uk"
+
 type DMOCollector struct {
+	reportCode string
+	priceScale PriceScale
+	baseURL    string
+	metrics    io.Writer
+}
+
+type DMOOption func(*DMOCollector)
+
+// WithReportCode sets the DMO report to collect, defaults to D10B.
+func WithReportCode(code string) DMOOption {
+	return func(c *DMOCollector) {
+		c.reportCode = code
+	}
+}
+
+// WithBaseURL sets the base URL the reports are downloaded from, e.g. a mirror of the DMO
+// website or a test server, defaults to the DMO website.
+func WithBaseURL(baseURL string) DMOOption {
+	return func(c *DMOCollector) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithPriceScale sets how the report quotes prices, defaults to PricePerHundred.
+func WithPriceScale(scale PriceScale) DMOOption {
+	return func(c *DMOCollector) {
+		c.priceScale = scale
+	}
+}
+
+// WithMetricsOutput sets where the collection metrics are written, defaults to stderr so
+// they aren't mixed into a command's output.
+func WithMetricsOutput(w io.Writer) DMOOption {
+	return func(c *DMOCollector) {
+		c.metrics = w
+	}
 }
 
-func NewDMOCollector() *DMOCollector {
-	return &DMOCollector{}
+func init() {
+	Register("dmo", func() Collector { return NewDMOCollector() })
+}
+
+func NewDMOCollector(opts ...DMOOption) *DMOCollector {
+	c := &DMOCollector{
+		reportCode: DMOReportD10B,
+		baseURL:    dmoBaseURL,
+		metrics:    os.Stderr,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (c *DMOCollector) Collect(ctx context.Context, date time.Time) (*CollectedBonds, error) {
+	start := time.Now()
+
+	collected, err := c.collect(ctx, date)
+
+	emitCollectMetrics(c.metrics, SourceDMO, collected, err, start)
+
+	return collected, err
+}
+
+// CollectFromFile collects the bonds from a report already downloaded from the DMO website,
+// e.g. to debug the parsing of a report without fetching it again.
+//
+// Parameters:
+//
+//	date: The trade date of the report.
+//	path: The path of the XLS report.
+//
+// Returns:
+//
+//	The collected bonds.
+func (c *DMOCollector) CollectFromFile(date time.Time, path string) (*CollectedBonds, error) {
+	return c.parseWorkbook(date, path)
+}
+
+func (c *DMOCollector) collect(ctx context.Context, date time.Time) (*CollectedBonds, error) {
 	// The DMO website has a number of reports that can be used to collect gilt data.
 	// https://www.dmo.gov.uk/data/pdfdatareport?reportCode=D1A
 	// https://www.dmo.gov.uk/data/pdfdatareport?reportCode=D9D
 	// https://www.dmo.gov.uk/data/pdfdatareport?reportCode=D10B
 
+	if _, ok := dmoReports[c.reportCode]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedReport, c.reportCode)
+	}
+
+	path, err := c.download(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+
+	return c.parseWorkbook(date, path)
+}
+
+// download downloads the report for the date to a temporary file, the caller removes the file.
+func (c *DMOCollector) download(ctx context.Context, date time.Time) (string, error) {
 	params := fmt.Sprintf("&Trade Date=%02d-%02d-%04d", date.Day(), date.Month(), date.Year())
-	url := "https://www.dmo.gov.uk/umbraco/surface/DataExport/GetDataExport?reportCode=D10B&exportFormatValue=xls&parameters=" + url.QueryEscape(params)
+	return downloadDMOReport(ctx, c.baseURL, c.reportCode, params)
+}
+
+// downloadDMOReport downloads an XLS export of a DMO report to a temporary file, the caller
+// removes the file.
+func downloadDMOReport(ctx context.Context, baseURL, reportCode, params string) (string, error) {
+	url := baseURL + "/umbraco/surface/DataExport/GetDataExport?reportCode=" + url.QueryEscape(reportCode) + "&exportFormatValue=xls&parameters=" + url.QueryEscape(params)
 
-	fmt.Printf("Fetching %s\n", url)
+	fmt.Fprintf(os.Stderr, "Fetching %s\n", url)
 
 	client := &http.Client{}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get data: http %d", resp.StatusCode)
+		return "", fmt.Errorf("failed to get data: http %d", resp.StatusCode)
+	}
+
+	// the DMO website can return an HTML error page with a 200 rather than the export, it is
+	// detected before the workbook parser fails on it with an opaque error
+	body := bufio.NewReader(resp.Body)
+	if err := checkWorkbookContent(resp.Header.Get("Content-Type"), body); err != nil {
+		return "", err
 	}
 
 	tmp, err := os.CreateTemp("", "gilt-*.xls")
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer os.Remove(tmp.Name())
 
-	size, err := io.Copy(tmp, resp.Body)
+	size, err := io.Copy(tmp, body)
 	tmp.Close()
 	if err != nil {
-		return nil, err
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	fmt.Fprintf(os.Stderr, "Downloaded %d bytes to %s\n", size, tmp.Name())
+
+	return tmp.Name(), nil
+}
+
+var (
+	// xlsMagic is the signature of an XLS (OLE2 compound document) file.
+	xlsMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	// xlsxMagic is the signature of an XLSX (zip) file.
+	xlsxMagic = []byte{'P', 'K', 0x03, 0x04}
+)
+
+// checkWorkbookContent checks a response body is a workbook from its content type and first
+// bytes, without consuming them.
+func checkWorkbookContent(contentType string, body *bufio.Reader) error {
+	if strings.Contains(strings.ToLower(contentType), "text/html") {
+		return fmt.Errorf("%w: %w: content type %s", types.ErrDataUnavailable, ErrUnexpectedContent, contentType)
 	}
 
-	fmt.Printf("Downloaded %d bytes to %s\n", size, tmp.Name())
+	magic, _ := body.Peek(len(xlsMagic))
+	if !bytes.HasPrefix(magic, xlsMagic) && !bytes.HasPrefix(magic, xlsxMagic) {
+		return fmt.Errorf("%w: %w: starts %q", types.ErrDataUnavailable, ErrUnexpectedContent, magic)
+	}
+
+	return nil
+}
+
+// parseWorkbook parses the bonds from a report workbook. The XLS parser can panic on a
+// malformed workbook, the panic or an error reading the workbook is returned as
+// ErrCorruptWorkbook so a bad download doesn't crash the process.
+func (c *DMOCollector) parseWorkbook(date time.Time, path string) (collected *CollectedBonds, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			collected = nil
+			err = fmt.Errorf("%w: %s: %v", ErrCorruptWorkbook, path, r)
+		}
+	}()
+
+	report, ok := dmoReports[c.reportCode]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedReport, c.reportCode)
+	}
 
-	stat, err := os.Stat(tmp.Name())
+	stat, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("File %s size: %d bytes\n", stat.Name(), stat.Size())
+	fmt.Fprintf(os.Stderr, "File %s size: %d bytes\n", stat.Name(), stat.Size())
 
-	wb, err := grate.Open(tmp.Name())
+	// a workbook the parser can't read is as corrupt as one it panics on
+	wb, err := grate.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorruptWorkbook, path, err)
 	}
 	defer wb.Close()
 
-	collected := NewCollectedBonds(SourceDMO, date)
-	parsed := 0
+	collected = NewCollectedBonds(SourceDMO, date)
 
 	sheets, err := wb.List()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorruptWorkbook, path, err)
 	}
+
+	stats := make([]sheetStats, 0, len(sheets))
+	gilts := 0
+
 	for _, sheetName := range sheets {
 		sheet, err := wb.Get(sheetName)
 
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %s: %w", ErrCorruptWorkbook, path, err)
 		}
 
+		st := sheetStats{name: sheetName}
+
+		// the optional columns are detected from the sheet's header row
+		sheetReport := report
+
 		for sheet.Next() {
+			st.rows++
+			collected.Quality.AddRow()
+
 			row := sheet.Strings()
-			c, err := c.parseRow(date, row)
+			c, err := c.parseRow(sheetReport, date, row)
+			if errors.Is(err, ErrInvaidRow) {
+				if cols, ok := report.Columns.withHeader(row); ok {
+					sheetReport.Columns = cols
+				}
+			}
 			if err == nil {
 				collected.AddBond(c)
-				parsed++
+				if c.Err == nil {
+					collected.Quality.AddAccrued(c.Bond, c.publishedAccrued)
+				}
+				st.gilts++
+				if c.Err != nil {
+					st.failed++
+				}
 			}
 		}
+
+		stats = append(stats, st)
+		gilts += st.gilts
 	}
 
-	if parsed == 0 {
-		return nil, types.ErrDataUnavailable
+	// distinguish between a workbook with no gilts (e.g. the data isn't published yet
+	// or the layout has changed) and a workbook with gilts that all failed to parse
+	if gilts == 0 {
+		return nil, fmt.Errorf("%w: %w: %s", types.ErrDataUnavailable, ErrNoGiltSheet, summariseSheets(stats))
+	}
+
+	if len(collected.Bonds) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAllRowsFailed, summariseSheets(stats))
+	}
+
+	if q := collected.Quality; q.AccruedMismatches > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d of %d bonds accrued interest differs from the DMO, worst %s\n", q.AccruedMismatches, q.AccruedChecked, q.WorstAccrued)
 	}
 
 	return collected, nil
@@ -115,12 +397,38 @@ func (d *DMOCollector) Source() string {
 	return SourceDMO
 }
 
-func (c *DMOCollector) parseRow(date time.Time, row []string) (*CollectedBond, error) {
-	if len(row) == 0 {
+var (
+	ErrNoGiltSheet     = fmt.Errorf("no gilt sheet found")
+	ErrAllRowsFailed   = fmt.Errorf("all gilt rows failed to parse")
+	ErrCorruptWorkbook = fmt.Errorf("corrupt workbook")
+	// ErrUnexpectedContent is a download which isn't a workbook, e.g. an HTML error page.
+	ErrUnexpectedContent = fmt.Errorf("unexpected content, not a workbook")
+)
+
+// sheetStats are the row counts for a workbook sheet.
+type sheetStats struct {
+	name   string
+	rows   int
+	gilts  int
+	failed int
+}
+
+func summariseSheets(stats []sheetStats) string {
+	parts := make([]string, len(stats))
+	for i, st := range stats {
+		parts[i] = fmt.Sprintf("sheet %q %d rows, %d gilts, %d failed", st.name, st.rows, st.gilts, st.failed)
+	}
+	return fmt.Sprintf("%d sheets (%s)", len(stats), strings.Join(parts, "; "))
+}
+
+func (c *DMOCollector) parseRow(report dmoReport, date time.Time, row []string) (*CollectedBond, error) {
+	cols := report.Columns
+
+	if len(row) <= cols.max() {
 		return nil, ErrInvaidRow
 	}
 
-	isin := row[0]
+	isin := row[cols.ISIN]
 
 	if !strings.HasPrefix(isin, "GB") {
 		return nil, ErrInvaidRow
@@ -128,7 +436,7 @@ func (c *DMOCollector) parseRow(date time.Time, row []string) (*CollectedBond, e
 
 	b := types.NewUKGilt(SourceDMO, date)
 	b.ISIN = strings.TrimSpace(isin)
-	b.Desc = strings.TrimSpace(row[1])
+	b.Desc = strings.TrimSpace(row[cols.Desc])
 
 	// unsupported bonds
 	if strings.Contains(strings.ToLower(b.Desc), "index-linked") {
@@ -137,121 +445,87 @@ func (c *DMOCollector) parseRow(date time.Time, row []string) (*CollectedBond, e
 
 	cb := &CollectedBond{Bond: b}
 
-	if coupon, err := parseCouponPercentage(b.Desc); err == nil {
+	// wraps the field error with the bond and column so the failures can be traced back to the report
+	fieldErr := func(sentinel error, col int, err error) error {
+		return fmt.Errorf("%w: ISIN %s column %d: %v", sentinel, b.ISIN, col, err)
+	}
+
+	if coupon, err := parseCoupon(cols, row, b.Desc); err == nil {
 		b.Coupon = coupon
 	} else {
-		cb.SetError(types.ErrInvalidCoupon)
+		// the error is a BondError with the description
+		cb.SetError(fmt.Errorf("ISIN %s column %d: %w", b.ISIN, cols.Desc, err))
 	}
 
-	if cleanPrice, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 32); err == nil {
-		b.CleanPrice = float64(cleanPrice)
+	if cleanPrice, err := strconv.ParseFloat(strings.TrimSpace(row[cols.CleanPrice]), 32); err == nil {
+		b.CleanPrice = c.priceScale.Scale(float64(cleanPrice))
+		if err := checkCleanPrice(b.CleanPrice); err != nil {
+			cb.SetError(fmt.Errorf("%w: ISIN %s column %d", err, b.ISIN, cols.CleanPrice))
+		}
 	} else {
-		cb.SetError(types.ErrInvalidCleanPrice)
+		cb.SetError(fieldErr(types.ErrInvalidCleanPrice, cols.CleanPrice, err))
 	}
 
-	if dirtyPrice, err := strconv.ParseFloat(strings.TrimSpace(row[3]), 32); err == nil {
-		b.DirtyPrice = float64(dirtyPrice)
+	if dirtyPrice, err := strconv.ParseFloat(strings.TrimSpace(row[cols.DirtyPrice]), 32); err == nil {
+		b.DirtyPrice = c.priceScale.Scale(float64(dirtyPrice))
 	} else {
-		cb.SetError(types.ErrInvalidDirtyPrice)
+		cb.SetError(fieldErr(types.ErrInvalidDirtyPrice, cols.DirtyPrice, err))
 	}
 
-	if ts, err := time.Parse("02-Jan-2006", strings.TrimSpace(row[7])); err == nil {
+	if ts, err := parseDate(report.DateFormats, row[cols.MaturityDate]); err == nil {
 		b.MaturityDate = ts
 	} else {
-		cb.SetError(types.ErrInvalidMaturityDate)
+		cb.SetError(fieldErr(types.ErrInvalidMaturityDate, cols.MaturityDate, err))
+	}
+
+	// the published yield is only kept for comparison with the yield computed from the
+	// price so a missing or invalid yield doesn't fail the bond
+	if cols.Yield >= 0 {
+		if y, err := strconv.ParseFloat(strings.TrimSpace(row[cols.Yield]), 64); err == nil {
+			b.SourceYield = y
+		}
+	}
+
+	// the published prices are rounded so the accrued column is preferred, without it the
+	// published accrued is the difference between the dirty and clean prices and must be
+	// taken before CompleteBond derives the dirty price
+	if accrued, err := strconv.ParseFloat(optionalCell(row, cols.Accrued), 64); err == nil {
+		cb.publishedAccrued = c.priceScale.Scale(accrued)
+	} else {
+		cb.publishedAccrued = b.DirtyPrice - b.CleanPrice
 	}
 
 	if cb.Err == nil {
-		cb.Err = types.CompleteBond(b)
+		if err := types.CompleteBond(b); err != nil {
+			cb.SetError(fmt.Errorf("ISIN %s: %w", b.ISIN, err))
+		}
 	}
 
 	return cb, nil
 }
 
-// parseCouponPercentage parses a coupon percentage string it the following formats
-// 0 5/8% Treasury Gilt 2025,
-// 2% Treasury Gilt 2025,
-// 3½% Treasury Gilt 2025
-//
-//	s: bond description
-//
-// Returns:
-//
-//	Coupon percentage
-func parseCouponPercentage(desc string) (float64, error) {
-	re := regexp.MustCompile(`^(\d+(?:\s+\d+\/\d+)?|\d+\/\d+|\d+|\d[¼½¾])(%)`)
-	match := re.FindStringSubmatch(desc)
-
-	if len(match) < 3 {
-		return 0, types.ErrInvalidCoupon
+// parseCoupon parses the coupon rate from the numeric coupon column when the report has
+// one, falling back to the description so a row with a blank or invalid coupon column
+// isn't lost.
+func parseCoupon(cols dmoColumns, row []string, desc string) (float64, error) {
+	if coupon, err := strconv.ParseFloat(optionalCell(row, cols.Coupon), 64); err == nil && coupon >= 0 {
+		return coupon, nil
 	}
 
-	m := match[1]
+	return types.ParseCouponPercentage(desc)
+}
 
-	// convert ½, ¼, ¾ suffixes
-	trimLast := func(s string) string {
-		r := []rune(s)
-		return string(r[0 : len(r)-1])
-	}
-	if strings.HasSuffix(m, "½") {
-		m = trimLast(m) + " 1/2"
-	} else if strings.HasSuffix(m, "¼") {
-		m = trimLast(m) + " 1/4"
-	} else if strings.HasSuffix(m, "¾") {
-		m = trimLast(m) + " 3/4"
-	}
+// parseDate parses a date using the first matching format.
+func parseDate(formats []string, s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
 
-	if strings.Contains(m, "/") {
-		parts := strings.Split(m, " ")
-		if len(parts) == 2 {
-			// Mixed number
-			whole, err := strconv.Atoi(parts[0])
-			if err != nil {
-				return 0, types.ErrInvalidCoupon
-			}
-			fractionParts := strings.Split(parts[1], "/")
-			if len(fractionParts) != 2 {
-				return 0, types.ErrInvalidCoupon
-			}
-			num, err := strconv.Atoi(fractionParts[0])
-			if err != nil {
-				return 0, types.ErrInvalidCoupon
-			}
-			den, err := strconv.Atoi(fractionParts[1])
-			if err != nil {
-				return 0, types.ErrInvalidCoupon
-			}
-			if den == 0 {
-				return 0, types.ErrInvalidCoupon
-			}
-			return float64(whole) + float64(num)/float64(den), nil
-		} else if len(parts) == 1 {
-			// Fraction only
-			fractionParts := strings.Split(parts[0], "/")
-			if len(fractionParts) != 2 {
-				return 0, types.ErrInvalidCoupon
-			}
-			num, err := strconv.Atoi(fractionParts[0])
-			if err != nil {
-				return 0, types.ErrInvalidCoupon
-			}
-			den, err := strconv.Atoi(fractionParts[1])
-			if err != nil {
-				return 0, types.ErrInvalidCoupon
-			}
-			if den == 0 {
-				return 0, types.ErrInvalidCoupon
-			}
-			return float64(num) / float64(den), nil
-		}
-	} else {
-		// Whole number
-		val, err := strconv.ParseFloat(m, 64)
-		if err != nil {
-			return 0, types.ErrInvalidCoupon
+	err := fmt.Errorf("invalid date %q", s)
+	for _, format := range formats {
+		var ts time.Time
+		if ts, err = time.Parse(format, s); err == nil {
+			return ts, nil
 		}
-		return val, nil
 	}
 
-	return 0, types.ErrInvalidCoupon
+	return time.Time{}, err
 }
@@ -0,0 +1,694 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	_ "github.com/pbnjay/grate/xls"
+)
+
+var update = flag.Bool("update", false, "regenerate the testdata fixtures")
+
+// d10bFixture is the path of a synthetic export in the D10B layout, the prices are rounded to
+// two decimal places as published and the optional coupon and accrued columns are detected
+// from the header row. Regenerate it with go test ./internal/collect -run TestDMOFixture -update.
+var d10bFixture = filepath.Join("testdata", "D10B_20250307.xls")
+
+// d10bRows are the rows of the D10B fixture, float64 cells are numbers and strings are text.
+var d10bRows = [][]any{
+	{"Gilt Prices and Yields"},
+	{"Close of business 07-Mar-2025"},
+	{},
+	{"ISIN Code", "Gilt Name", "Clean Price (£)", "Dirty Price (£)", "Yield (%)", "Modified Duration", "Accrued Interest (£)", "Redemption Date", "Coupon (%)"},
+	// the coupon is only in the coupon column
+	{"GB00B24FF097", "Treasury Gilt 2030", 101.23, 102.40, 4.5036, 4.93, 1.174451, "07-Dec-2030", 4.75},
+	// a blank coupon falls back to the description
+	{"GB0004893086", "4¼% Treasury Gilt 2032", 97.85, 98.90, 4.601, 6.21, 1.050824, "07-Jun-2032", ""},
+	{"GB00B84Z9V04", "3¼% Treasury Gilt 2044", 76.40, 76.80, 5.2322, 13.08, 0.395028, "22-Jan-2044", 3.25},
+	// index-linked gilts are not supported and skipped
+	{"GB00TEST0001", "0⅛% Index-linked Treasury Gilt 2031", 95.5, 95.6, 1.1, 5.9, 0.1, "10-Aug-2031", 0.125},
+}
+
+// writeLE writes the fixed size values little-endian, writes to a buffer can't fail.
+func writeLE(buf *bytes.Buffer, data ...any) {
+	for _, d := range data {
+		binary.Write(buf, binary.LittleEndian, d)
+	}
+}
+
+// xlsRecord appends a BIFF8 record.
+func xlsRecord(buf *bytes.Buffer, recType uint16, data ...any) {
+	var body bytes.Buffer
+	writeLE(&body, data...)
+	writeLE(buf, recType, uint16(body.Len()))
+	buf.Write(body.Bytes())
+}
+
+// xlsBOF appends a BIFF8 BOF record for a workbook (0x0005) or worksheet (0x0010) substream.
+func xlsBOF(buf *bytes.Buffer, docType uint16) {
+	xlsRecord(buf, 0x0809, uint16(0x0600), docType, uint16(0), uint16(0x07CC), uint64(0))
+}
+
+// xlsSheet is a worksheet of a workbook written by writeWorkbook.
+type xlsSheet struct {
+	name string
+	rows [][]any
+}
+
+// writeXLS writes a minimal single sheet BIFF8 XLS workbook.
+func writeXLS(path string, sheet string, rows [][]any) error {
+	return writeWorkbook(path, xlsSheet{name: sheet, rows: rows})
+}
+
+// writeWorkbook writes a minimal BIFF8 XLS workbook of the sheets, the workbook stream in a
+// version 3 compound file with one FAT sector and one directory sector.
+func writeWorkbook(path string, sheets ...xlsSheet) error {
+	strs := []string{}
+	strIndex := map[string]uint32{}
+
+	substreams := make([]bytes.Buffer, len(sheets))
+	for i, sheet := range sheets {
+		var cells bytes.Buffer
+		maxCol := 1
+		for r, row := range sheet.rows {
+			for c, v := range row {
+				switch v := v.(type) {
+				case float64:
+					xlsRecord(&cells, 0x0203, uint16(r), uint16(c), uint16(0), v)
+				case string:
+					if v == "" {
+						continue
+					}
+					i, ok := strIndex[v]
+					if !ok {
+						i = uint32(len(strs))
+						strIndex[v] = i
+						strs = append(strs, v)
+					}
+					xlsRecord(&cells, 0x00FD, uint16(r), uint16(c), uint16(0), i)
+				}
+				maxCol = max(maxCol, c+1)
+			}
+		}
+
+		xlsBOF(&substreams[i], 0x0010)
+		xlsRecord(&substreams[i], 0x0200, uint32(0), uint32(len(sheet.rows)), uint16(0), uint16(maxCol), uint16(0))
+		substreams[i].Write(cells.Bytes())
+		xlsRecord(&substreams[i], 0x000A)
+	}
+
+	// the shared strings are stored as UTF-16
+	sst := []any{uint32(len(strs)), uint32(len(strs))}
+	for _, s := range strs {
+		u := utf16.Encode([]rune(s))
+		sst = append(sst, uint16(len(u)), uint8(1), u)
+	}
+
+	var globals bytes.Buffer
+	xlsBOF(&globals, 0x0005)
+	xlsRecord(&globals, 0x00FC, sst...)
+
+	// the sheets are after the globals, the BoundSheet8 records and the EOF record
+	sheetPos := globals.Len() + 4
+	for _, sheet := range sheets {
+		sheetPos += 4 + 8 + len(sheet.name)
+	}
+	for i, sheet := range sheets {
+		name := []byte(sheet.name)
+		xlsRecord(&globals, 0x0085, uint32(sheetPos), uint16(0), uint8(len(name)), uint8(0), name)
+		sheetPos += substreams[i].Len()
+	}
+	xlsRecord(&globals, 0x000A)
+
+	stream := bytes.NewBuffer(globals.Bytes())
+	for i := range substreams {
+		stream.Write(substreams[i].Bytes())
+	}
+
+	// streams under 4096 bytes are in the mini stream, padding keeps it in regular sectors
+	const sectorSize = 512
+	const endOfChain, freeSector, fatSector = 0xFFFFFFFE, 0xFFFFFFFF, 0xFFFFFFFD
+	size := max(stream.Len(), 4096)
+	stream.Write(make([]byte, size-stream.Len()))
+	streamSectors := (size + sectorSize - 1) / sectorSize
+	stream.Write(make([]byte, streamSectors*sectorSize-size))
+	if streamSectors+2 > sectorSize/4 {
+		return fmt.Errorf("workbook too large: %d bytes", size)
+	}
+
+	var file bytes.Buffer
+
+	// header, the FAT is sector 0, the directory sector 1 and the workbook stream from sector 2
+	difat := [109]uint32{}
+	for i := range difat {
+		difat[i] = freeSector
+	}
+	difat[0] = 0
+	writeLE(
+		&file,
+		uint64(0xE11AB1A1E011CFD0), [2]uint64{}, uint16(0x3E), uint16(3), uint16(0xFFFE),
+		uint16(9), uint16(6), [6]byte{}, int32(0), int32(1), uint32(1), int32(0),
+		int32(4096), uint32(endOfChain), int32(0), uint32(endOfChain), int32(0), difat,
+	)
+
+	fat := make([]uint32, sectorSize/4)
+	for i := range fat {
+		fat[i] = freeSector
+	}
+	fat[0] = fatSector
+	fat[1] = endOfChain
+	for i := 2; i < streamSectors+2; i++ {
+		fat[i] = uint32(i + 1)
+	}
+	fat[streamSectors+1] = endOfChain
+	writeLE(&file, fat)
+
+	dirEntry := func(name string, objType uint8, child uint32, start uint32, size uint64) {
+		var n [32]uint16
+		copy(n[:], utf16.Encode([]rune(name)))
+		writeLE(
+			&file,
+			n, uint16(2*(len(name)+1)), objType, uint8(1), uint32(freeSector), uint32(freeSector),
+			child, [2]uint64{}, uint32(0), int64(0), int64(0), start, size,
+		)
+	}
+	dirEntry("Root Entry", 5, 1, endOfChain, 0)
+	dirEntry("Workbook", 2, freeSector, 2, uint64(size))
+	file.Write(make([]byte, 2*128))
+
+	file.Write(stream.Bytes())
+
+	return os.WriteFile(path, file.Bytes(), 0o644)
+}
+
+func TestDMOFixture(t *testing.T) {
+	if *update {
+		if err := writeXLS(d10bFixture, "D10B", d10bRows); err != nil {
+			t.Fatalf("failed to write %s: %v", d10bFixture, err)
+		}
+	}
+
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	collected, err := NewDMOCollector().CollectFromFile(date, d10bFixture)
+	if err != nil {
+		t.Fatalf("CollectFromFile() error = %v", err)
+	}
+
+	if len(collected.Bonds) != 3 || len(collected.Failures) != 0 {
+		t.Fatalf("got %d bonds and %d failures, want 3 bonds", len(collected.Bonds), len(collected.Failures))
+	}
+
+	// the dirty prices are rounded, only the accrued column reconciles
+	if collected.Quality.AccruedMismatches != 0 {
+		t.Errorf("AccruedMismatches = %d, want 0", collected.Quality.AccruedMismatches)
+	}
+
+	tests := []struct {
+		isin        string
+		coupon      float64
+		maturity    time.Time
+		sourceYield float64
+	}{
+		{"GB00B24FF097", 4.75, time.Date(2030, 12, 7, 0, 0, 0, 0, time.UTC), 4.5036},
+		{"GB0004893086", 4.25, time.Date(2032, 6, 7, 0, 0, 0, 0, time.UTC), 4.601},
+		{"GB00B84Z9V04", 3.25, time.Date(2044, 1, 22, 0, 0, 0, 0, time.UTC), 5.2322},
+	}
+
+	for i, tt := range tests {
+		b := collected.Bonds[i]
+		if b.ISIN != tt.isin || b.Coupon != tt.coupon || !b.MaturityDate.Equal(tt.maturity) || b.SourceYield != tt.sourceYield {
+			t.Errorf(
+				"bond %d = %s %.4f%% %s yield %.4f, want %s %.4f%% %s yield %.4f",
+				i, b.ISIN, b.Coupon, b.MaturityDate.Format("2006-01-02"), b.SourceYield,
+				tt.isin, tt.coupon, tt.maturity.Format("2006-01-02"), tt.sourceYield,
+			)
+		}
+
+		// the published yield is kept for comparison, the yield is still solved from the clean
+		// price and agrees within the rounding of the published price
+		if diff := b.YieldToMaturity - b.SourceYield; math.Abs(diff) > 0.001 || b.ComputedVsSourceYield != diff {
+			t.Errorf("bond %s yield %.6f, source yield %.4f, difference %v, want within 0.001",
+				b.ISIN, b.YieldToMaturity, b.SourceYield, b.ComputedVsSourceYield)
+		}
+	}
+
+	if collected.Quality.YieldDiscrepancies != 0 {
+		t.Errorf("YieldDiscrepancies = %d, want 0", collected.Quality.YieldDiscrepancies)
+	}
+}
+
+func TestDMOColumnsWithHeader(t *testing.T) {
+	base := dmoReports[DMOReportD10B].Columns
+
+	tests := []struct {
+		name        string
+		row         []string
+		wantCoupon  int
+		wantAccrued int
+		wantFound   bool
+	}{
+		{name: "coupon column", row: []string{"ISIN Code", "Gilt Name", "Coupon (%)"}, wantCoupon: 2, wantAccrued: -1, wantFound: true},
+		{name: "accrued column", row: []string{"ISIN Code", "Accrued Interest (£)"}, wantCoupon: -1, wantAccrued: 1, wantFound: true},
+		{name: "coupon dates are not the coupon", row: []string{"ISIN Code", "Coupon Dates"}, wantCoupon: -1, wantAccrued: -1},
+		{name: "not a header", row: []string{"Gilt Prices and Yields"}, wantCoupon: -1, wantAccrued: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, found := base.withHeader(tt.row)
+			if found != tt.wantFound || cols.Coupon != tt.wantCoupon || cols.Accrued != tt.wantAccrued {
+				t.Errorf(
+					"withHeader() = coupon %d accrued %d found %t, want coupon %d accrued %d found %t",
+					cols.Coupon, cols.Accrued, found, tt.wantCoupon, tt.wantAccrued, tt.wantFound,
+				)
+			}
+		})
+	}
+}
+
+func TestDMOWorkbookErrors(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	path := filepath.Join(t.TempDir(), "empty.xls")
+	if err := writeXLS(path, "D10B", d10bRows[:4]); err != nil {
+		t.Fatalf("writeXLS() error = %v", err)
+	}
+
+	// a report without gilts, e.g. not yet published, is data unavailable
+	_, err := NewDMOCollector().CollectFromFile(date, path)
+	if !errors.Is(err, types.ErrDataUnavailable) || !errors.Is(err, ErrNoGiltSheet) {
+		t.Errorf("CollectFromFile() error = %v, want %v", err, ErrNoGiltSheet)
+	}
+}
+
+func TestDMOCorruptWorkbook(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	data, err := os.ReadFile(d10bFixture)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", d10bFixture, err)
+	}
+
+	// the workbook stream is from the third 512 byte sector
+	overwritten := bytes.Clone(data)
+	copy(overwritten[1536:], bytes.Repeat([]byte{0xFF}, 200))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "garbage", data: []byte("not a workbook")},
+		{name: "signature only", data: append(bytes.Clone(xlsMagic), make([]byte, 600)...)},
+		// the parser panics on these two truncations and returns an error on the third
+		{name: "truncated header", data: data[:513]},
+		{name: "truncated directory", data: data[:1536]},
+		{name: "truncated stream", data: data[:3000]},
+		{name: "corrupt stream", data: overwritten},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "D10B.xls")
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatalf("failed to write %s: %v", path, err)
+			}
+
+			_, err := NewDMOCollector().CollectFromFile(date, path)
+			if !errors.Is(err, ErrCorruptWorkbook) {
+				t.Errorf("CollectFromFile() error = %v, want %v", err, ErrCorruptWorkbook)
+			}
+		})
+	}
+}
+
+// collectRows collects the bonds from a D10B report of the rows.
+func collectRows(t *testing.T, rows [][]any, opts ...DMOOption) *CollectedBonds {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "D10B.xls")
+	if err := writeXLS(path, "D10B", rows); err != nil {
+		t.Fatalf("writeXLS() error = %v", err)
+	}
+
+	collected, err := NewDMOCollector(append([]DMOOption{WithMetricsOutput(io.Discard)}, opts...)...).CollectFromFile(time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC), path)
+	if err != nil {
+		t.Fatalf("CollectFromFile() error = %v", err)
+	}
+
+	return collected
+}
+
+// withCell returns a copy of the D10B fixture rows with a cell of a gilt row replaced.
+func withCell(row, col int, value any) [][]any {
+	rows := make([][]any, len(d10bRows))
+	copy(rows, d10bRows)
+
+	rows[row] = append([]any{}, rows[row]...)
+	rows[row][col] = value
+
+	return rows
+}
+
+func TestDMOPriceScale(t *testing.T) {
+	// the fixture's prices quoted as fractions of par
+	fractions := make([][]any, len(d10bRows))
+	for i, row := range d10bRows {
+		fractions[i] = append([]any{}, row...)
+		if i < 4 {
+			continue
+		}
+		for _, col := range []int{2, 3, 6} {
+			fractions[i][col] = row[col].(float64) / 100
+		}
+	}
+
+	perHundred := collectRows(t, d10bRows)
+	ofPar := collectRows(t, fractions, WithPriceScale(PriceFractionOfPar))
+
+	if len(perHundred.Bonds) != 3 || len(ofPar.Bonds) != 3 || len(ofPar.Failures) != 0 {
+		t.Fatalf("got %d and %d bonds with %d failures, want 3 bonds from each scale",
+			len(perHundred.Bonds), len(ofPar.Bonds), len(ofPar.Failures))
+	}
+
+	for i, want := range perHundred.Bonds {
+		got := ofPar.Bonds[i]
+		// the report's prices are parsed as float32
+		if math.Abs(want.CleanPrice-d10bRows[4+i][2].(float64)) > 1e-4 {
+			t.Errorf("%s CleanPrice = %v per £100, want %v", want.ISIN, want.CleanPrice, d10bRows[4+i][2])
+		}
+		if got.ISIN != want.ISIN || math.Abs(got.CleanPrice-want.CleanPrice) > 1e-4 || math.Abs(got.DirtyPrice-want.DirtyPrice) > 1e-4 {
+			t.Errorf("%s fraction of par prices = %v %v, want %v %v", got.ISIN, got.CleanPrice, got.DirtyPrice, want.CleanPrice, want.DirtyPrice)
+		}
+	}
+
+	// a price in the other scale is outside the sane range, the first gilt is quoted per £100
+	// and the others as fractions of par
+	mixed := append(append([][]any{}, d10bRows[:5]...), fractions[5:]...)
+
+	tests := []struct {
+		scale      PriceScale
+		wantBonds  []string
+		wantFailed []string
+	}{
+		{scale: PricePerHundred, wantBonds: []string{"GB00B24FF097"}, wantFailed: []string{"GB0004893086", "GB00B84Z9V04"}},
+		{scale: PriceFractionOfPar, wantBonds: []string{"GB0004893086", "GB00B84Z9V04"}, wantFailed: []string{"GB00B24FF097"}},
+	}
+
+	for _, tt := range tests {
+		collected := collectRows(t, mixed, WithPriceScale(tt.scale))
+
+		var bonds, failed []string
+		for _, b := range collected.Bonds {
+			bonds = append(bonds, b.ISIN)
+		}
+		for _, f := range collected.Failures {
+			if !errors.Is(f.Err, types.ErrInvalidCleanPrice) {
+				t.Errorf("scale %d %s error = %v, want %v", tt.scale, f.Bond.ISIN, f.Err, types.ErrInvalidCleanPrice)
+			}
+			failed = append(failed, f.Bond.ISIN)
+		}
+
+		if !slices.Equal(bonds, tt.wantBonds) || !slices.Equal(failed, tt.wantFailed) {
+			t.Errorf("scale %d = %v with %v failed, want %v with %v failed", tt.scale, bonds, failed, tt.wantBonds, tt.wantFailed)
+		}
+	}
+}
+
+func TestDMOQualityReport(t *testing.T) {
+	tests := []struct {
+		name             string
+		rows             [][]any
+		wantMismatches   int
+		wantWorstAccrued string
+		wantYieldDiffs   int
+	}{
+		{name: "published", rows: d10bRows},
+		// 1.20 rather than the 1.174451 accrued on the 4¾% 2030 gilt
+		{name: "accrued mismatch", rows: withCell(4, 6, 1.20), wantMismatches: 1, wantWorstAccrued: "GB00B24FF097"},
+		// 4.70% rather than the 4.5036% yield of the clean price
+		{name: "yield discrepancy", rows: withCell(4, 4, 4.70), wantYieldDiffs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := collectRows(t, tt.rows).Quality
+
+			if q.AccruedChecked != 3 || q.AccruedMismatches != tt.wantMismatches {
+				t.Errorf("AccruedMismatches = %d of %d, want %d of 3", q.AccruedMismatches, q.AccruedChecked, tt.wantMismatches)
+			}
+			if !strings.Contains(q.WorstAccrued, tt.wantWorstAccrued) || (tt.wantWorstAccrued == "") != (q.WorstAccrued == "") {
+				t.Errorf("WorstAccrued = %q, want %q", q.WorstAccrued, tt.wantWorstAccrued)
+			}
+			if q.YieldDiscrepancies != tt.wantYieldDiffs {
+				t.Errorf("YieldDiscrepancies = %d, want %d", q.YieldDiscrepancies, tt.wantYieldDiffs)
+			}
+		})
+	}
+}
+
+func TestDMOQualityFailures(t *testing.T) {
+	rows := append(slices.Clone(d10bRows[:4]),
+		[]any{"GB00B24FF097", "Treasury Gilt 2030", 101.23, 102.40, 4.5036, 4.93, 1.174451, "07-Dec-2030", 4.75},
+		[]any{"GB0004893086", "4¼% Treasury Gilt 2032", 97.85, 98.90, 4.601, 6.21, 1.050824, "07-Jun-2032", ""},
+		// no coupon in the coupon column or the description
+		[]any{"GB00B84Z9V04", "Treasury Gilt 2044", 76.40, 76.80, 5.2322, 13.08, 0.395028, "22-Jan-2044", ""},
+		// a clean price that isn't a number
+		[]any{"GB00BMGR2809", "0⅛% Treasury Gilt 2028", "n/a", 92.10, 4.1, 2.9, 0.01, "31-Jan-2028", 0.125},
+		// a clean price out of range and a maturity date that isn't a date, counted in both
+		[]any{"GB00BL68HJ26", "0⅞% Treasury Gilt 2029", 1000.0, 88.30, 4.2, 3.8, 0.3, "2029-10-22", 0.875},
+		// a blank dirty price
+		[]any{"GB00BJMHB534", "0⅞% Green Gilt 2033", 76.90, "", 4.5, 7.6, 0.2, "31-Jul-2033", 0.875},
+		// index-linked gilts are skipped rather than failed
+		[]any{"GB00TEST0001", "0⅛% Index-linked Treasury Gilt 2031", 95.5, 95.6, 1.1, 5.9, 0.1, "10-Aug-2031", 0.125},
+	)
+
+	q := collectRows(t, rows).Quality
+
+	// the rows seen include the blank rows read after the gilts, the same as the fixture
+	wantRows := collectRows(t, d10bRows).Quality.RowsSeen + len(rows) - len(d10bRows)
+
+	if q.RowsSeen != wantRows || q.Parsed != 2 || q.Failed != 4 || q.Duplicates != 0 {
+		t.Errorf("RowsSeen = %d, Parsed = %d, Failed = %d, Duplicates = %d, want %d, 2, 4, 0",
+			q.RowsSeen, q.Parsed, q.Failed, q.Duplicates, wantRows)
+	}
+
+	want := map[string]int{
+		"invalid_coupon":        1,
+		"invalid_clean_price":   2,
+		"invalid_maturity_date": 1,
+		"invalid_dirty_price":   1,
+	}
+	if !maps.Equal(q.FailuresByType, want) {
+		t.Errorf("FailuresByType = %v, want %v", q.FailuresByType, want)
+	}
+
+	// an error matching no sentinel is counted as other
+	if got := ErrorCategories(errors.New("timeout")); !slices.Equal(got, []string{QualityOther}) {
+		t.Errorf("ErrorCategories() = %v, want %v", got, []string{QualityOther})
+	}
+}
+
+func TestDMOFieldErrors(t *testing.T) {
+	// no coupon in the coupon column or the description and neither price is a number
+	rows := append(slices.Clone(d10bRows[:5]),
+		[]any{"GB00B84Z9V04", "Treasury Gilt 2044", "n/a", "n/a", 5.2322, 13.08, 0.395028, "22-Jan-2044", ""},
+	)
+
+	collected := collectRows(t, rows)
+	if len(collected.Failures) != 1 {
+		t.Fatalf("got %d failures, want 1", len(collected.Failures))
+	}
+	err := collected.Failures[0].Err
+
+	// each field error is kept with the ISIN and column of the report
+	want := `ISIN GB00B84Z9V04 column 1: invalid coupon: Desc "Treasury Gilt 2044"` + "\n" +
+		`invalid clean price: ISIN GB00B84Z9V04 column 2: strconv.ParseFloat: parsing "n/a": invalid syntax` + "\n" +
+		`invalid dirty price: ISIN GB00B84Z9V04 column 3: strconv.ParseFloat: parsing "n/a": invalid syntax`
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+
+	for _, sentinel := range []error{types.ErrInvalidCoupon, types.ErrInvalidCleanPrice, types.ErrInvalidDirtyPrice} {
+		if !errors.Is(err, sentinel) {
+			t.Errorf("errors.Is(%v) = false, want true", sentinel)
+		}
+	}
+	if errors.Is(err, types.ErrInvalidMaturityDate) {
+		t.Errorf("errors.Is(%v) = true, want false", types.ErrInvalidMaturityDate)
+	}
+
+	var bondErr *types.BondError
+	if !errors.As(err, &bondErr) || bondErr.Field != "Desc" || bondErr.Value != "Treasury Gilt 2044" {
+		t.Errorf("errors.As() = %v, want the Desc BondError", bondErr)
+	}
+}
+
+func TestDMOCouponFraction(t *testing.T) {
+	// the 4¾% 2030 gilt's coupon stored as the fraction 0.0475 rather than the percentage
+	collected := collectRows(t, withCell(4, 8, 0.0475))
+
+	if len(collected.Failures) != 1 || collected.Failures[0].Bond.ISIN != "GB00B24FF097" {
+		t.Fatalf("got %d failures, want the 4¾%% 2030 gilt", len(collected.Failures))
+	}
+
+	err := collected.Failures[0].Err
+	var bondErr *types.BondError
+	if !errors.Is(err, types.ErrInvalidCoupon) || !errors.As(err, &bondErr) || bondErr.Field != "Coupon" || bondErr.Value != 0.0475 {
+		t.Errorf("error = %v, want an invalid Coupon of 0.0475", err)
+	}
+
+	if got := collected.Quality.FailuresByType["invalid_coupon"]; got != 1 {
+		t.Errorf("FailuresByType[invalid_coupon] = %d, want 1", got)
+	}
+
+	// a gilt collected without validation, e.g. from DividendData, fails enrichment
+	b := types.NewUKGiltWithMaturity(SourceDividendData, testDate, 0.035, time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC))
+	b.Ticker = "TR25"
+	b.CleanPrice = 99.5
+	if _, failures := EnrichBonds([]*types.Bond{b}); len(failures) != 1 || !errors.Is(failures[0].Err, types.ErrInvalidCoupon) {
+		t.Errorf("EnrichBonds() failures = %v, want the 0.035 coupon rejected", failures)
+	}
+}
+
+func TestDMOCollect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/umbraco/surface/DataExport/GetDataExport" || query.Get("reportCode") != DMOReportD10B ||
+			query.Get("exportFormatValue") != "xls" || query.Get("parameters") != "&Trade Date=07-03-2025" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.ms-excel")
+		http.ServeFile(w, r, d10bFixture)
+	}))
+	defer server.Close()
+
+	collector := NewDMOCollector(WithBaseURL(server.URL+"/"), WithMetricsOutput(io.Discard))
+
+	collected, err := collector.Collect(t.Context(), time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if len(collected.Bonds) != 3 || collected.Bonds[0].ISIN != "GB00B24FF097" {
+		t.Errorf("got %d bonds, want the 3 gilts of the fixture", len(collected.Bonds))
+	}
+}
+
+func TestDMOMultipleSheets(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	index := xlsSheet{name: "Index", rows: [][]any{{"Contents"}, {"D10B", "Gilt Prices and Yields"}}}
+	linkers := xlsSheet{name: "Index-linked", rows: [][]any{d10bRows[3], d10bRows[7]}}
+	gilts := xlsSheet{name: "D10B", rows: d10bRows}
+
+	// the clean prices are text so every gilt fails to parse
+	failing := xlsSheet{name: "D10B", rows: [][]any{d10bRows[3]}}
+	for _, row := range d10bRows[4:7] {
+		row = slices.Clone(row)
+		row[2] = "n/a"
+		failing.rows = append(failing.rows, row)
+	}
+
+	tests := []struct {
+		name      string
+		sheets    []xlsSheet
+		wantBonds int
+		wantErr   []error
+		// the per sheet counts in the error, the row counts are left out as the parser
+		// reads a blank row after the last
+		wantSheets []string
+	}{
+		{name: "gilt sheet after other sheets", sheets: []xlsSheet{index, linkers, gilts}, wantBonds: 3},
+		{
+			name:       "no gilt sheet",
+			sheets:     []xlsSheet{index, linkers},
+			wantErr:    []error{types.ErrDataUnavailable, ErrNoGiltSheet},
+			wantSheets: []string{`2 sheets (sheet "Index" `, `, 0 gilts, 0 failed; sheet "Index-linked" `, `, 0 gilts, 0 failed)`},
+		},
+		{
+			name:       "all gilts failed",
+			sheets:     []xlsSheet{index, failing},
+			wantErr:    []error{ErrAllRowsFailed},
+			wantSheets: []string{`2 sheets (sheet "Index" `, `, 0 gilts, 0 failed; sheet "D10B" `, `, 3 gilts, 3 failed)`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "D10B.xls")
+			if err := writeWorkbook(path, tt.sheets...); err != nil {
+				t.Fatalf("writeWorkbook() error = %v", err)
+			}
+
+			collected, err := NewDMOCollector().CollectFromFile(date, path)
+
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("CollectFromFile() error = %v, want %v", err, want)
+				}
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("CollectFromFile() error = %v", err)
+			}
+			for _, want := range tt.wantSheets {
+				if !strings.Contains(fmt.Sprint(err), want) {
+					t.Errorf("CollectFromFile() error = %v, want the sheet counts %q", err, want)
+				}
+			}
+
+			if err == nil && len(collected.Bonds) != tt.wantBonds {
+				t.Errorf("got %d bonds, want %d", len(collected.Bonds), tt.wantBonds)
+			}
+		})
+	}
+}
+
+func TestDMODownloadUnexpectedContent(t *testing.T) {
+	const page = "<!DOCTYPE html><html><body><h1>Something went wrong</h1></body></html>"
+
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{name: "html", contentType: "text/html; charset=utf-8"},
+		// the body isn't a workbook whatever the content type says
+		{name: "html as a workbook", contentType: "application/vnd.ms-excel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				io.WriteString(w, page)
+			}))
+			defer server.Close()
+
+			collector := NewDMOCollector(WithBaseURL(server.URL), WithMetricsOutput(io.Discard))
+
+			_, err := collector.Collect(t.Context(), time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC))
+			if !errors.Is(err, ErrUnexpectedContent) || !errors.Is(err, types.ErrDataUnavailable) {
+				t.Errorf("Collect() error = %v, want %v", err, ErrUnexpectedContent)
+			}
+		})
+	}
+}
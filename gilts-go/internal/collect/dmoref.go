@@ -0,0 +1,207 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pbnjay/grate"
+)
+
+// DMOReportD1A is the DMO gilts in issue report, the reference data of every gilt rather than prices.
+const DMOReportD1A = "D1A"
+
+// dmoRefColumns are the column indices of the reference fields in the gilts in issue report.
+type dmoRefColumns struct {
+	Desc          int
+	ISIN          int
+	MaturityDate  int
+	IssueDate     int
+	AmountInIssue int
+}
+
+// max returns the largest column index, rows must have at least this many columns.
+func (c dmoRefColumns) max() int {
+	return max(c.Desc, c.ISIN, c.MaturityDate, c.IssueDate, c.AmountInIssue)
+}
+
+var (
+	// dmoRefCols is the layout of the gilts in issue report export.
+	dmoRefCols = dmoRefColumns{
+		Desc:          0,
+		ISIN:          1,
+		MaturityDate:  2,
+		IssueDate:     3,
+		AmountInIssue: 6,
+	}
+	// dmoRefDateFormats are the accepted formats of the date columns.
+	dmoRefDateFormats = []string{"02-Jan-2006"}
+)
+
+// DMOReferenceCollector collects the gilts in issue from the DMO website, the static reference
+// data of each gilt (coupon, maturity, first issue and amount in issue) rather than prices.
+type DMOReferenceCollector struct {
+	baseURL string
+}
+
+// NewDMOReferenceCollector creates a collector for the gilts in issue report.
+//
+// Parameters:
+//
+//	baseURL: The base URL the report is downloaded from, the DMO website if empty.
+//
+// Returns:
+//
+//	The collector.
+func NewDMOReferenceCollector(baseURL string) *DMOReferenceCollector {
+	if baseURL == "" {
+		baseURL = dmoBaseURL
+	}
+
+	return &DMOReferenceCollector{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Collect downloads and parses the current gilts in issue report.
+func (c *DMOReferenceCollector) Collect(ctx context.Context) ([]types.GiltRef, error) {
+	path, err := downloadDMOReport(ctx, c.baseURL, DMOReportD1A, "")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+
+	return c.parseWorkbook(path)
+}
+
+// CollectFromFile parses a gilts in issue report already downloaded from the DMO website.
+//
+// Parameters:
+//
+//	path: The path of the XLS report.
+//
+// Returns:
+//
+//	The reference data of the conventional gilts in the report.
+func (c *DMOReferenceCollector) CollectFromFile(path string) ([]types.GiltRef, error) {
+	return c.parseWorkbook(path)
+}
+
+// parseWorkbook parses the reference data from a gilts in issue workbook, a panic in the XLS
+// parser or an error reading the workbook is returned as ErrCorruptWorkbook in the same way
+// as the price reports.
+func (c *DMOReferenceCollector) parseWorkbook(path string) (refs []types.GiltRef, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			refs = nil
+			err = fmt.Errorf("%w: %s: %v", ErrCorruptWorkbook, path, r)
+		}
+	}()
+
+	wb, err := grate.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorruptWorkbook, path, err)
+	}
+	defer wb.Close()
+
+	sheets, err := wb.List()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorruptWorkbook, path, err)
+	}
+
+	stats := make([]sheetStats, 0, len(sheets))
+	gilts := 0
+
+	for _, sheetName := range sheets {
+		sheet, err := wb.Get(sheetName)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrCorruptWorkbook, path, err)
+		}
+
+		st := sheetStats{name: sheetName}
+
+		for sheet.Next() {
+			st.rows++
+
+			ref, err := parseRefRow(sheet.Strings())
+			if errors.Is(err, ErrInvaidRow) || errors.Is(err, types.ErrUnsupportedBond) {
+				continue
+			}
+
+			st.gilts++
+
+			if err != nil {
+				st.failed++
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				continue
+			}
+
+			refs = append(refs, *ref)
+		}
+
+		stats = append(stats, st)
+		gilts += st.gilts
+	}
+
+	if gilts == 0 {
+		return nil, fmt.Errorf("%w: %w: %s", types.ErrDataUnavailable, ErrNoGiltSheet, summariseSheets(stats))
+	}
+
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAllRowsFailed, summariseSheets(stats))
+	}
+
+	return refs, nil
+}
+
+// parseRefRow parses the reference data of a gilt from a row of the gilts in issue report.
+// Rows which aren't gilts return ErrInvaidRow and index-linked gilts ErrUnsupportedBond.
+func parseRefRow(row []string) (*types.GiltRef, error) {
+	cols := dmoRefCols
+
+	if len(row) <= cols.max() {
+		return nil, ErrInvaidRow
+	}
+
+	isin := strings.TrimSpace(row[cols.ISIN])
+
+	if !strings.HasPrefix(isin, "GB") {
+		return nil, ErrInvaidRow
+	}
+
+	ref := &types.GiltRef{
+		ISIN: isin,
+		Desc: strings.TrimSpace(row[cols.Desc]),
+	}
+
+	if strings.Contains(strings.ToLower(ref.Desc), "index-linked") {
+		return nil, types.ErrUnsupportedBond
+	}
+
+	coupon, err := types.ParseCouponPercentage(ref.Desc)
+	if err != nil {
+		return nil, fmt.Errorf("ISIN %s column %d: %w", isin, cols.Desc, err)
+	}
+	ref.Coupon = coupon
+
+	ref.MaturityDate, err = parseDate(dmoRefDateFormats, row[cols.MaturityDate])
+	if err != nil {
+		return nil, fmt.Errorf("%w: ISIN %s column %d: %v", types.ErrInvalidMaturityDate, isin, cols.MaturityDate, err)
+	}
+
+	// the first issue date and amount in issue are informational so they don't fail the gilt
+	if ts, err := parseDate(dmoRefDateFormats, row[cols.IssueDate]); err == nil {
+		ref.IssueDate = ts
+	}
+
+	amount := strings.ReplaceAll(strings.TrimSpace(row[cols.AmountInIssue]), ",", "")
+	if f, err := strconv.ParseFloat(amount, 64); err == nil {
+		ref.AmountInIssue = f
+	}
+
+	return ref, nil
+}
@@ -0,0 +1,91 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+	"context"
+	"fmt"
+)
+
+const (
+	// RawVariant is the variant the source bonds are stored under by StoreEnriched.
+	RawVariant = "raw"
+	// EnrichedVariant is the variant the enriched bonds are stored under by StoreEnriched.
+	EnrichedVariant = "enriched"
+)
+
+// EnrichBonds recalculates the yield to maturity and derived fields of a copy of each bond
+// from its clean price, the bonds are unchanged. Bonds which fail are returned separately
+// rather than failing them all.
+//
+// Parameters:
+//
+//	bonds: The bonds to enrich.
+//
+// Returns:
+//
+//	The enriched copies of the bonds and the bonds which failed with their errors.
+func EnrichBonds(bonds []*types.Bond) ([]*types.Bond, []*CollectedBond) {
+	enriched := []*types.Bond{}
+	failures := []*CollectedBond{}
+
+	for _, b := range bonds {
+		c := b.Clone()
+
+		if err := types.CompleteBond(c); err != nil {
+			failures = append(failures, &CollectedBond{Bond: b, Err: err})
+			continue
+		}
+
+		enriched = append(enriched, c)
+	}
+
+	return enriched, failures
+}
+
+// StoredEnriched is the result of StoreEnriched.
+type StoredEnriched struct {
+	// RawPath is the path the source bonds were stored to.
+	RawPath string
+	// EnrichedPath is the path the enriched bonds were stored to.
+	EnrichedPath string
+	// Failures are the bonds which failed enrichment, they are only in the raw bonds.
+	Failures []*CollectedBond
+}
+
+// StoreEnriched stores the collected bonds and an enriched copy of them in one call so they
+// are kept in sync, as source.raw.parquet and source.enriched.parquet. The bonds are enriched
+// before either is stored so a bond which fails enrichment is stored in the raw bonds only,
+// it never prevents the raw bonds being stored. The raw bonds are stored first, if storing
+// the enriched bonds fails the raw bonds remain.
+//
+// Parameters:
+//
+//	ctx:       The context.
+//	collected: The collected bonds.
+//	store:     The storage target.
+//
+// Returns:
+//
+//	The paths the bonds were stored to and the bonds which failed enrichment.
+func StoreEnriched(ctx context.Context, collected *CollectedBonds, store Store) (*StoredEnriched, error) {
+	bonds, failures := EnrichBonds(collected.Bonds)
+
+	enriched := *collected
+	enriched.Bonds = bonds
+
+	rawPath, err := store.StoreVariant(ctx, collected, RawVariant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store raw bonds: %w", err)
+	}
+
+	enrichedPath, err := store.StoreVariant(ctx, &enriched, EnrichedVariant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store enriched bonds: %w", err)
+	}
+
+	return &StoredEnriched{
+		RawPath:      rawPath,
+		EnrichedPath: enrichedPath,
+		Failures:     failures,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+
+	"bytes"
+	"testing"
+)
+
+func TestEnrichedRoundTrip(t *testing.T) {
+	enriched, failures := EnrichBonds(testCollected().Bonds)
+	if len(failures) != 0 {
+		t.Fatalf("EnrichBonds() failed %d bonds: %v", len(failures), failures[0].Err)
+	}
+
+	bonds := roundTrip(t, enriched...)
+
+	for i, b := range bonds {
+		want := enriched[i]
+		if want.Duration <= 0 || want.Convexity <= 0 || want.DV01 <= 0 || want.AccruedAmount <= 0 {
+			t.Fatalf("bond %s isn't enriched: duration %v convexity %v DV01 %v accrued %v",
+				want.ISIN, want.Duration, want.Convexity, want.DV01, want.AccruedAmount)
+		}
+
+		if b.Duration != want.Duration || b.Convexity != want.Convexity || b.DV01 != want.DV01 ||
+			b.AccruedAmount != want.AccruedAmount || b.YieldToMaturity != want.YieldToMaturity {
+			t.Errorf("bond %s read duration %v convexity %v DV01 %v accrued %v yield %v, want %v %v %v %v %v",
+				b.ISIN, b.Duration, b.Convexity, b.DV01, b.AccruedAmount, b.YieldToMaturity,
+				want.Duration, want.Convexity, want.DV01, want.AccruedAmount, want.YieldToMaturity)
+		}
+	}
+}
+
+func TestStoreEnriched(t *testing.T) {
+	collected := testCollected()
+
+	// a matured bond fails enrichment but is still stored in the raw bonds
+	matured := types.NewUKGiltWithMaturity(SourceDMO, testDate, 2, testDate.AddDate(0, -1, 0))
+	matured.ISIN = "GB0000000009"
+	matured.CleanPrice = 100
+	collected.AddBond(&CollectedBond{Bond: matured})
+
+	client := newFakeS3()
+	store := NewS3Store(client, &S3Path{Bucket: "gilts", Prefix: "data"}, DatePrefixed, StoreOptions{})
+
+	stored, err := StoreEnriched(t.Context(), collected, store)
+	if err != nil {
+		t.Fatalf("StoreEnriched() error = %v", err)
+	}
+
+	rawKey, enrichedKey := "data/2025/03/07/DMO.raw.parquet", "data/2025/03/07/DMO.enriched.parquet"
+	if stored.RawPath != "s3://gilts/"+rawKey || stored.EnrichedPath != "s3://gilts/"+enrichedKey {
+		t.Errorf("StoreEnriched() = %s and %s, want %s and %s", stored.RawPath, stored.EnrichedPath, rawKey, enrichedKey)
+	}
+	if client.puts != 2 || len(client.objects) != 2 {
+		t.Errorf("got %d uploads of %d objects, want the raw and enriched objects", client.puts, len(client.objects))
+	}
+
+	if len(stored.Failures) != 1 || stored.Failures[0].Bond.ISIN != matured.ISIN || stored.Failures[0].Err == nil {
+		t.Errorf("Failures = %v, want the matured bond", stored.Failures)
+	}
+
+	read := func(key string) []*types.Bond {
+		data := client.objects[key]
+		bonds, err := ReadBonds(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("ReadBonds(%s) error = %v", key, err)
+		}
+		return bonds
+	}
+
+	raw := read(rawKey)
+	if len(raw) != 4 || raw[3].ISIN != matured.ISIN {
+		t.Errorf("got %d raw bonds, want the 4 collected bonds", len(raw))
+	}
+	for _, b := range raw {
+		if b.YieldToMaturity != 0 {
+			t.Errorf("raw bond %s has yield %v, want the source bond", b.ISIN, b.YieldToMaturity)
+		}
+	}
+
+	enriched := read(enrichedKey)
+	if len(enriched) != 3 {
+		t.Errorf("got %d enriched bonds, want 3 without the matured bond", len(enriched))
+	}
+	for i, b := range enriched {
+		if b.ISIN != raw[i].ISIN || b.YieldToMaturity == 0 || b.Duration == 0 {
+			t.Errorf("enriched bond %s has yield %v duration %v, want the enriched %s", b.ISIN, b.YieldToMaturity, b.Duration, raw[i].ISIN)
+		}
+	}
+
+	// the collected bonds aren't enriched in place
+	if collected.Bonds[0].YieldToMaturity != 0 {
+		t.Errorf("collected bond yield = %v, want it unchanged", collected.Bonds[0].YieldToMaturity)
+	}
+}
+
+func TestCollectGiltRef(t *testing.T) {
+	// the DMO report has only the ISIN of each gilt, the ticker comes from the reference data
+	collected := collectRows(t, withCell(6, 0, "GB00B0000000"))
+
+	tests := []struct {
+		isin       string
+		wantTicker string
+		wantDesc   string
+	}{
+		// the report's description isn't replaced by the reference description
+		{isin: "GB00B24FF097", wantTicker: "TR30", wantDesc: "Treasury Gilt 2030"},
+		{isin: "GB0004893086", wantTicker: "TR32", wantDesc: "4¼% Treasury Gilt 2032"},
+		// an ISIN missing from the reference data has no ticker
+		{isin: "GB00B0000000", wantTicker: "", wantDesc: "3¼% Treasury Gilt 2044"},
+	}
+
+	if len(collected.Bonds) != len(tests) {
+		t.Fatalf("got %d collected bonds, want %d", len(collected.Bonds), len(tests))
+	}
+	for i, tt := range tests {
+		b := collected.Bonds[i]
+		if b.ISIN != tt.isin || b.Ticker != tt.wantTicker || b.Desc != tt.wantDesc {
+			t.Errorf("bond %d = %s %q %q, want %s %q %q", i, b.ISIN, b.Ticker, b.Desc, tt.isin, tt.wantTicker, tt.wantDesc)
+		}
+	}
+
+	// a ticker already set on the bond is kept by the enrichment
+	bond := *collected.Bonds[1]
+	bond.Ticker = "T32"
+	enriched, failures := EnrichBonds([]*types.Bond{&bond})
+	if len(failures) != 0 {
+		t.Fatalf("EnrichBonds() failed: %v", failures[0].Err)
+	}
+	if enriched[0].Ticker != "T32" {
+		t.Errorf("enriched ticker = %s, want the ticker T32 kept", enriched[0].Ticker)
+	}
+}
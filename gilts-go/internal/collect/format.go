@@ -0,0 +1,122 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FormatParquet is the parquet format the bonds are stored in.
+	FormatParquet = "parquet"
+	// FormatCSV is a CSV of the main bond fields with a header row.
+	FormatCSV = "csv"
+	// FormatJSON is a JSON array of the bonds.
+	FormatJSON = "json"
+)
+
+var (
+	ErrInvalidFormat = fmt.Errorf("invalid format")
+)
+
+// ParseFormat parses an output format name, either "parquet", "csv" or "json".
+func ParseFormat(s string) (string, error) {
+	switch format := strings.ToLower(s); format {
+	case FormatParquet, FormatCSV, FormatJSON:
+		return format, nil
+	case "":
+		return FormatParquet, nil
+	}
+	return FormatParquet, ErrInvalidFormat
+}
+
+// csvHeader are the columns of the CSV format.
+var csvHeader = []string{
+	"Source",
+	"ISIN",
+	"Ticker",
+	"Desc",
+	"Coupon",
+	"SettlementDate",
+	"MaturityDate",
+	"CleanPrice",
+	"DirtyPrice",
+	"AccruedAmount",
+	"YieldToMaturity",
+}
+
+func csvRecord(b *types.Bond) []string {
+	date := func(ts time.Time) string {
+		if ts.IsZero() {
+			return ""
+		}
+		return ts.Format("2006-01-02")
+	}
+	float := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	return []string{
+		b.Source,
+		b.ISIN,
+		b.Ticker,
+		b.Desc,
+		float(b.Coupon),
+		date(b.SettlementDate),
+		date(b.MaturityDate),
+		float(b.CleanPrice),
+		float(b.DirtyPrice),
+		float(b.AccruedAmount),
+		float(b.YieldToMaturity),
+	}
+}
+
+func writeCSV(bonds []*types.Bond, output io.Writer) error {
+	writer := csv.NewWriter(output)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, b := range bonds {
+		if err := writer.Write(csvRecord(b)); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteTo writes the collected bonds to a writer, e.g. stdout to pipe them into other tools.
+// The output is written as is, binary parquet is not encoded.
+//
+// Parameters:
+//
+//	w:         The writer.
+//	collected: The collected bonds.
+//	format:    The format, FormatParquet, FormatCSV or FormatJSON.
+//
+// Returns:
+//
+//	ErrInvalidFormat if the format isn't supported.
+func WriteTo(w io.Writer, collected *CollectedBonds, format string) error {
+	switch format {
+	case FormatParquet:
+		return writeBonds(collected.Bonds, w)
+	case FormatCSV:
+		return writeCSV(collected.Bonds, w)
+	case FormatJSON:
+		if err := json.NewEncoder(w).Encode(collected.Bonds); err != nil {
+			return fmt.Errorf("failed to write bonds: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+}
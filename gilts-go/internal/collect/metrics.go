@@ -0,0 +1,69 @@
+package collect
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const metricsNamespace = "Gilts"
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+// emitCollectMetrics writes the collection metrics for a source as a CloudWatch embedded
+// metric format log line: the number of bonds collected, the number of failures, whether
+// the collection failed and the collection duration. In Lambda stdout and stderr are sent to
+// CloudWatch Logs which extracts the metrics from the log line.
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+func emitCollectMetrics(w io.Writer, source string, collected *CollectedBonds, err error, start time.Time) {
+	bonds, failures, failed := 0, 0, 0
+
+	if collected != nil {
+		bonds = len(collected.Bonds)
+		failures = len(collected.Failures)
+	}
+
+	if err != nil {
+		failed = 1
+	}
+
+	line := map[string]any{
+		"_aws": emfMetadata{
+			Timestamp: time.Now().UnixMilli(),
+			CloudWatchMetrics: []emfMetricDirective{
+				{
+					Namespace:  metricsNamespace,
+					Dimensions: [][]string{{"Source"}},
+					Metrics: []emfMetric{
+						{Name: "BondsCollected", Unit: "Count"},
+						{Name: "BondsFailed", Unit: "Count"},
+						{Name: "CollectionFailed", Unit: "Count"},
+						{Name: "CollectionDuration", Unit: "Milliseconds"},
+					},
+				},
+			},
+		},
+		"Source":             source,
+		"BondsCollected":     bonds,
+		"BondsFailed":        failures,
+		"CollectionFailed":   failed,
+		"CollectionDuration": time.Since(start).Milliseconds(),
+	}
+
+	// metrics are best effort and must not fail the collection
+	_ = json.NewEncoder(w).Encode(line)
+}
@@ -0,0 +1,106 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmitCollectMetrics(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	collected := NewCollectedBonds(SourceDMO, date)
+	collected.Bonds = []*types.Bond{{}, {}, {}}
+	collected.Failures = []*CollectedBond{{Err: types.ErrInvalidCoupon}}
+
+	tests := []struct {
+		name      string
+		collected *CollectedBonds
+		err       error
+		want      map[string]float64
+	}{
+		{
+			name:      "collected",
+			collected: collected,
+			want:      map[string]float64{"BondsCollected": 3, "BondsFailed": 1, "CollectionFailed": 0},
+		},
+		{
+			name: "failed",
+			err:  errors.New("failed to get data: http 500"),
+			want: map[string]float64{"BondsCollected": 0, "BondsFailed": 0, "CollectionFailed": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			emitCollectMetrics(&buf, SourceDMO, tt.collected, tt.err, time.Now())
+
+			// CloudWatch Logs extracts the metrics from a single JSON log line
+			if lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); len(lines) != 1 {
+				t.Fatalf("got %d lines, want 1: %s", len(lines), buf.String())
+			}
+
+			var line struct {
+				AWS struct {
+					Timestamp         int64
+					CloudWatchMetrics []struct {
+						Namespace  string
+						Dimensions [][]string
+						Metrics    []struct{ Name, Unit string }
+					}
+				} `json:"_aws"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("failed to decode metrics: %v", err)
+			}
+
+			var values map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &values); err != nil {
+				t.Fatalf("failed to decode metrics: %v", err)
+			}
+
+			if line.AWS.Timestamp <= 0 {
+				t.Errorf("Timestamp = %d, want milliseconds since the epoch", line.AWS.Timestamp)
+			}
+
+			if len(line.AWS.CloudWatchMetrics) != 1 {
+				t.Fatalf("got %d metric directives, want 1", len(line.AWS.CloudWatchMetrics))
+			}
+			directive := line.AWS.CloudWatchMetrics[0]
+
+			if directive.Namespace != metricsNamespace {
+				t.Errorf("Namespace = %q, want %q", directive.Namespace, metricsNamespace)
+			}
+
+			// each dimension and metric must be a member of the log line
+			for _, dims := range directive.Dimensions {
+				for _, dim := range dims {
+					if values[dim] != SourceDMO {
+						t.Errorf("dimension %s = %v, want %s", dim, values[dim], SourceDMO)
+					}
+				}
+			}
+
+			if len(directive.Metrics) != 4 {
+				t.Errorf("got %d metrics, want 4", len(directive.Metrics))
+			}
+			for _, m := range directive.Metrics {
+				if _, ok := values[m.Name].(float64); !ok || m.Unit == "" {
+					t.Errorf("metric %s = %v unit %q, want a number with a unit", m.Name, values[m.Name], m.Unit)
+				}
+			}
+
+			for name, want := range tt.want {
+				if values[name] != want {
+					t.Errorf("%s = %v, want %v", name, values[name], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PriceScale is how a source quotes prices, prices are stored in pounds per £100 nominal.
+type PriceScale int
+
+const (
+	// PricePerHundred is a price in pounds per £100 nominal, e.g. 98.50.
+	PricePerHundred PriceScale = iota
+	// PriceFractionOfPar is a price as a fraction of par, e.g. 0.9850.
+	PriceFractionOfPar
+)
+
+// Scale converts a price quoted in the scale to pounds per £100 nominal.
+func (s PriceScale) Scale(price float64) float64 {
+	if s == PriceFractionOfPar {
+		return price * 100
+	}
+	return price
+}
+
+// The sane range of clean prices per £100 nominal, prices outside the range are most
+// likely parsed from the wrong column or quoted in a different scale.
+const (
+	MinCleanPrice = 20.0
+	MaxCleanPrice = 200.0
+)
+
+// checkCleanPrice checks the clean price (per £100 nominal) is in the sane range.
+func checkCleanPrice(price float64) error {
+	if price < MinCleanPrice || price > MaxCleanPrice {
+		return fmt.Errorf("%w: %.4f is outside %.0f-%.0f", types.ErrInvalidCleanPrice, price, MinCleanPrice, MaxCleanPrice)
+	}
+	return nil
+}
+
+// parsePounds parses a price in pounds, e.g. "£98.50". The currency prefix is stripped whatever
+// its encoding: "£", the mojibake "Â£" when UTF-8 is decoded as Latin-1, or any other leading
+// run of non-numeric characters. Thousands separators are ignored.
+func parsePounds(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+
+	s = strings.TrimLeftFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '-' && r != '+' && r != '.'
+	})
+
+	s = strings.ReplaceAll(s, ",", "")
+
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,35 @@
+package collect
+
+import "testing"
+
+func TestParsePounds(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "£98.50", want: 98.5},
+		// UTF-8 decoded as Latin-1
+		{in: "Â£98.50", want: 98.5},
+		// UTF-8 decoded as Latin-1 twice
+		{in: "Ã‚Â£98.50", want: 98.5},
+		{in: "98.50", want: 98.5},
+		{in: " £ 101.234 ", want: 101.234},
+		{in: "GBP 98.5", want: 98.5},
+		{in: "1,234.5", want: 1234.5},
+		{in: "£1,234.50", want: 1234.5},
+		{in: "£.75", want: 0.75},
+		{in: "£-0.5", want: -0.5},
+		{in: "", wantErr: true},
+		{in: "£", wantErr: true},
+		{in: "£n/a", wantErr: true},
+		{in: "£98.50p", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePounds(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePounds(%q) = %v, %v, want %v, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
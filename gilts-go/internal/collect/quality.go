@@ -0,0 +1,175 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrAccruedMismatch = fmt.Errorf("accrued interest mismatch")
+)
+
+// AccruedTolerance is the maximum difference between the computed and published accrued
+// interest per £100 nominal, allowing for the rounding of the published prices.
+const AccruedTolerance = 0.001
+
+// YieldDiscrepancyTolerance is the maximum difference (in percentage points) between the yield
+// to maturity supplied by the source and the yield computed from the price.
+const YieldDiscrepancyTolerance = 0.05
+
+// QualityOther is the failure category for errors which don't match a known category.
+const QualityOther = "other"
+
+// qualityCategories are the failure categories of collected bonds, matched in order with errors.Is.
+var qualityCategories = []struct {
+	name string
+	err  error
+}{
+	{"invalid_row", ErrInvaidRow},
+	{"unsupported_bond", types.ErrUnsupportedBond},
+	{"invalid_ticker", types.ErrInvalidTicker},
+	{"invalid_desc", types.ErrInvalidDesc},
+	{"invalid_coupon", types.ErrInvalidCoupon},
+	{"invalid_maturity_date", types.ErrInvalidMaturityDate},
+	{"invalid_settlement_date", types.ErrInvalidSettlementDate},
+	{"invalid_clean_price", types.ErrInvalidCleanPrice},
+	{"invalid_dirty_price", types.ErrInvalidDirtyPrice},
+	{"invalid_yield_to_maturity", types.ErrInvalidYieldToMaturity},
+	{"invalid_face_price", types.ErrInvalidFacePrice},
+	{"missing_price_and_yield", types.ErrMissingPriceAndYield},
+	{"no_convergence", types.ErrYieldToMaturityNoConvergence},
+	{"derivative_too_small", types.ErrYieldToMaturityDerivativeTooSmall},
+	{"yield_out_of_bounds", types.ErrYieldOutOfBounds},
+}
+
+// QualityReport is a summary of the data quality of a collection run.
+type QualityReport struct {
+	// RowsSeen is the number of rows read from the source, including rows which aren't bonds.
+	RowsSeen int `json:"rowsSeen"`
+	// Parsed is the number of bonds parsed without error, excluding duplicates.
+	Parsed int `json:"parsed"`
+	// Duplicates is the number of bonds parsed without error but dropped as a duplicate
+	// of another bond.
+	Duplicates int `json:"duplicates"`
+	// Failed is the number of bonds which failed to parse.
+	Failed int `json:"failed"`
+	// FailuresByType is the number of failed bonds per failure category. A bond with
+	// multiple errors is counted in each matching category.
+	FailuresByType map[string]int `json:"failuresByType"`
+	// AccruedChecked is the number of bonds whose accrued interest was reconciled
+	// against the published accrued interest.
+	AccruedChecked int `json:"accruedChecked"`
+	// AccruedMismatches is the number of bonds whose accrued interest differs from
+	// the published accrued interest by more than AccruedTolerance.
+	AccruedMismatches int `json:"accruedMismatches"`
+	// YieldDiscrepancies is the number of bonds whose source yield to maturity differs
+	// from the yield computed from the price by more than YieldDiscrepancyTolerance.
+	YieldDiscrepancies int `json:"yieldDiscrepancies"`
+	// WorstAccrued describes the bond with the largest accrued interest difference.
+	WorstAccrued string `json:"worstAccrued,omitempty"`
+
+	worstAccruedDiff float64
+}
+
+func NewQualityReport() *QualityReport {
+	return &QualityReport{
+		FailuresByType: map[string]int{},
+	}
+}
+
+// AddRow records a row read from the source.
+func (q *QualityReport) AddRow() {
+	q.RowsSeen++
+}
+
+// AddBond records a collected bond, categorising its error if it failed.
+func (q *QualityReport) AddBond(cb *CollectedBond) {
+	if cb.Err == nil {
+		q.Parsed++
+		if math.Abs(cb.Bond.ComputedVsSourceYield) > YieldDiscrepancyTolerance {
+			q.YieldDiscrepancies++
+		}
+		return
+	}
+
+	q.Failed++
+
+	for _, category := range ErrorCategories(cb.Err) {
+		q.FailuresByType[category]++
+	}
+}
+
+// DropDuplicate records a parsed bond dropped as a duplicate, it is no longer counted as parsed.
+func (q *QualityReport) DropDuplicate() {
+	q.Parsed--
+	q.Duplicates++
+}
+
+// ErrorCategories returns the failure categories matching the error using errors.Is,
+// or QualityOther if none match.
+func ErrorCategories(err error) []string {
+	var categories []string
+
+	for _, c := range qualityCategories {
+		if errors.Is(err, c.err) {
+			categories = append(categories, c.name)
+		}
+	}
+
+	if len(categories) == 0 {
+		categories = append(categories, QualityOther)
+	}
+
+	return categories
+}
+
+// AddAccrued reconciles the computed accrued interest of a completed bond against the
+// accrued interest published by the source, recording the bond if it is the worst offender.
+func (q *QualityReport) AddAccrued(b *types.Bond, publishedAccrued float64) {
+	q.AccruedChecked++
+
+	err := reconcileAccrued(b, publishedAccrued, AccruedTolerance)
+	if err == nil {
+		return
+	}
+
+	q.AccruedMismatches++
+
+	if diff := math.Abs(b.AccruedAmount - publishedAccrued); diff > q.worstAccruedDiff {
+		q.worstAccruedDiff = diff
+		q.WorstAccrued = err.Error()
+	}
+}
+
+// reconcileAccrued checks the computed accrued interest of a completed bond is within the
+// tolerance of the published accrued interest.
+//
+// Parameters:
+//
+//	b:                The completed bond.
+//	publishedAccrued: The accrued interest published by the source.
+//	tol:              The tolerance.
+//
+// Returns:
+//
+//	ErrAccruedMismatch if the difference exceeds the tolerance.
+func reconcileAccrued(b *types.Bond, publishedAccrued float64, tol float64) error {
+	diff := b.AccruedAmount - publishedAccrued
+
+	if math.Abs(diff) > tol {
+		return fmt.Errorf(
+			"%w: ISIN %s computed %.6f published %.6f (diff %.6f, %d/%d days)",
+			ErrAccruedMismatch,
+			b.ISIN,
+			b.AccruedAmount,
+			publishedAccrued,
+			diff,
+			b.AccruedDays,
+			b.CouponPeriodDays,
+		)
+	}
+
+	return nil
+}
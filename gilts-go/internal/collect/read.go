@@ -0,0 +1,124 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+)
+
+var (
+	ErrNewerSchemaVersion = fmt.Errorf("newer schema version")
+)
+
+// ReadSchemaVersion reads the schema version from the parquet metadata, 0 if the
+// file was written before versioning was introduced.
+func ReadSchemaVersion(r io.ReaderAt, size int64) (int, error) {
+	file, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open parquet: %w", err)
+	}
+
+	value, ok := file.Lookup(SchemaVersionKey)
+	if !ok {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q: %w", value, err)
+	}
+
+	return version, nil
+}
+
+// ReadBonds reads bonds from parquet written by StoreToPath/StoreToS3.
+// Files written with an older schema version are read with a warning, fields
+// missing from the older schema are zero. Files written with a newer schema version
+// return ErrNewerSchemaVersion, the fields could have changed meaning and this build
+// would silently drop the fields it doesn't know.
+func ReadBonds(r io.ReaderAt, size int64) ([]*types.Bond, error) {
+	version, err := ReadSchemaVersion(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%w: file version %d, current version is %d", ErrNewerSchemaVersion, version, SchemaVersion)
+	}
+
+	// warnings are written to stderr so they don't corrupt a command's output
+	if version < SchemaVersion {
+		fmt.Fprintf(os.Stderr, "Warning: reading schema version %d, current version is %d\n", version, SchemaVersion)
+	}
+
+	rows, err := parquet.Read[types.Bond](r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+
+	bonds := make([]*types.Bond, len(rows))
+	for i := range rows {
+		bonds[i] = &rows[i]
+	}
+
+	return bonds, nil
+}
+
+// ReadBondsFromPath reads bonds from a local parquet file.
+func ReadBondsFromPath(path string) ([]*types.Bond, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadBonds(file, stat.Size())
+}
+
+// LoadBonds reads bonds from a local parquet file or an s3:// object, the AWS config for
+// the profile is only loaded for S3 objects.
+func LoadBonds(ctx context.Context, path string, profile string) ([]*types.Bond, error) {
+	s3Path, _ := ParseS3(path)
+	if s3Path == nil {
+		return ReadBondsFromPath(path)
+	}
+
+	cfg, err := LoadAWSConfig(ctx, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+
+	return LoadFromS3(ctx, s3.NewFromConfig(cfg), s3Path)
+}
+
+// LoadFromS3 reads bonds from a parquet object in S3, the path prefix is the object key.
+func LoadFromS3(ctx context.Context, s3Client S3API, src *S3Path) ([]*types.Bond, error) {
+	output, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(src.Bucket),
+		Key:    aws.String(src.Prefix),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %w", src.Bucket, src.Prefix, err)
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3://%s/%s: %w", src.Bucket, src.Prefix, err)
+	}
+
+	return ReadBonds(bytes.NewReader(data), int64(len(data)))
+}
@@ -0,0 +1,98 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// writeVersionedBonds writes the bonds to parquet with the schema version metadata.
+func writeVersionedBonds(t *testing.T, version int, bonds []*types.Bond) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[*types.Bond](&buf, parquet.KeyValueMetadata(SchemaVersionKey, strconv.Itoa(version)))
+	if _, err := w.Write(bonds); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestReadBondsSchemaVersion(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	b := types.NewUKGiltWithMaturity(SourceDMO, date, 4.25, time.Date(2032, 6, 7, 0, 0, 0, 0, time.UTC))
+	b.ISIN = "GB0004893086"
+
+	tests := []struct {
+		name    string
+		version int
+		wantErr error
+	}{
+		{name: "current", version: SchemaVersion},
+		{name: "older", version: SchemaVersion - 1},
+		{name: "newer", version: SchemaVersion + 1, wantErr: ErrNewerSchemaVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeVersionedBonds(t, tt.version, []*types.Bond{b})
+
+			bonds, err := ReadBonds(bytes.NewReader(data), int64(len(data)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadBonds() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (len(bonds) != 1 || bonds[0].ISIN != b.ISIN) {
+				t.Errorf("ReadBonds() = %d bonds, want the written bond", len(bonds))
+			}
+		})
+	}
+}
+
+// roundTrip writes the bonds to parquet and reads them back.
+func roundTrip(t *testing.T, bonds ...*types.Bond) []*types.Bond {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := writeBonds(bonds, &buf); err != nil {
+		t.Fatalf("writeBonds() error = %v", err)
+	}
+
+	read, err := ReadBonds(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadBonds() error = %v", err)
+	}
+	if len(read) != len(bonds) {
+		t.Fatalf("ReadBonds() = %d bonds, want %d", len(read), len(bonds))
+	}
+
+	return read
+}
+
+func TestReadBondsAmountInIssue(t *testing.T) {
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	withRef := types.NewUKGiltWithMaturity(SourceDMO, date, 4.25, time.Date(2032, 6, 7, 0, 0, 0, 0, time.UTC))
+	types.ApplyGiltRef(withRef, &types.GiltRef{ISIN: "GB0004893086", AmountInIssue: 41_304.174})
+
+	// a bond without reference data has no amount in issue
+	withoutRef := types.NewUKGiltWithMaturity(SourceDMO, date, 3.5, time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC))
+
+	bonds := roundTrip(t, withRef, withoutRef)
+
+	if bonds[0].ISIN != "GB0004893086" || bonds[0].AmountInIssue != 41_304.174 {
+		t.Errorf("bond %s AmountInIssue = %v, want GB0004893086 41304.174", bonds[0].ISIN, bonds[0].AmountInIssue)
+	}
+	if bonds[1].AmountInIssue != 0 {
+		t.Errorf("AmountInIssue = %v, want 0 without reference data", bonds[1].AmountInIssue)
+	}
+}
@@ -0,0 +1,79 @@
+package collect
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownSource = fmt.Errorf("unknown source")
+)
+
+// Registry creates collectors by source name so commands can select the source without
+// depending on the concrete collectors. Names are case insensitive.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]func() Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: map[string]func() Collector{},
+	}
+}
+
+// Register adds the factory for a source name, it panics if the name is already registered.
+func (r *Registry) Register(name string, factory func() Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name = strings.ToLower(name)
+
+	if _, ok := r.factories[name]; ok {
+		panic(fmt.Sprintf("collector %q is already registered", name))
+	}
+
+	r.factories[name] = factory
+}
+
+// New creates the collector for a source name, ErrUnknownSource if the name isn't registered.
+func (r *Registry) New(name string) (Collector, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[strings.ToLower(name)]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownSource, name, strings.Join(r.Names(), ", "))
+	}
+
+	return factory(), nil
+}
+
+// Names returns the registered source names in order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// DefaultRegistry is the registry the collectors in this package register themselves with.
+var DefaultRegistry = NewRegistry()
+
+// Register adds the factory for a source name to the default registry.
+func Register(name string, factory func() Collector) {
+	DefaultRegistry.Register(name, factory)
+}
+
+// CollectorByName creates the collector for a source name from the default registry.
+func CollectorByName(name string) (Collector, error) {
+	return DefaultRegistry.New(name)
+}
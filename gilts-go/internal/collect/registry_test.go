@@ -0,0 +1,73 @@
+package collect
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	stub := NewStubCollector("Tradeweb", NewCollectedBonds("Tradeweb", testDate))
+	r.Register("tradeweb", func() Collector { return stub })
+
+	// names are case insensitive
+	for _, name := range []string{"tradeweb", "Tradeweb"} {
+		c, err := r.New(name)
+		if err != nil {
+			t.Fatalf("New(%s) error = %v", name, err)
+		}
+		if c != stub {
+			t.Errorf("New(%s) = %v, want the registered stub", name, c)
+		}
+	}
+
+	if _, err := r.New("lse"); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("New(lse) error = %v, want %v", err, ErrUnknownSource)
+	}
+
+	if names := r.Names(); !slices.Equal(names, []string{"tradeweb"}) {
+		t.Errorf("Names() = %v, want [tradeweb]", names)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register() of a registered name didn't panic")
+		}
+	}()
+	r.Register("TRADEWEB", func() Collector { return stub })
+}
+
+func TestCollectorByName(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantType string
+	}{
+		{name: "dmo", wantType: "*collect.DMOCollector"},
+		{name: "DMO", wantType: "*collect.DMOCollector"},
+		{name: "dividenddata", wantType: "*collect.DividendDataCollector"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := CollectorByName(tt.name)
+			if err != nil {
+				t.Fatalf("CollectorByName() error = %v", err)
+			}
+			if got := fmt.Sprintf("%T", c); got != tt.wantType {
+				t.Errorf("CollectorByName() = %s, want %s", got, tt.wantType)
+			}
+		})
+	}
+
+	// tradeweb isn't implemented so isn't registered
+	if _, err := CollectorByName("tradeweb"); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("CollectorByName(tradeweb) error = %v, want %v", err, ErrUnknownSource)
+	}
+
+	if names := DefaultRegistry.Names(); !slices.Equal(names, []string{"dividenddata", "dmo"}) {
+		t.Errorf("Names() = %v, want [dividenddata dmo]", names)
+	}
+}
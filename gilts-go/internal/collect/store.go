@@ -0,0 +1,166 @@
+package collect
+
+import (
+	"benritz/gilts/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Store is a storage target for collected bonds.
+type Store interface {
+	// Store persists the collected bonds and returns the path they were stored to.
+	Store(ctx context.Context, collected *CollectedBonds) (string, error)
+	// StoreVariant persists a variant of the collected bonds, e.g. raw or enriched, alongside
+	// the source's file (source.variant.parquet) and returns the path they were stored to.
+	StoreVariant(ctx context.Context, collected *CollectedBonds, variant string) (string, error)
+	// Exists checks if bonds have already been stored for the source and date.
+	Exists(ctx context.Context, source string, date time.Time) (bool, error)
+	// Load reads the bonds stored for the source and date, ErrBondsNotFound if none have been stored.
+	Load(ctx context.Context, source string, date time.Time) ([]*types.Bond, error)
+}
+
+var (
+	ErrBondsNotFound = fmt.Errorf("bonds not found")
+)
+
+// LoadAWSConfig loads the AWS config for the shared config profile, "default" for the
+// default credential chain.
+func LoadAWSConfig(ctx context.Context, profile string) (aws.Config, error) {
+	if profile == "default" {
+		return config.LoadDefaultConfig(ctx)
+	}
+	return config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
+}
+
+// NewStore returns the storage for the destination, an S3 bucket for s3:// destinations
+// otherwise a local directory. The AWS config is only loaded for S3 destinations.
+//
+// Parameters:
+//
+//	dst:     The destination, a local directory or s3://bucket/prefix.
+//	profile: The AWS profile to use for S3 destinations.
+//	layout:  The storage layout.
+//	opts:    The S3 storage options, ignored for local directories.
+func NewStore(ctx context.Context, dst string, profile string, layout KeyLayout, opts StoreOptions) (Store, error) {
+	s3Path, _ := ParseS3(dst)
+	if s3Path == nil {
+		return NewPathStore(dst, layout), nil
+	}
+
+	cfg, err := LoadAWSConfig(ctx, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+
+	return NewS3Store(s3.NewFromConfig(cfg), s3Path, layout, opts), nil
+}
+
+// PathStore stores collected bonds under a local directory.
+type PathStore struct {
+	Basepath string
+	Layout   KeyLayout
+}
+
+func NewPathStore(basepath string, layout KeyLayout) *PathStore {
+	return &PathStore{
+		Basepath: basepath,
+		Layout:   layout,
+	}
+}
+
+func (s *PathStore) Store(ctx context.Context, collected *CollectedBonds) (string, error) {
+	return StoreToPath(ctx, collected, s.Basepath, s.Layout)
+}
+
+func (s *PathStore) StoreVariant(ctx context.Context, collected *CollectedBonds, variant string) (string, error) {
+	return storeVariantToPath(ctx, collected, s.Basepath, s.Layout, variant)
+}
+
+func (s *PathStore) Exists(ctx context.Context, source string, date time.Time) (bool, error) {
+	_, err := os.Stat(localPath(s.Basepath, source, date, s.Layout, ""))
+	if err == nil {
+		return true, nil
+	}
+
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+func (s *PathStore) Load(ctx context.Context, source string, date time.Time) ([]*types.Bond, error) {
+	path := localPath(s.Basepath, source, date, s.Layout, "")
+
+	bonds, err := ReadBondsFromPath(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBondsNotFound, path)
+	}
+
+	return bonds, err
+}
+
+// S3Store stores collected bonds in an S3 bucket.
+type S3Store struct {
+	Client  S3API
+	Dst     *S3Path
+	Layout  KeyLayout
+	Options StoreOptions
+}
+
+func NewS3Store(client S3API, dst *S3Path, layout KeyLayout, opts StoreOptions) *S3Store {
+	return &S3Store{
+		Client:  client,
+		Dst:     dst,
+		Layout:  layout,
+		Options: opts,
+	}
+}
+
+func (s *S3Store) Store(ctx context.Context, collected *CollectedBonds) (string, error) {
+	return StoreToS3(ctx, collected, s.Client, s.Dst, s.Layout, s.Options)
+}
+
+func (s *S3Store) StoreVariant(ctx context.Context, collected *CollectedBonds, variant string) (string, error) {
+	return storeVariantToS3(ctx, collected, s.Client, s.Dst, s.Layout, s.Options, variant)
+}
+
+func (s *S3Store) Exists(ctx context.Context, source string, date time.Time) (bool, error) {
+	key := s3Key(s.Dst, source, date, s.Layout, "")
+
+	_, err := s.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.Dst.Bucket),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	var notFound *s3types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+
+	return false, fmt.Errorf("failed to check s3://%s/%s: %w", s.Dst.Bucket, key, err)
+}
+
+func (s *S3Store) Load(ctx context.Context, source string, date time.Time) ([]*types.Bond, error) {
+	key := s3Key(s.Dst, source, date, s.Layout, "")
+
+	bonds, err := LoadFromS3(ctx, s.Client, &S3Path{Bucket: s.Dst.Bucket, Prefix: key})
+
+	var noSuchKey *s3types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, fmt.Errorf("%w: s3://%s/%s", ErrBondsNotFound, s.Dst.Bucket, key)
+	}
+
+	return bonds, err
+}
@@ -0,0 +1,49 @@
+package collect
+
+import (
+	"context"
+	"time"
+)
+
+// StubCollector is an in-memory Collector which returns preconfigured bonds or an error,
+// for exercising storage and processing without fetching from a source.
+type StubCollector struct {
+	// Collected is returned by Collect, its settlement date is set to the requested date.
+	Collected *CollectedBonds
+	// Err is returned by Collect instead of the bonds if set.
+	Err error
+	// SourceName is the source of the collector.
+	SourceName string
+	// Dates are the dates Collect was called with.
+	Dates []time.Time
+}
+
+// NewStubCollector creates a collector which returns the bonds for the source.
+func NewStubCollector(source string, collected *CollectedBonds) *StubCollector {
+	return &StubCollector{
+		Collected:  collected,
+		SourceName: source,
+	}
+}
+
+func (c *StubCollector) Collect(ctx context.Context, date time.Time) (*CollectedBonds, error) {
+	c.Dates = append(c.Dates, date)
+
+	if c.Err != nil {
+		return nil, c.Err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	collected := *c.Collected
+	collected.Source = c.SourceName
+	collected.SettlementDate = date
+
+	return &collected, nil
+}
+
+func (c *StubCollector) Source() string {
+	return c.SourceName
+}
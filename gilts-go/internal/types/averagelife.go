@@ -0,0 +1,49 @@
+package types
+
+import "time"
+
+// principalRepayment is a repayment of principal as a fraction of the face value.
+type principalRepayment struct {
+	Date     time.Time
+	Fraction float64
+}
+
+// principalRepayments returns the principal repayments of a bond after the settlement date.
+// Gilts are bullet bonds, the face value is repaid in full on the maturity date.
+func principalRepayments(b *Bond) []principalRepayment {
+	return []principalRepayment{
+		{Date: b.MaturityDate, Fraction: 1},
+	}
+}
+
+// WeightedAverageLife calculates the average time to the repayment of the principal weighted
+// by the principal repaid. For a bullet bond such as a gilt this is the time to maturity, for
+// amortising or sinking fund bonds it is shorter.
+//
+// Parameters:
+//
+//	b: The bond.
+//
+// Returns:
+//
+//	The weighted average life in years, zero if the bond has matured or the dates are invalid.
+func WeightedAverageLife(b *Bond) float64 {
+	life := 0.0
+	total := 0.0
+
+	for _, r := range principalRepayments(b) {
+		years, err := MaturityYearFraction(b.SettlementDate, r.Date)
+		if err != nil {
+			continue
+		}
+
+		life += years * r.Fraction
+		total += r.Fraction
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return life / total
+}
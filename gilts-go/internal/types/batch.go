@@ -0,0 +1,71 @@
+package types
+
+import (
+	"runtime"
+	"sync"
+)
+
+// CompleteBonds completes each bond in turn, a bond which fails doesn't stop the others.
+//
+// Parameters:
+//
+//	bonds: The bonds to complete.
+//	opts:  The solver options.
+//
+// Returns:
+//
+//	The error completing each bond, nil if it succeeded, in the same order as the bonds.
+func CompleteBonds(bonds []*Bond, opts SolverOptions) []error {
+	errs := make([]error, len(bonds))
+
+	for i, b := range bonds {
+		errs[i] = CompleteBondWithOptions(b, opts)
+	}
+
+	return errs
+}
+
+// CompleteBondsParallel completes the bonds concurrently with a pool of workers, the pricing
+// of each bond is independent so a large universe can use all the CPUs. Each bond is only
+// completed by one worker so the bonds must be distinct.
+//
+// Parameters:
+//
+//	bonds:   The bonds to complete.
+//	workers: The number of workers, the number of CPUs if not positive.
+//	opts:    The solver options.
+//
+// Returns:
+//
+//	The error completing each bond, nil if it succeeded, in the same order as the bonds.
+func CompleteBondsParallel(bonds []*Bond, workers int, opts SolverOptions) []error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	errs := make([]error, len(bonds))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range min(workers, len(bonds)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// each worker writes only the errors of the bonds it completes
+			for i := range indices {
+				errs[i] = CompleteBondWithOptions(bonds[i], opts)
+			}
+		}()
+	}
+
+	for i := range bonds {
+		indices <- i
+	}
+	close(indices)
+
+	wg.Wait()
+
+	return errs
+}
@@ -0,0 +1,35 @@
+package types
+
+import "math"
+
+// ToContinuous converts a yield compounded a number of times per year to a continuously
+// compounded yield, i.e. solves (1 + y/n)^n = e^r for r.
+//
+// Parameters:
+//
+//	yieldPct: Annual yield (as a percentage), e.g. a semi-annual bond-equivalent yield.
+//	freq:     The number of compounding periods per year.
+//
+// Returns:
+//
+//	Continuously compounded yield as a percentage.
+func ToContinuous(yieldPct float64, freq int) float64 {
+	n := float64(freq)
+	return n * math.Log1p(yieldPct/100/n) * 100
+}
+
+// ToCompounded converts a continuously compounded yield to a yield compounded a number of
+// times per year, the inverse of ToContinuous.
+//
+// Parameters:
+//
+//	continuousPct: Continuously compounded yield (as a percentage).
+//	freq:          The number of compounding periods per year.
+//
+// Returns:
+//
+//	Annual yield compounded freq times per year as a percentage.
+func ToCompounded(continuousPct float64, freq int) float64 {
+	n := float64(freq)
+	return n * math.Expm1(continuousPct/100/n) * 100
+}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// couponPercentageRe matches a coupon percentage at the start of a description.
+var couponPercentageRe = regexp.MustCompile(`^(\d+(?:\s+\d+\/\d+)?|\d+\/\d+|\d+\.\d+|\d+|\d[¼½¾])(%)`)
+
+// ParseCouponPercentage parses a coupon percentage from the start of a gilt description in the following formats
+// 0 5/8% Treasury Gilt 2025,
+// 2% Treasury Gilt 2025,
+// 3½% Treasury Gilt 2025,
+// 4.25% 2030
+//
+//	desc: bond description
+//
+// Returns:
+//
+//	Coupon percentage, the error is a BondError for the description.
+func ParseCouponPercentage(desc string) (float64, error) {
+	coupon, err := parseCouponPercentage(desc)
+	if err != nil {
+		return 0, newBondError(err, "Desc", desc)
+	}
+	return coupon, nil
+}
+
+func parseCouponPercentage(desc string) (float64, error) {
+	match := couponPercentageRe.FindStringSubmatch(desc)
+
+	if len(match) < 3 {
+		return 0, ErrInvalidCoupon
+	}
+
+	m := match[1]
+
+	// convert ½, ¼, ¾ suffixes
+	trimLast := func(s string) string {
+		r := []rune(s)
+		return string(r[0 : len(r)-1])
+	}
+	if strings.HasSuffix(m, "½") {
+		m = trimLast(m) + " 1/2"
+	} else if strings.HasSuffix(m, "¼") {
+		m = trimLast(m) + " 1/4"
+	} else if strings.HasSuffix(m, "¾") {
+		m = trimLast(m) + " 3/4"
+	}
+
+	if strings.Contains(m, "/") {
+		parts := strings.Split(m, " ")
+		if len(parts) == 2 {
+			// Mixed number
+			whole, err := strconv.Atoi(parts[0])
+			if err != nil {
+				return 0, ErrInvalidCoupon
+			}
+			fractionParts := strings.Split(parts[1], "/")
+			if len(fractionParts) != 2 {
+				return 0, ErrInvalidCoupon
+			}
+			num, err := strconv.Atoi(fractionParts[0])
+			if err != nil {
+				return 0, ErrInvalidCoupon
+			}
+			den, err := strconv.Atoi(fractionParts[1])
+			if err != nil {
+				return 0, ErrInvalidCoupon
+			}
+			if den == 0 {
+				return 0, ErrInvalidCoupon
+			}
+			return float64(whole) + float64(num)/float64(den), nil
+		} else if len(parts) == 1 {
+			// Fraction only
+			fractionParts := strings.Split(parts[0], "/")
+			if len(fractionParts) != 2 {
+				return 0, ErrInvalidCoupon
+			}
+			num, err := strconv.Atoi(fractionParts[0])
+			if err != nil {
+				return 0, ErrInvalidCoupon
+			}
+			den, err := strconv.Atoi(fractionParts[1])
+			if err != nil {
+				return 0, ErrInvalidCoupon
+			}
+			if den == 0 {
+				return 0, ErrInvalidCoupon
+			}
+			return float64(num) / float64(den), nil
+		}
+	} else {
+		// Whole number
+		val, err := strconv.ParseFloat(m, 64)
+		if err != nil {
+			return 0, ErrInvalidCoupon
+		}
+		return val, nil
+	}
+
+	return 0, ErrInvalidCoupon
+}
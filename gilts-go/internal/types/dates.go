@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+// DateClamped returns the date for the year, month and day of month, clamping the day to the
+// last day of the month rather than rolling over into the next month as time.Date does,
+// e.g. 29 February in a non-leap year is 28 February and 31 April is 30 April.
+func DateClamped(year int, month time.Month, day int, loc *time.Location) time.Time {
+	// day 0 of the next month is the last day of the month
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > last {
+		day = last
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// AddMonthsClamped adds a number of months to a date with the result on the given day of month,
+// clamped to the last day of the month. The day is usually the coupon day of the bond (the
+// maturity day) so a schedule doesn't drift after passing through a short month, e.g. the
+// 31 August coupon six months after 28 February.
+func AddMonthsClamped(t time.Time, months int, day int) time.Time {
+	// normalise the year and month, the day of the first of the month never rolls over
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+
+	return DateClamped(first.Year(), first.Month(), day, t.Location())
+}
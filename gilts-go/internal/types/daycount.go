@@ -0,0 +1,185 @@
+package types
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// DayCount is the day count convention used to calculate accrued interest.
+type DayCount int
+
+const (
+	// ActualActual is Actual/Actual (ICMA), the actual days accrued over the actual days
+	// in the coupon period. This is the convention for UK gilts.
+	ActualActual DayCount = iota
+	// Actual365 is Actual/365 (Fixed), the actual days accrued over a 365 day year.
+	Actual365
+	// Thirty360 is 30/360 (Bond Basis), each month has 30 days over a 360 day year.
+	Thirty360
+)
+
+func (dc DayCount) String() string {
+	switch dc {
+	case ActualActual:
+		return "actualactual"
+	case Actual365:
+		return "act365"
+	case Thirty360:
+		return "30360"
+	}
+	return "unknown"
+}
+
+// ParseDayCount parses a day count convention name: actualactual, act365 or 30360.
+func ParseDayCount(s string) (DayCount, error) {
+	switch strings.ToLower(s) {
+	case "actualactual", "actual/actual", "act/act":
+		return ActualActual, nil
+	case "act365", "actual/365", "act/365":
+		return Actual365, nil
+	case "30360", "30/360":
+		return Thirty360, nil
+	}
+	return ActualActual, newBondError(ErrInvalidDayCount, "DayCountConvention", s)
+}
+
+// AccrualRule is which ends of the accrual period are counted in the accrued days, the
+// rules differ by one day of accrued interest.
+type AccrualRule int
+
+const (
+	// SettlementExclusive accrues from the previous coupon date (inclusive) to the settlement
+	// date (exclusive), settling the day after a coupon date accrues one day. This is the
+	// convention for UK gilts.
+	SettlementExclusive AccrualRule = iota
+	// SettlementInclusive accrues from the previous coupon date to the settlement date both
+	// inclusive, settling the day after a coupon date accrues two days.
+	SettlementInclusive
+)
+
+func (r AccrualRule) String() string {
+	switch r {
+	case SettlementExclusive:
+		return "exclusive"
+	case SettlementInclusive:
+		return "inclusive"
+	}
+	return "unknown"
+}
+
+// ParseAccrualRule parses an accrual rule name: exclusive or inclusive.
+func ParseAccrualRule(s string) (AccrualRule, error) {
+	switch strings.ToLower(s) {
+	case "", "exclusive":
+		return SettlementExclusive, nil
+	case "inclusive":
+		return SettlementInclusive, nil
+	}
+	return SettlementExclusive, newBondError(ErrInvalidAccrualRule, "AccrualRule", s)
+}
+
+// AccrualEnd returns the end date (exclusive) of the accrual period for the settlement date.
+func (r AccrualRule) AccrualEnd(settlement time.Time) time.Time {
+	if r == SettlementInclusive {
+		return settlement.AddDate(0, 0, 1)
+	}
+	return settlement
+}
+
+// actualDays returns the number of actual days between two dates.
+func actualDays(start, end time.Time) int {
+	return int(math.Floor(end.Sub(start).Hours() / 24))
+}
+
+// days360 returns the number of days between two dates using 30/360 (Bond Basis).
+func days360(start, end time.Time) int {
+	d1 := start.Day()
+	d2 := end.Day()
+
+	if d1 == 31 {
+		d1 = 30
+	}
+
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+
+	return 360*(end.Year()-start.Year()) + 30*int(end.Month()-start.Month()) + (d2 - d1)
+}
+
+// AccruedFraction calculates the fraction of the coupon period accrued at the settlement date.
+//
+// Parameters:
+//
+//	settlement: The settlement date.
+//	prevCoupon: The previous coupon date.
+//	nextCoupon: The next coupon date.
+//	freq:       The number of coupon payments per year.
+//
+// Returns:
+//
+//	Fraction of the coupon period accrued.
+func (dc DayCount) AccruedFraction(settlement, prevCoupon, nextCoupon time.Time, freq int) float64 {
+	switch dc {
+	case Actual365:
+		return float64(actualDays(prevCoupon, settlement)) * float64(freq) / 365
+	case Thirty360:
+		return float64(days360(prevCoupon, settlement)) * float64(freq) / 360
+	default:
+		return float64(actualDays(prevCoupon, settlement)) / float64(actualDays(prevCoupon, nextCoupon))
+	}
+}
+
+// couponFrequency infers the number of coupon payments per year from the coupon dates.
+func couponFrequency(prevCoupon, nextCoupon time.Time) int {
+	months := (nextCoupon.Year()-prevCoupon.Year())*12 + int(nextCoupon.Month()-prevCoupon.Month())
+	if months <= 0 || months > 12 {
+		return 2
+	}
+	return 12 / months
+}
+
+// CleanToDirty converts a clean price to a dirty price by adding the accrued interest.
+// The coupon frequency is inferred from the coupon dates.
+//
+// Parameters:
+//
+//	cleanPrice: Clean price of the bond.
+//	coupon:     Annual coupon rate (as a percentage).
+//	face:       Face value of the bond.
+//	settlement: The settlement date.
+//	prevCoupon: The previous coupon date.
+//	nextCoupon: The next coupon date.
+//	conv:       The day count convention.
+//
+// Returns:
+//
+//	Dirty price.
+func CleanToDirty(cleanPrice, coupon, face float64, settlement, prevCoupon, nextCoupon time.Time, conv DayCount) float64 {
+	freq := couponFrequency(prevCoupon, nextCoupon)
+	fraction := conv.AccruedFraction(settlement, prevCoupon, nextCoupon, freq)
+	return cleanPrice + accruedInterest(coupon, face, fraction, freq)
+}
+
+// DirtyToClean converts a dirty price to a clean price by subtracting the accrued interest.
+// The coupon frequency is inferred from the coupon dates.
+//
+// Parameters:
+//
+//	dirtyPrice: Dirty price of the bond.
+//	coupon:     Annual coupon rate (as a percentage).
+//	face:       Face value of the bond.
+//	settlement: The settlement date.
+//	prevCoupon: The previous coupon date.
+//	nextCoupon: The next coupon date.
+//	conv:       The day count convention.
+//
+// Returns:
+//
+//	Clean price.
+func DirtyToClean(dirtyPrice, coupon, face float64, settlement, prevCoupon, nextCoupon time.Time, conv DayCount) float64 {
+	freq := couponFrequency(prevCoupon, nextCoupon)
+	fraction := conv.AccruedFraction(settlement, prevCoupon, nextCoupon, freq)
+	return dirtyPrice - accruedInterest(coupon, face, fraction, freq)
+}
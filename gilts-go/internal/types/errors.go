@@ -0,0 +1,38 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// BondError is an error in a bond field, it wraps one of the Err sentinels so the category
+// can be checked with errors.Is while keeping the field and the offending value.
+type BondError struct {
+	// Err is the sentinel error, e.g. ErrInvalidCoupon.
+	Err error
+	// Field is the name of the bond field, e.g. Coupon.
+	Field string
+	// Value is the offending value of the field.
+	Value any
+}
+
+// newBondError creates a BondError for the field and value.
+func newBondError(err error, field string, value any) *BondError {
+	return &BondError{
+		Err:   err,
+		Field: field,
+		Value: value,
+	}
+}
+
+func (e *BondError) Error() string {
+	value := e.Value
+	if ts, ok := value.(time.Time); ok {
+		value = ts.Format("2006-01-02")
+	}
+	return fmt.Sprintf("%v: %s %q", e.Err, e.Field, fmt.Sprint(value))
+}
+
+func (e *BondError) Unwrap() error {
+	return e.Err
+}
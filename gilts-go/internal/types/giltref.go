@@ -0,0 +1,310 @@
+package types
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GiltRef is the static reference data for a gilt, fields that are fixed for the
+// life of the gilt and independent of the daily prices.
+type GiltRef struct {
+	ISIN            string
+	Ticker          string
+	Desc            string
+	Coupon          float64
+	MaturityDate    time.Time
+	FirstCouponDate time.Time // zero if not known
+	CouponFrequency int       // zero if the gilt pays the default semi-annual coupons
+	IssueDate       time.Time // zero if not known
+	AmountInIssue   float64   // nominal amount in issue (£ million), zero if not known
+}
+
+// RedemptionDate returns the date the gilt is redeemed, gilts are redeemed at par on the maturity date.
+func (r *GiltRef) RedemptionDate() time.Time {
+	return r.MaturityDate
+}
+
+//go:embed gilts.json
+var giltsJSON []byte
+
+type giltRefJSON struct {
+	ISIN            string  `json:"isin"`
+	Ticker          string  `json:"ticker"`
+	Desc            string  `json:"desc"`
+	Coupon          float64 `json:"coupon"`
+	MaturityDate    string  `json:"maturityDate"`
+	FirstCouponDate string  `json:"firstCouponDate,omitempty"`
+	Frequency       int     `json:"frequency,omitempty"`
+	IssueDate       string  `json:"issueDate,omitempty"`
+	AmountInIssue   float64 `json:"amountInIssue,omitempty"`
+}
+
+type giltRefs struct {
+	byISIN   map[string]*GiltRef
+	byTicker map[string]*GiltRef
+}
+
+var loadGiltRefs = sync.OnceValue(func() *giltRefs {
+	refs, err := parseGiltRefs(giltsJSON)
+	if err != nil {
+		// the reference file is embedded so this is a build error
+		panic(fmt.Sprintf("invalid embedded gilts.json: %v", err))
+	}
+	return refs
+})
+
+func parseGiltRefs(data []byte) (*giltRefs, error) {
+	var rows []giltRefJSON
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	refs := &giltRefs{
+		byISIN:   make(map[string]*GiltRef, len(rows)),
+		byTicker: make(map[string]*GiltRef, len(rows)),
+	}
+
+	for _, row := range rows {
+		ref := &GiltRef{
+			ISIN:            row.ISIN,
+			Ticker:          row.Ticker,
+			Desc:            row.Desc,
+			Coupon:          row.Coupon,
+			CouponFrequency: row.Frequency,
+			AmountInIssue:   row.AmountInIssue,
+		}
+
+		ts, err := time.Parse("2006-01-02", row.MaturityDate)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid maturity date: %w", row.ISIN, err)
+		}
+		ref.MaturityDate = ts
+
+		if row.FirstCouponDate != "" {
+			ts, err := time.Parse("2006-01-02", row.FirstCouponDate)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid first coupon date: %w", row.ISIN, err)
+			}
+			ref.FirstCouponDate = ts
+		}
+
+		if row.IssueDate != "" {
+			ts, err := time.Parse("2006-01-02", row.IssueDate)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid issue date: %w", row.ISIN, err)
+			}
+			ref.IssueDate = ts
+		}
+
+		if ref.ISIN != "" {
+			refs.byISIN[ref.ISIN] = ref
+		}
+		if ref.Ticker != "" {
+			refs.byTicker[ref.Ticker] = ref
+		}
+	}
+
+	return refs, nil
+}
+
+// MarshalGiltRefs encodes the reference data in the format of the embedded gilts.json, one
+// gilt per line in order of maturity so regenerating the file gives a readable diff.
+//
+// Parameters:
+//
+//	refs: The reference data of the gilts.
+//
+// Returns:
+//
+//	The JSON array of the gilts.
+func MarshalGiltRefs(refs []GiltRef) ([]byte, error) {
+	sorted := slices.Clone(refs)
+	slices.SortFunc(sorted, func(a, b GiltRef) int {
+		if c := a.MaturityDate.Compare(b.MaturityDate); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ISIN, b.ISIN)
+	})
+
+	date := func(ts time.Time) string {
+		if ts.IsZero() {
+			return ""
+		}
+		return ts.Format("2006-01-02")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+
+	for i, ref := range sorted {
+		row, err := json.Marshal(giltRefJSON{
+			ISIN:            ref.ISIN,
+			Ticker:          ref.Ticker,
+			Desc:            ref.Desc,
+			Coupon:          ref.Coupon,
+			MaturityDate:    date(ref.MaturityDate),
+			FirstCouponDate: date(ref.FirstCouponDate),
+			Frequency:       ref.CouponFrequency,
+			IssueDate:       date(ref.IssueDate),
+			AmountInIssue:   ref.AmountInIssue,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString("  ")
+		buf.Write(row)
+		if i < len(sorted)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("]\n")
+
+	return buf.Bytes(), nil
+}
+
+// LookupGilt finds the reference data for a gilt by its ISIN.
+//
+// Parameters:
+//
+//	isin: The ISIN of the gilt.
+//
+// Returns:
+//
+//	The reference data and true if the gilt is known, otherwise nil and false.
+func LookupGilt(isin string) (*GiltRef, bool) {
+	ref, ok := loadGiltRefs().byISIN[isin]
+	return ref, ok
+}
+
+// LookupGiltByTicker finds the reference data for a gilt by its ticker.
+//
+// Parameters:
+//
+//	ticker: The ticker of the gilt.
+//
+// Returns:
+//
+//	The reference data and true if the gilt is known, otherwise nil and false.
+func LookupGiltByTicker(ticker string) (*GiltRef, bool) {
+	ref, ok := loadGiltRefs().byTicker[ticker]
+	return ref, ok
+}
+
+// lookupBondRef finds the reference data for a bond by its ISIN or otherwise its ticker.
+func lookupBondRef(b *Bond) (*GiltRef, bool) {
+	if b.ISIN != "" {
+		if ref, ok := LookupGilt(b.ISIN); ok {
+			return ref, true
+		}
+	}
+	if b.Ticker != "" {
+		return LookupGiltByTicker(b.Ticker)
+	}
+	return nil, false
+}
+
+// MergeGiltRef fills in the bond's missing fields from the gilt reference data,
+// found by the bond's ISIN or otherwise its ticker. Only empty fields are filled,
+// fields already set on the bond are never overwritten.
+//
+// Parameters:
+//
+//	b: The bond to fill in.
+//
+// Returns:
+//
+//	True if reference data was found for the bond.
+func MergeGiltRef(b *Bond) bool {
+	ref, ok := lookupBondRef(b)
+	if !ok {
+		return false
+	}
+
+	ApplyGiltRef(b, ref)
+
+	return true
+}
+
+// ApplyGiltRef fills in the bond's missing fields from the reference data of the gilt,
+// e.g. collected by the DMO reference collector rather than the embedded reference data.
+// Only empty fields are filled, fields already set on the bond are never overwritten.
+//
+// Parameters:
+//
+//	b:   The bond to fill in.
+//	ref: The reference data of the gilt.
+func ApplyGiltRef(b *Bond, ref *GiltRef) {
+	if b.ISIN == "" {
+		b.ISIN = ref.ISIN
+	}
+	if b.Ticker == "" {
+		b.Ticker = ref.Ticker
+	}
+	if b.Desc == "" {
+		b.Desc = ref.Desc
+	}
+	if b.Coupon == 0 && !b.Strip {
+		b.Coupon = ref.Coupon
+	}
+	if b.MaturityDate.IsZero() {
+		b.MaturityDate = ref.MaturityDate
+	}
+	if b.CouponFrequency == 0 {
+		b.CouponFrequency = ref.CouponFrequency
+	}
+	if b.FirstCouponDate.IsZero() {
+		b.FirstCouponDate = ref.FirstCouponDate
+	}
+	if b.IssueDate.IsZero() {
+		b.IssueDate = ref.IssueDate
+	}
+	if b.AmountInIssue == 0 {
+		b.AmountInIssue = ref.AmountInIssue
+	}
+}
+
+// CheckGiltRef checks the bond's maturity date falls on a coupon date of the gilt reference
+// data, the coupons of a gilt are paid on the same day of the month as it matures. A mismatch
+// is most likely a corrupted date in the source data. Bonds without reference data pass.
+//
+// Parameters:
+//
+//	b: The bond to check.
+//
+// Returns:
+//
+//	ErrInvalidMaturityDate if the maturity date isn't on a reference coupon date.
+func CheckGiltRef(b *Bond) error {
+	ref, ok := lookupBondRef(b)
+	if !ok || b.MaturityDate.IsZero() {
+		return nil
+	}
+
+	freq := ref.CouponFrequency
+	if freq == 0 {
+		freq = DefaultCouponFrequency
+	}
+
+	// the coupon months are every 12/freq months from the reference maturity month
+	months := int(b.MaturityDate.Month()) - int(ref.MaturityDate.Month())
+	if b.MaturityDate.Day() != ref.MaturityDate.Day() || months%(12/freq) != 0 {
+		return fmt.Errorf(
+			"%w: %s is not a coupon date of %s maturing %s",
+			ErrInvalidMaturityDate,
+			b.MaturityDate.Format("2006-01-02"),
+			ref.ISIN,
+			ref.MaturityDate.Format("2006-01-02"),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package types
+
+import "math"
+
+// YieldPricePoint is a point on the price/yield curve of a bond.
+type YieldPricePoint struct {
+	Yield      float64 `json:"yield"`
+	CleanPrice float64 `json:"cleanPrice"`
+}
+
+// PriceYieldGrid calculates the clean price of a bond across a range of yields, e.g. to chart
+// the price/yield curve. The bond must be completed so the coupon schedule and accrued interest
+// are known, the prices are calculated the same as CompleteBond including an irregular first
+// coupon. The price decreases as the yield increases.
+//
+// Parameters:
+//
+//	b:        The bond.
+//	minYield: The first yield (as a percentage).
+//	maxYield: The last yield (as a percentage), included if it falls on a step.
+//	step:     The change in yield between points (as a percentage).
+//
+// Returns:
+//
+//	The points in increasing order of yield, nil if the range or step is invalid.
+func PriceYieldGrid(b *Bond, minYield, maxYield, step float64) []YieldPricePoint {
+	if b == nil || step <= 0 || maxYield < minYield || b.CouponPeriodDays <= 0 {
+		return nil
+	}
+
+	// the yields are calculated from the index rather than summing the steps so the
+	// rounding errors don't accumulate and drop the last point
+	count := int(math.Floor((maxYield-minYield)/step+1e-9)) + 1
+
+	points := make([]YieldPricePoint, count)
+	for i := range points {
+		y := minYield + float64(i)*step
+		points[i] = YieldPricePoint{
+			Yield:      y,
+			CleanPrice: bondDirtyPrice(b, y) - b.AccruedAmount,
+		}
+	}
+
+	return points
+}
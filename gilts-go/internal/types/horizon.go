@@ -0,0 +1,128 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidHorizon = fmt.Errorf("invalid horizon date")
+)
+
+// HorizonReturn calculates the annualised total return of holding a bond to a horizon date
+// with the coupons reinvested at a fixed rate. The bond is assumed to be sold at the horizon
+// at its current yield to maturity, so the price is pulled towards par as the bond approaches
+// maturity. See HorizonReturnAtYield to sell at a different (forward) yield.
+//
+// Holding to maturity with the coupons reinvested at the yield to maturity returns the
+// yield to maturity.
+//
+// Parameters:
+//
+//	b:            The bond, completed with CompleteBond.
+//	horizon:      The horizon date, after the settlement date. A horizon after the maturity
+//	              date is treated as the maturity date.
+//	reinvestRate: Annual rate the coupons are reinvested at (as a percentage).
+//
+// Returns:
+//
+//	Annualised total return as a percentage, semi-annually compounded to be comparable
+//	with the yield to maturity.
+func HorizonReturn(b *Bond, horizon time.Time, reinvestRate float64) (float64, error) {
+	if b == nil {
+		return 0, ErrNilBond
+	}
+
+	return HorizonReturnAtYield(b, horizon, reinvestRate, b.YieldToMaturity)
+}
+
+// HorizonReturnAtYield calculates the annualised total return in the same way as HorizonReturn
+// but the bond is sold at the horizon at the given yield to maturity.
+//
+// Parameters:
+//
+//	b:            The bond, completed with CompleteBond.
+//	horizon:      The horizon date, after the settlement date.
+//	reinvestRate: Annual rate the coupons are reinvested at (as a percentage).
+//	horizonYield: Yield to maturity the bond is sold at on the horizon date (as a percentage).
+//
+// Returns:
+//
+//	Annualised total return as a percentage.
+func HorizonReturnAtYield(b *Bond, horizon time.Time, reinvestRate, horizonYield float64) (float64, error) {
+	if b == nil {
+		return 0, ErrNilBond
+	}
+
+	if !horizon.After(b.SettlementDate) {
+		return 0, ErrInvalidHorizon
+	}
+
+	if b.DirtyPrice <= 0 || b.CouponPeriodDays <= 0 {
+		return 0, ErrInvalidDirtyPrice
+	}
+
+	atMaturity := !horizon.Before(b.MaturityDate)
+
+	// the time of the cash flows and the horizon in coupon periods from the settlement date,
+	// as used to discount the cash flows when pricing
+	r := float64(b.RemainingDays) / float64(b.CouponPeriodDays)
+	freq := b.Frequency()
+	months := 12 / freq
+	cp := b.Coupon / 100 / float64(freq) * b.FacePrice
+	rr := reinvestRate / 100 / float64(freq)
+
+	h := r + float64(b.CouponPeriods-1)
+	paid := b.CouponPeriods
+
+	if !atMaturity {
+		// the coupons paid on or before the horizon date
+		paid = 0
+		for paid < b.CouponPeriods && !AddMonthsClamped(b.NextCouponDate, months*paid, b.MaturityDate.Day()).After(horizon) {
+			paid++
+		}
+
+		prev := b.PrevCouponDate
+		if paid > 0 {
+			prev = AddMonthsClamped(b.NextCouponDate, months*(paid-1), b.MaturityDate.Day())
+		}
+		next := AddMonthsClamped(b.NextCouponDate, months*paid, b.MaturityDate.Day())
+
+		start := r - 1
+		if paid > 0 {
+			start = r + float64(paid-1)
+		}
+
+		h = start + horizon.Sub(prev).Hours()/next.Sub(prev).Hours()
+	}
+
+	// the coupons reinvested to the horizon
+	value := 0.0
+	for j := 1; j <= paid; j++ {
+		t := r + float64(j-1)
+		value += cp * math.Pow(1+rr, h-t)
+	}
+
+	if atMaturity {
+		value += b.FacePrice
+	} else {
+		// the bond is sold at the horizon for its dirty price, the accrued interest
+		// since the last coupon is part of the return
+		c := *b
+		c.SettlementDate = horizon
+		c.PrevCouponDate = time.Time{}
+		c.NextCouponDate = time.Time{}
+		c.CleanPrice = 0
+		c.DirtyPrice = 0
+		c.YieldToMaturity = horizonYield
+
+		if err := CompleteBond(&c); err != nil {
+			return 0, err
+		}
+
+		value += c.DirtyPrice
+	}
+
+	return (math.Pow(value/b.DirtyPrice, 1/h) - 1) * float64(freq) * 100, nil
+}
@@ -0,0 +1,37 @@
+package types
+
+import "math"
+
+// SimpleYield calculates the money-market simple yield of a bond with at most one remaining
+// cash flow, i.e. the final coupon and redemption. This is how very short gilts are often quoted
+// as the full discounted cash flow yield to maturity is unnecessary.
+//
+// The bond must have been completed with CompleteBond.
+//
+// Returns:
+//
+//	Simple yield as a percentage, annualised using actual days to maturity over 365.
+func SimpleYield(b *Bond) (float64, error) {
+	if b == nil {
+		return 0, ErrNilBond
+	}
+
+	if b.CouponPeriods > 1 {
+		return 0, ErrMultipleCashFlowsRemaining
+	}
+
+	days := math.Floor(b.MaturityDate.Sub(b.SettlementDate).Hours() / 24)
+	if days <= 0 {
+		return 0, ErrMaturityDateBeforeSettlement
+	}
+
+	if b.DirtyPrice <= 0 {
+		return 0, ErrInvalidDirtyPrice
+	}
+
+	redemption := b.FacePrice + b.Coupon/float64(b.Frequency())/100*b.FacePrice
+
+	y := (redemption - b.DirtyPrice) / b.DirtyPrice * 365 / days
+
+	return y * 100, nil
+}
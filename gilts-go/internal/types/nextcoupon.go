@@ -0,0 +1,23 @@
+package types
+
+// NextCouponAmount calculates the amount of the next coupon payment. A regular coupon is the
+// annual coupon divided by the coupon frequency, e.g. 2.125 per 100 face value for a 4¼% gilt.
+// An irregular first coupon of a new issue is paid for the actual days from the issue date to
+// the first coupon date so a long first period pays more and a short first period less.
+//
+// Parameters:
+//
+//	b: The bond, the coupon dates are required for an irregular first coupon.
+//
+// Returns:
+//
+//	The amount of the next coupon, zero for a strip.
+func NextCouponAmount(b *Bond) float64 {
+	freq := b.Frequency()
+	regular := b.Coupon / 100 / float64(freq) * b.FacePrice
+
+	// the first coupon accrues at the regular rate over the actual days of the first period
+	_, extra := regularPeriod(b)
+
+	return regular * (1 + extra)
+}
@@ -0,0 +1,62 @@
+package types
+
+// Holding is a nominal amount of a bond held in a portfolio.
+type Holding struct {
+	Bond    *Bond
+	Nominal float64
+}
+
+// MarketValue returns the dirty value of the holding, i.e. the cash needed to
+// buy the nominal amount at the bond's dirty price.
+func (h *Holding) MarketValue() float64 {
+	return h.Nominal / h.Bond.FacePrice * h.Bond.DirtyPrice
+}
+
+// Portfolio is a collection of bond holdings.
+// The bonds are expected to have been completed with CompleteBond.
+type Portfolio struct {
+	Holdings []Holding
+}
+
+// AddHolding adds a nominal amount of a bond to the portfolio.
+func (p *Portfolio) AddHolding(b *Bond, nominal float64) {
+	p.Holdings = append(p.Holdings, Holding{Bond: b, Nominal: nominal})
+}
+
+// MarketValue returns the aggregate dirty value of the portfolio.
+func (p *Portfolio) MarketValue() float64 {
+	value := 0.0
+	for i := range p.Holdings {
+		value += p.Holdings[i].MarketValue()
+	}
+	return value
+}
+
+// ScenarioPnL calculates the change in market value of the portfolio for a parallel
+// shift in yields. Each holding is fully repriced at its yield to maturity plus the
+// shift rather than approximated using duration, so the result remains accurate
+// for large shifts. The holdings are priced the same as CompleteBond so a zero shift
+// has no P&L.
+//
+// Parameters:
+//
+//	shiftBp: The yield shift in basis points, e.g. 100 for +1%.
+//
+// Returns:
+//
+//	The change in market value.
+func (p *Portfolio) ScenarioPnL(shiftBp float64) float64 {
+	pnl := 0.0
+
+	for i := range p.Holdings {
+		h := &p.Holdings[i]
+		b := h.Bond
+
+		base := bondDirtyPrice(b, b.YieldToMaturity)
+		shifted := bondDirtyPrice(b, b.YieldToMaturity+shiftBp/100)
+
+		pnl += h.Nominal / b.FacePrice * (shifted - base)
+	}
+
+	return pnl
+}
@@ -0,0 +1,72 @@
+package types
+
+import "time"
+
+// PricePoint is the price of a bond for a settlement date.
+type PricePoint struct {
+	Date          time.Time
+	CleanPrice    float64
+	DirtyPrice    float64
+	AccruedAmount float64
+}
+
+// PricePath calculates the price of a bond for each day from one settlement date to another
+// at a fixed yield to maturity. The dirty price follows the sawtooth pattern of accrued interest,
+// rising through each coupon period and dropping back when a coupon date is crossed.
+//
+// If the bond has no yield to maturity it is first calculated from the clean price.
+// The path ends on the day before maturity if the range extends past the maturity date.
+//
+// Parameters:
+//
+//	b:    The bond.
+//	from: The first settlement date.
+//	to:   The last settlement date (inclusive).
+//
+// Returns:
+//
+//	The price for each settlement date.
+func PricePath(b *Bond, from, to time.Time) ([]PricePoint, error) {
+	if b == nil {
+		return nil, ErrNilBond
+	}
+
+	if from.IsZero() || to.Before(from) {
+		return nil, ErrInvalidSettlementDate
+	}
+
+	yield := b.YieldToMaturity
+	if yield == 0 {
+		c := *b
+		if err := CompleteBond(&c); err != nil {
+			return nil, err
+		}
+		yield = c.YieldToMaturity
+	}
+
+	points := []PricePoint{}
+
+	for d := from; !d.After(to) && d.Before(b.MaturityDate); d = d.AddDate(0, 0, 1) {
+		// reset the fields derived from the settlement date so they are recalculated
+		c := *b
+		c.SettlementDate = d
+		c.PrevCouponDate = time.Time{}
+		c.NextCouponDate = time.Time{}
+		c.CleanPrice = 0
+		c.DirtyPrice = 0
+		c.YieldToMaturity = yield
+
+		if err := CompleteBond(&c); err != nil {
+			return nil, err
+		}
+
+		points = append(points, PricePoint{
+			Date:          d,
+			CleanPrice:    c.CleanPrice,
+			DirtyPrice:    c.DirtyPrice,
+			AccruedAmount: c.AccruedAmount,
+		})
+	}
+
+	return points, nil
+}
@@ -0,0 +1,103 @@
+package types
+
+import "time"
+
+// Revalue prices a bond as of a valuation date at a yield, e.g. to mark a holding to market.
+//
+// The dates of a bond are related as:
+//
+//	trade date:      the date the bond is bought or sold, not held on Bond.
+//	settlement date: the date the cash is exchanged, the accrued interest is calculated to
+//	                 this date. Gilts normally settle the next business day (T+1).
+//	valuation date:  the date the bond is priced as of. Revaluing treats it as the settlement
+//	                 date of a hypothetical trade so the accrued interest and prices are as of it.
+//
+// The original bond is unchanged, the revalued copy keeps the original settlement date in
+// SettlementDate and has ValuationDate set, the other date derived fields (coupon dates,
+// remaining and accrued days) are as of the valuation date. Revaluing on the settlement date
+// at the bond's yield gives the same prices as CompleteBond, within the solver tolerance if
+// the yield was solved from the price.
+//
+// Parameters:
+//
+//	b:     The bond.
+//	asOf:  The valuation date.
+//	yield: The yield to maturity (as a percentage), zero to use the bond's yield.
+//
+// Returns:
+//
+//	A copy of the bond priced as of the valuation date.
+func Revalue(b *Bond, asOf time.Time, yield float64) (*Bond, error) {
+	if b == nil {
+		return nil, ErrNilBond
+	}
+
+	if asOf.IsZero() {
+		return nil, ErrInvalidSettlementDate
+	}
+
+	if yield == 0 {
+		yield = b.YieldToMaturity
+	}
+
+	// reset the fields derived from the settlement date so they are recalculated
+	c := *b
+	c.SettlementDate = asOf
+	c.PrevCouponDate = time.Time{}
+	c.NextCouponDate = time.Time{}
+	c.CleanPrice = 0
+	c.DirtyPrice = 0
+	c.YieldToMaturity = yield
+
+	if err := CompleteBond(&c); err != nil {
+		return nil, err
+	}
+
+	c.SettlementDate = b.SettlementDate
+	c.ValuationDate = asOf
+
+	return &c, nil
+}
+
+// ForwardYield calculates the yield to maturity implied by a forward clean price, the price
+// agreed today for settlement on a later date. The coupon periods are rebuilt as of the forward
+// settlement date so a coupon paid between the bond's settlement date and the forward date is
+// excluded, it is paid to the holder before the forward settlement.
+//
+// Parameters:
+//
+//	b:                 The bond.
+//	forwardSettlement: The forward settlement date, on or after the bond's settlement date.
+//	forwardCleanPrice: The forward clean price.
+//
+// Returns:
+//
+//	The forward yield to maturity (as a percentage).
+func ForwardYield(b *Bond, forwardSettlement time.Time, forwardCleanPrice float64) (float64, error) {
+	if b == nil {
+		return 0, ErrNilBond
+	}
+
+	if forwardSettlement.IsZero() || forwardSettlement.Before(b.SettlementDate) {
+		return 0, ErrInvalidSettlementDate
+	}
+
+	if forwardCleanPrice <= 0 {
+		return 0, ErrInvalidCleanPrice
+	}
+
+	// reset the fields derived from the settlement date so they are recalculated
+	c := *b
+	c.SettlementDate = forwardSettlement
+	c.PrevCouponDate = time.Time{}
+	c.NextCouponDate = time.Time{}
+	c.CleanPrice = forwardCleanPrice
+	c.DirtyPrice = 0
+	c.YieldToMaturity = 0
+
+	if err := CompleteBond(&c); err != nil {
+		return 0, err
+	}
+
+	return c.YieldToMaturity, nil
+}
@@ -0,0 +1,40 @@
+package types
+
+// riskBump is the change in yield (as a fraction) for the convexity finite difference, 1bp.
+const riskBump = 0.0001
+
+// riskMeasures calculates the interest rate risk of a bond from its yield to maturity, the
+// sensitivity of the dirty price to a change in the yield.
+//
+// Parameters:
+//
+//	C:     Annual coupon rate.
+//	F:     Face value of the bond.
+//	y:     Yield to maturity as a percentage.
+//	n:     The number of coupon payments per year.
+//	m:     The number of coupon payouts remaining to maturity.
+//	tn:    The number of days from the settlement date to the next coupon payment.
+//	tb:    The number of days in a regular coupon period.
+//	extra: The extra fraction of a regular coupon paid by an irregular next coupon.
+//
+// Returns:
+//
+//	The modified duration in years, the convexity and the DV01 (the fall in the dirty price
+//	for a 1bp rise in the yield), all zero if the price isn't positive.
+func riskMeasures(C, F, y float64, n, m, tn, tb int, extra float64) (float64, float64, float64) {
+	y = y / 100
+
+	price, derivative := irregularDirtyPriceAndDerivative(C, F, y, n, m, tn, tb, extra)
+	if price <= 0 || !isFinite(price) || !isFinite(derivative) {
+		return 0, 0, 0
+	}
+
+	up, _ := irregularDirtyPriceAndDerivative(C, F, y+riskBump, n, m, tn, tb, extra)
+	down, _ := irregularDirtyPriceAndDerivative(C, F, y-riskBump, n, m, tn, tb, extra)
+
+	duration := -derivative / price
+	convexity := (up + down - 2*price) / (price * riskBump * riskBump)
+	dv01 := -derivative * riskBump
+
+	return duration, convexity, dv01
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrSelfTestFailed = fmt.Errorf("self-test failed")
+)
+
+const (
+	// selfTestYield is the expected yield to maturity for the self-test gilt. It is the yield
+	// of the DMO price/yield formula with two coupons remaining, 46 days to the next coupon of
+	// a 182 day period and 136 days accrued, solved by bisection rather than this engine:
+	//
+	//	100.807692 = 99.50 + 1.75 × 136/182 = (1.75 + 101.75v) × v^(46/182), v = 1/(1 + y/2)
+	selfTestYield = 4.311873
+	// selfTestTolerance is the maximum deviation from the expected yield to maturity, 0.5bp. The
+	// solver stops within 0.001 of the price, about 0.15bp of yield for this gilt, so a tighter
+	// tolerance would fail on solver noise rather than a pricing error.
+	selfTestTolerance = 0.005
+)
+
+// SelfTest checks the pricing engine by pricing a known gilt, the 3½% Treasury Gilt 2025
+// settling on 7 March 2025 at a clean price of 99.50. The yield to maturity is solved from
+// the clean price and compared with the expected value, then the clean price is recalculated
+// from that yield and compared with the original price.
+//
+// Returns:
+//
+//	An error wrapping ErrSelfTestFailed if the engine deviates from the expected values.
+func SelfTest() error {
+	settlementDate := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	maturityDate := time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC)
+	cleanPrice := 99.50
+
+	b := NewUKGilt("self-test", settlementDate)
+	b.Desc = "3½% Treasury Gilt 2025"
+	b.Coupon = 3.5
+	b.MaturityDate = maturityDate
+	b.CleanPrice = cleanPrice
+
+	if err := CompleteBond(b); err != nil {
+		return fmt.Errorf("%w: %w", ErrSelfTestFailed, err)
+	}
+
+	if math.Abs(b.YieldToMaturity-selfTestYield) > selfTestTolerance {
+		return fmt.Errorf(
+			"%w: yield to maturity %.6f%%, expected %.6f%%",
+			ErrSelfTestFailed,
+			b.YieldToMaturity,
+			selfTestYield,
+		)
+	}
+
+	p := NewUKGilt("self-test", settlementDate)
+	p.Coupon = b.Coupon
+	p.MaturityDate = maturityDate
+	p.YieldToMaturity = b.YieldToMaturity
+
+	if err := CompleteBond(p); err != nil {
+		return fmt.Errorf("%w: %w", ErrSelfTestFailed, err)
+	}
+
+	if math.Abs(p.CleanPrice-cleanPrice) > 0.001 {
+		return fmt.Errorf(
+			"%w: clean price %.6f, expected %.6f",
+			ErrSelfTestFailed,
+			p.CleanPrice,
+			cleanPrice,
+		)
+	}
+
+	return nil
+}
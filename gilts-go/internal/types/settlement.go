@@ -0,0 +1,51 @@
+package types
+
+// Settlement is the cash needed to buy a nominal amount of a bond, split into the clean
+// consideration and the accrued interest paid to the seller.
+type Settlement struct {
+	Nominal            float64
+	CleanConsideration float64
+	AccruedInterest    float64
+	Total              float64
+}
+
+// SettlementCost calculates the total cash needed to buy a nominal amount of a bond,
+// i.e. the nominal at the dirty price including the accrued interest.
+//
+// Parameters:
+//
+//	b:       The bond, completed with CompleteBond.
+//	nominal: The nominal amount, e.g. 10000 for £10,000 nominal.
+//
+// Returns:
+//
+//	The total settlement cost.
+func SettlementCost(b *Bond, nominal float64) float64 {
+	return SettlementBreakdown(b, nominal).Total
+}
+
+// SettlementBreakdown calculates the cash needed to buy a nominal amount of a bond split into
+// the clean consideration and the accrued interest. The prices are per FacePrice nominal,
+// i.e. per £100 for gilts.
+//
+// Parameters:
+//
+//	b:       The bond, completed with CompleteBond.
+//	nominal: The nominal amount.
+//
+// Returns:
+//
+//	The settlement cost and its components.
+func SettlementBreakdown(b *Bond, nominal float64) Settlement {
+	units := nominal / b.FacePrice
+
+	clean := units * b.CleanPrice
+	accrued := units * b.AccruedAmount
+
+	return Settlement{
+		Nominal:            nominal,
+		CleanConsideration: clean,
+		AccruedInterest:    accrued,
+		Total:              clean + accrued,
+	}
+}
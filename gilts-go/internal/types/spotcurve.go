@@ -0,0 +1,277 @@
+package types
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+var (
+	ErrInvalidSpotCurve       = fmt.Errorf("invalid spot curve")
+	ErrParCouponNotBracketed  = fmt.Errorf("par coupon is not between the bisection bounds")
+	ErrParCouponNoConvergence = fmt.Errorf("bisection failed to converge within max iterations")
+	ErrNoBootstrapBonds       = fmt.Errorf("no bonds to bootstrap the spot curve from")
+)
+
+const (
+	// spotCurveFrequency is the number of times per year the spot rates are compounded.
+	spotCurveFrequency = 2
+	// parCouponMin and parCouponMax are the bisection bounds for the par coupon (as a percentage).
+	parCouponMin = 0.0
+	parCouponMax = 20.0
+	// parCouponTolerance is the width of the bisection interval for convergence.
+	parCouponTolerance = 1e-10
+	// parCouponMaxIterations is the maximum number of bisection iterations.
+	parCouponMaxIterations = 200
+	// spotRateMin and spotRateMax are the bisection bounds for a bootstrapped spot rate (as a percentage).
+	spotRateMin = -5.0
+	spotRateMax = 50.0
+	// spotRateTolerance is the width of the bisection interval for convergence.
+	spotRateTolerance = 1e-10
+)
+
+// SpotCurve is a zero-coupon (spot) yield curve, e.g. bootstrapped from gilt prices.
+// The rates are semi-annually compounded (bond-equivalent) to be comparable with the
+// gilt yields to maturity. Rates between the points are linearly interpolated and rates
+// outside the points are flat extrapolated.
+type SpotCurve struct {
+	// Maturities are the times of the points in years, in ascending order.
+	Maturities []float64
+	// Rates are the spot rates of the points (as a percentage).
+	Rates []float64
+}
+
+// NewSpotCurve creates a spot curve from the maturities (in years, ascending) and spot rates
+// (as a percentage) of its points.
+func NewSpotCurve(maturities []float64, rates []float64) (*SpotCurve, error) {
+	if len(maturities) == 0 || len(maturities) != len(rates) {
+		return nil, ErrInvalidSpotCurve
+	}
+
+	for i := 1; i < len(maturities); i++ {
+		if maturities[i] <= maturities[i-1] {
+			return nil, fmt.Errorf("%w: maturities must be ascending", ErrInvalidSpotCurve)
+		}
+	}
+
+	return &SpotCurve{Maturities: maturities, Rates: rates}, nil
+}
+
+// FlatSpotCurve creates a spot curve with the same rate (as a percentage) for all maturities.
+func FlatSpotCurve(rate float64) *SpotCurve {
+	return &SpotCurve{Maturities: []float64{0}, Rates: []float64{rate}}
+}
+
+// Rate returns the spot rate (as a percentage) for a time in years.
+func (c *SpotCurve) Rate(t float64) float64 {
+	n := len(c.Maturities)
+
+	if t <= c.Maturities[0] {
+		return c.Rates[0]
+	}
+
+	if t >= c.Maturities[n-1] {
+		return c.Rates[n-1]
+	}
+
+	i := 1
+	for c.Maturities[i] < t {
+		i++
+	}
+
+	t0, t1 := c.Maturities[i-1], c.Maturities[i]
+	r0, r1 := c.Rates[i-1], c.Rates[i]
+
+	return r0 + (r1-r0)*(t-t0)/(t1-t0)
+}
+
+// DiscountFactor returns the present value of 1 paid at a time in years, the spot rate is
+// compounded semi-annually whatever the coupon frequency of the bond being priced:
+//
+//	(1 + r/200)^(-2t)
+func (c *SpotCurve) DiscountFactor(t float64) float64 {
+	return math.Pow(1+c.Rate(t)/(100*spotCurveFrequency), -spotCurveFrequency*t)
+}
+
+// curveCleanPrice calculates the clean price per 100 face value of a bond priced off the curve.
+// The coupons are paid at regular intervals back from maturity so the first period may be partial.
+func curveCleanPrice(curve *SpotCurve, C, maturity float64, freq int) float64 {
+	n := float64(freq)
+	periods := int(math.Ceil(maturity*n - 1e-9))
+	cp := C / n
+
+	price := 100 * curve.DiscountFactor(maturity)
+
+	for i := 1; i <= periods; i++ {
+		price += cp * curve.DiscountFactor(maturity-float64(periods-i)/n)
+	}
+
+	// the accrued interest for the elapsed part of the first coupon period
+	first := maturity - float64(periods-1)/n
+	accrued := cp * (1 - first*n)
+
+	return price - accrued
+}
+
+// ParCoupon calculates the coupon which prices a bond at par off the spot curve using the
+// bisection method. The price is monotone in the coupon so bisection is robust, unlike the
+// Newton-Raphson method used to solve the yield to maturity.
+//
+// Parameters:
+//
+//	curve:    The spot curve.
+//	maturity: The time to maturity in years.
+//	freq:     The number of coupon payments per year.
+//
+// Returns:
+//
+//	The par coupon as a percentage.
+func ParCoupon(curve *SpotCurve, maturity float64, freq int) (float64, error) {
+	if curve == nil || len(curve.Maturities) == 0 || len(curve.Maturities) != len(curve.Rates) {
+		return 0, ErrInvalidSpotCurve
+	}
+
+	if maturity <= 0 {
+		return 0, ErrInvalidMaturityDate
+	}
+
+	if err := validateFrequency(freq); err != nil {
+		return 0, err
+	}
+
+	lo, hi := parCouponMin, parCouponMax
+
+	flo := curveCleanPrice(curve, lo, maturity, freq) - 100
+	fhi := curveCleanPrice(curve, hi, maturity, freq) - 100
+
+	if flo > 0 || fhi < 0 {
+		return 0, ErrParCouponNotBracketed
+	}
+
+	for range parCouponMaxIterations {
+		mid := (lo + hi) / 2
+
+		if hi-lo < parCouponTolerance {
+			return mid, nil
+		}
+
+		if curveCleanPrice(curve, mid, maturity, freq)-100 < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return 0, ErrParCouponNoConvergence
+}
+
+// cashFlowTimes returns the times in years of the remaining cash flows of a completed bond, the
+// j-th coupon is j-1 regular periods after the next coupon date the same as the price/yield formula.
+func cashFlowTimes(b *Bond) []float64 {
+	periodDays, _ := regularPeriod(b)
+	n := float64(b.Frequency())
+	r := float64(b.RemainingDays) / float64(periodDays)
+
+	times := make([]float64, b.CouponPeriods)
+	for j := range times {
+		times[j] = (r + float64(j)) / n
+	}
+
+	return times
+}
+
+// curveDirtyPrice calculates the dirty price of a completed bond priced off the curve, the next
+// coupon is the bond's next coupon amount so an irregular first coupon is included.
+func curveDirtyPrice(curve *SpotCurve, b *Bond, times []float64) float64 {
+	regular := b.Coupon / 100 / float64(b.Frequency()) * b.FacePrice
+
+	price := b.FacePrice * curve.DiscountFactor(times[len(times)-1])
+
+	for j, t := range times {
+		cp := regular
+		if j == 0 {
+			cp = b.NextCouponAmount
+		}
+		price += cp * curve.DiscountFactor(t)
+	}
+
+	return price
+}
+
+// BootstrapSpotCurve bootstraps a spot curve from the dirty prices of completed bonds. The bonds
+// are taken in order of maturity, the spot rate at each maturity is solved by bisection so the
+// bond's cash flows discounted off the curve so far and the new point equal its dirty price.
+// Bonds which mature at the same time as an earlier bond, have no price or whose spot rate
+// can't be bracketed, e.g. a bad price, are skipped.
+//
+// Parameters:
+//
+//	bonds: The completed bonds, e.g. the conventional gilts and strips collected on a day.
+//
+// Returns:
+//
+//	The spot curve with a point at the maturity of each bond used.
+func BootstrapSpotCurve(bonds []*Bond) (*SpotCurve, error) {
+	type candidate struct {
+		bond  *Bond
+		times []float64
+	}
+
+	candidates := make([]candidate, 0, len(bonds))
+	for _, b := range bonds {
+		if b == nil || b.DirtyPrice <= 0 || b.CouponPeriods < 1 || b.CouponPeriodDays < 1 {
+			continue
+		}
+		candidates = append(candidates, candidate{bond: b, times: cashFlowTimes(b)})
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(a.times[len(a.times)-1], b.times[len(b.times)-1])
+	})
+
+	curve := &SpotCurve{}
+
+	for _, c := range candidates {
+		maturity := c.times[len(c.times)-1]
+		if n := len(curve.Maturities); n > 0 && maturity <= curve.Maturities[n-1] {
+			continue
+		}
+
+		// the trial curve is the curve so far plus the point being solved
+		trial := &SpotCurve{
+			Maturities: append(slices.Clip(curve.Maturities), maturity),
+			Rates:      append(slices.Clip(curve.Rates), 0),
+		}
+		last := len(trial.Rates) - 1
+
+		priceDiff := func(rate float64) float64 {
+			trial.Rates[last] = rate
+			return curveDirtyPrice(trial, c.bond, c.times) - c.bond.DirtyPrice
+		}
+
+		// the price falls as the spot rate rises
+		lo, hi := spotRateMin, spotRateMax
+		if priceDiff(lo) < 0 || priceDiff(hi) > 0 {
+			continue
+		}
+
+		for hi-lo > spotRateTolerance {
+			mid := (lo + hi) / 2
+			if priceDiff(mid) > 0 {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+
+		curve.Maturities = append(curve.Maturities, maturity)
+		curve.Rates = append(curve.Rates, (lo+hi)/2)
+	}
+
+	if len(curve.Maturities) == 0 {
+		return nil, ErrNoBootstrapBonds
+	}
+
+	return curve, nil
+}
@@ -3,48 +3,145 @@ package types
 import (
 	"fmt"
 	"math"
+	"strings"
 	"time"
 )
 
 type BondType string
 
 var (
-	UKGilt BondType = "UK Gilt"
+	UKGilt            BondType = "UK Gilt"
+	UKIndexLinkedGilt BondType = "UK Index-linked Gilt"
 )
 
 type Bond struct {
-	Type             BondType
-	Source           string
-	ISIN             string
-	Ticker           string
-	Desc             string
-	FacePrice        float64
-	Coupon           float64
-	SettlementDate   time.Time
-	PrevCouponDate   time.Time
-	NextCouponDate   time.Time
-	RemainingDays    int
-	AccruedDays      int
-	CouponPeriodDays int
-	CouponPeriods    int
-	MaturityDate     time.Time
-	MaturityYears    int
-	MaturityDays     int
-	CleanPrice       float64
-	DirtyPrice       float64
-	YieldToMaturity  float64
-	AccruedAmount    float64
+	Type                      BondType
+	Source                    string
+	ISIN                      string
+	Ticker                    string
+	Desc                      string
+	AmountInIssue             float64
+	FacePrice                 float64
+	Coupon                    float64
+	CouponFrequency           int
+	Strip                     bool
+	DayCountConvention        DayCount
+	AccrualRule               AccrualRule
+	SettlementDate            time.Time
+	ValuationDate             time.Time
+	PrevCouponDate            time.Time
+	NextCouponDate            time.Time
+	IssueDate                 time.Time
+	FirstCouponDate           time.Time
+	RemainingDays             int
+	AccruedDays               int
+	CouponPeriodDays          int
+	CouponPeriods             int
+	MaturityDate              time.Time
+	MaturityYears             int
+	MaturityDays              int
+	AverageLife               float64
+	NextCouponAmount          float64
+	CleanPrice                float64
+	DirtyPrice                float64
+	YieldToMaturity           float64
+	YieldToMaturityContinuous float64
+	SourceYield               float64
+	ComputedVsSourceYield     float64
+	AccruedAmount             float64
+	Duration                  float64
+	Convexity                 float64
+	DV01                      float64
+	SolverIterations          int
 }
 
-func NewUKGilt(source string, settlementDate time.Time) *Bond {
+const (
+	// MaxCoupon is the maximum coupon rate (as a percentage) of a bond, a larger coupon is
+	// rejected by CompleteBond as most likely a scaling error, e.g. basis points.
+	MaxCoupon = 25.0
+	// MinCoupon is the minimum coupon rate (as a percentage) of a bond other than a strip, a
+	// smaller coupon is rejected by CompleteBond as most likely a fraction rather than a
+	// percentage, e.g. 0.035 for 3½%. The smallest gilt coupon is 0⅛%.
+	MinCoupon = 0.1
+)
+
+// DefaultFacePrice is the face value of a gilt, gilts are priced per £100 nominal.
+const DefaultFacePrice = 100.0
+
+// NewBond creates a bond of any type. The face value isn't validated here, CompleteBond
+// returns ErrInvalidFacePrice if it isn't positive.
+//
+// Parameters:
+//
+//	bondType:       The type of the bond.
+//	face:           The face value the prices are quoted per.
+//	source:         The source of the bond data.
+//	settlementDate: The settlement date.
+//
+// Returns:
+//
+//	The bond.
+func NewBond(bondType BondType, face float64, source string, settlementDate time.Time) *Bond {
 	return &Bond{
-		Type:           UKGilt,
-		FacePrice:      100.0,
+		Type:           bondType,
+		FacePrice:      face,
 		Source:         source,
 		SettlementDate: settlementDate,
 	}
 }
 
+// NewUKGilt creates a UK gilt priced per £100 nominal.
+func NewUKGilt(source string, settlementDate time.Time) *Bond {
+	return NewBond(UKGilt, DefaultFacePrice, source, settlementDate)
+}
+
+// DefaultCouponFrequency is the number of coupon payments per year when a bond has no
+// coupon frequency, conventional gilts pay semi-annually.
+const DefaultCouponFrequency = 2
+
+// Frequency returns the number of coupon payments per year of the bond.
+func (b *Bond) Frequency() int {
+	if b.CouponFrequency == 0 {
+		return DefaultCouponFrequency
+	}
+	return b.CouponFrequency
+}
+
+// PeriodProgress returns the progress of the bond through the current coupon period, e.g. for
+// display. The bond must be completed.
+//
+// Returns:
+//
+//	The days to the next coupon date and the fraction of the coupon period elapsed (0 to 1),
+//	zero if the bond isn't completed.
+func (b *Bond) PeriodProgress() (int, float64) {
+	if b.CouponPeriodDays <= 0 {
+		return 0, 0
+	}
+
+	// the remaining days are from the settlement date so the elapsed fraction doesn't depend
+	// on the accrual rule, a when-issued bond hasn't started its first period
+	elapsed := float64(b.CouponPeriodDays-b.RemainingDays) / float64(b.CouponPeriodDays)
+
+	return b.RemainingDays, math.Min(math.Max(elapsed, 0), 1)
+}
+
+// Clone returns a deep copy of the bond. The bond's fields are all values, including the
+// dates, so the copy shares nothing with the original.
+func (b *Bond) Clone() *Bond {
+	c := *b
+	return &c
+}
+
+// NewUKGiltWithMaturity creates a UK gilt from only its coupon and maturity date, the coupon
+// dates are inferred from the maturity date when the bond is completed.
+func NewUKGiltWithMaturity(source string, settlementDate time.Time, coupon float64, maturityDate time.Time) *Bond {
+	b := NewUKGilt(source, settlementDate)
+	b.Coupon = coupon
+	b.MaturityDate = maturityDate
+	return b
+}
+
 // MaturityYears calculates the number of years and days from the settlement date to the maturity date.
 // It returns an error if the maturity date is before the settlement date.
 // Parameters:
@@ -72,20 +169,17 @@ func MaturityYears(settlementDate, maturityDate time.Time) (int, int, error) {
 		maturityDate.Location(),
 	)
 
-	start := time.Date(
+	// the settlement day is clamped so 29 February doesn't roll over into March
+	start := DateClamped(
 		maturityDate.Year(),
 		settlementDate.Month(),
 		settlementDate.Day(),
-		0,
-		0,
-		0,
-		0,
 		maturityDate.Location(),
 	)
 
 	if start.After(end) {
 		years--
-		start = start.AddDate(-1, 0, 0)
+		start = DateClamped(start.Year()-1, settlementDate.Month(), settlementDate.Day(), maturityDate.Location())
 	}
 
 	days := int(end.Sub(start).Hours() / 24)
@@ -93,7 +187,38 @@ func MaturityYears(settlementDate, maturityDate time.Time) (int, int, error) {
 	return years, days, nil
 }
 
-// CleanPrice calculates the bond price when cash flows occur at unequal intervals.
+// MaturityYearFraction calculates the years from the settlement date to the maturity date as a
+// fraction. The days after the last full year are divided by the actual days in that year, 366
+// if it spans a 29 February, rather than assuming 365 days.
+//
+// Parameters:
+//
+//	settlementDate: The date when the bond is settled.
+//	maturityDate:   The date when the bond matures.
+//
+// Returns:
+//
+//	The years until maturity.
+func MaturityYearFraction(settlementDate, maturityDate time.Time) (float64, error) {
+	years, days, err := MaturityYears(settlementDate, maturityDate)
+	if err != nil {
+		return 0, err
+	}
+
+	if days == 0 {
+		return float64(years), nil
+	}
+
+	// the part year is from the last anniversary of the settlement date before maturity
+	start := maturityDate.AddDate(0, 0, -days)
+	end := DateClamped(start.Year()+1, settlementDate.Month(), settlementDate.Day(), start.Location())
+
+	return float64(years) + float64(days)/float64(actualDays(start, end)), nil
+}
+
+// CleanPrice calculates the bond price when cash flows occur at unequal intervals, the dirty
+// price less the interest accrued since the last coupon date so it agrees with the prices
+// CompleteBond derives.
 //
 // Parameters:
 //
@@ -107,34 +232,14 @@ func MaturityYears(settlementDate, maturityDate time.Time) (int, int, error) {
 //
 // Returns:
 //
-//	Clean bond price.
+//	Clean bond price, zero if no coupons remain.
 func CleanPrice(C, y, F float64, n, m, tn, tb int) float64 {
-	// Calculate the price of a gilt using the formula:
-	// Price = (Coupon / (1 + Yield)^1) + (Coupon / (1 + Yield)^2) + ... + (Coupon + 100 / (1 + Yield)^Years)
-
-	CP := C / 100 / float64(n) * F
-	ypp := y / 100 / float64(n)
-
-	price := 0.0
-
-	// At maturity, we receive the face value plus possibly a partial coupon
-	mp := F
-
-	// If maturity doesn't coincide with a coupon payment date, add partial coupon
-	r := float64(tn) / float64(tb)
-	if r > 0 {
-		mp += CP * r
-		m--
+	// a bond settling on or after its last coupon date has no cash flows
+	if m < 1 {
+		return 0
 	}
 
-	// Add the present value of the maturity payment
-	price += mp / math.Pow(1+ypp, float64(m)+r)
-
-	for j := int(1); j <= m; j++ {
-		price += CP / math.Pow(1+ypp, float64(j))
-	}
-
-	return price
+	return DirtyPrice(C, y, F, n, m, tn, tb) - AccruedInterest(C, F, tb-tn, tb, n)
 }
 
 // DirtyPrice calculates the bond price when cash flows occur at unequal intervals.
@@ -153,16 +258,14 @@ func CleanPrice(C, y, F float64, n, m, tn, tb int) float64 {
 //
 //	Dirty bond price.
 func DirtyPrice(C, y, F float64, n, m, tn, tb int) float64 {
-	y = y / 100
-
-	sum := 0.0
-	for j := int(1); j <= m; j++ {
-		sum += (C / float64(n)) / math.Pow(1+(y/float64(n)), float64(j-1))
+	// A strip (zero-coupon) is a single discounted redemption payment
+	if C == 0 {
+		return StripPrice(y, F, n, m, tn, tb)
 	}
 
-	r := float64(tn) / float64(tb)
+	price, _ := dirtyPriceAndDerivative(C, F, y/100, n, m, tn, tb)
 
-	return (1 / math.Pow(1+(y/float64(n)), r)) * (sum + F/math.Pow(1+(y/float64(n)), float64(m-1)))
+	return price
 }
 
 // DirtyPriceDerivative calculates the derivative of the bond price function with respect to yield for unequal intervals.
@@ -181,22 +284,83 @@ func DirtyPrice(C, y, F float64, n, m, tn, tb int) float64 {
 //
 //	The derivative of the bond price function.
 func DirtyPriceDerivative(C, F, y float64, n, m, tn, tb int) float64 {
-	derivative := 0.0
-	for j := int(1); j <= m; j++ {
-		derivative += -(float64(j-1) * (C / float64(n)) / math.Pow(1+(y/float64(n)), float64(j)) / float64(n))
+	_, derivative := dirtyPriceAndDerivative(C, F, y, n, m, tn, tb)
+
+	return derivative
+}
+
+// dirtyPriceAndDerivative calculates both the dirty price and its derivative with respect to yield
+// in a single pass over the cash flows so the discount factors are shared. The discount factors
+// are computed incrementally rather than using math.Pow per coupon as this is called repeatedly
+// by the Newton-Raphson method.
+//
+// Parameters:
+//
+//	C:    Annual coupon rate.
+//	F:    Face value of the bond.
+//	y:    Yield to maturity (as a fraction).
+//	n:    The number of coupon payments per year.
+//	m:    The number of coupon payouts remaining to maturity.
+//	tn:   The number of days from the settlement date to the next coupon payment.
+//	tb:   The number of days between the last coupon date and the next coupon date.
+//
+// Returns:
+//
+//	Dirty bond price and the derivative of the bond price function, zero if no coupons remain.
+func dirtyPriceAndDerivative(C, F, y float64, n, m, tn, tb int) (float64, float64) {
+	// a bond settling on or after its last coupon date has no cash flows
+	if m < 1 {
+		return 0, 0
 	}
 
+	cp := C / 100 / float64(n) * F
+	g := 1 + y/float64(n)
+	v := 1 / g
+
+	// the cash flows are discounted to the next coupon date, the j-th coupon over j-1
+	// periods so with a single coupon remaining it and the redemption are undiscounted
 	sum := 0.0
+	dsum := 0.0
+	df := 1.0
 	for j := int(1); j <= m; j++ {
-		sum += (C / float64(n)) / math.Pow(1+(y/float64(n)), float64(j-1))
+		sum += cp * df
+		df *= v
+		dsum += -(float64(j-1) * cp * df / float64(n))
+	}
+
+	redemption := F / math.Pow(g, float64(m-1))
+	dredemption := -(float64(m-1) / float64(n)) * redemption * v
+
+	// then discounted over the fraction of a period to the settlement date
+	r := float64(tn) / float64(tb)
+	vr := 1 / math.Pow(g, r)
+
+	price := vr * (sum + redemption)
+
+	derivative := -r / float64(n) * vr * v * (sum + redemption)
+	derivative += vr * (dsum + dredemption)
+
+	return price, derivative
+}
+
+// irregularDirtyPriceAndDerivative calculates the dirty price and its derivative in the same way
+// as dirtyPriceAndDerivative when the next coupon pays an extra fraction of a regular coupon,
+// i.e. a long (positive) or short (negative) first coupon period of a new issue.
+func irregularDirtyPriceAndDerivative(C, F, y float64, n, m, tn, tb int, extra float64) (float64, float64) {
+	price, derivative := dirtyPriceAndDerivative(C, F, y, n, m, tn, tb)
+
+	if extra == 0 {
+		return price, derivative
 	}
 
+	cp := extra * C / 100 / float64(n) * F
+	g := 1 + y/float64(n)
 	r := float64(tn) / float64(tb)
 
-	derivative += -r / (1 + y/float64(n)) * (F/math.Pow(1+y/float64(n), float64(m-1)) + sum)
-	derivative += (1 / math.Pow(1+y/float64(n), r)) * (-(float64(m-1) / float64(n)) * F / math.Pow(1+y/float64(n), float64(m)) / float64(n))
+	price += cp / math.Pow(g, r)
+	derivative += -r / float64(n) * cp / math.Pow(g, r+1)
 
-	return derivative
+	return price, derivative
 }
 
 // DirtyPriceYieldToMaturity calculates the yield to maturity using the Newton-Raphson numerical method
@@ -219,25 +383,403 @@ func DirtyPriceDerivative(C, F, y float64, n, m, tn, tb int) float64 {
 //
 //	Yield to maturity as a percentage.
 func DirtyPriceYieldToMaturity(C, F, P float64, n, m, tn, tb int, y, t float64, i int) (float64, error) {
+	return DirtyPriceYieldToMaturityWithOptions(C, F, P, n, m, tn, tb, y, SolverOptions{
+		Tolerance:     t,
+		MaxIterations: i,
+	})
+}
+
+// SolverOptions are the options for the Newton-Raphson yield to maturity solver.
+type SolverOptions struct {
+	// Tolerance is the price difference for convergence per 100 face value, it is
+	// scaled by the face value so the precision is the same for any denomination.
+	Tolerance float64
+	// MaxIterations is the maximum number of iterations.
+	MaxIterations int
+	// Damped limits the size of each step to prevent the solver overshooting into
+	// absurd yields, e.g. for deep discount bonds. Each step is clamped to MaxStep and
+	// steps which would cross below MinYield (zero when unbounded) are halved.
+	Damped bool
+	// MaxStep is the maximum change in yield (as a percentage) per iteration when damped.
+	// Zero means no limit.
+	MaxStep float64
+	// Bounded rejects a converged yield outside MinYield and MaxYield with ErrYieldOutOfBounds,
+	// the solver can converge to a valid but absurd yield for a bad price.
+	Bounded bool
+	// MinYield is the minimum yield (as a percentage) when bounded.
+	MinYield float64
+	// MaxYield is the maximum yield (as a percentage) when bounded.
+	MaxYield float64
+	// Seed is how CompleteBond estimates the initial guess of the yield to maturity.
+	Seed SeedStrategy
+	// InitialGuess is the initial guess of the yield to maturity (as a percentage) used by
+	// CompleteBond instead of the seed strategy. Zero means no guess.
+	InitialGuess float64
+	// RoundPrices rounds the prices CompleteBond derives to PriceDecimals decimal places with
+	// round-half-to-even to match the quoted prices, the given prices are never rounded. The
+	// prices are rounded after all the calculations so the precision of the yield is kept.
+	RoundPrices bool
+	// PriceDecimals is the number of decimal places of the rounded prices.
+	PriceDecimals int
+}
+
+// roundHalfEven rounds a value to the number of decimal places, halves are rounded to the
+// nearest even digit.
+func roundHalfEven(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.RoundToEven(value*scale) / scale
+}
+
+// SeedStrategy is how the initial guess of the yield to maturity is estimated. The closer the
+// guess the fewer iterations, the closed-form estimate can be poor for bonds far from par.
+type SeedStrategy int
+
+const (
+	// SeedEstimate is the closed-form approximation of EstimatedYieldToMaturity.
+	SeedEstimate SeedStrategy = iota
+	// SeedCoupon is the coupon rate, the yield of a bond priced at par.
+	SeedCoupon
+	// SeedCurrentYield is the annual coupon divided by the clean price.
+	SeedCurrentYield
+)
+
+func (s SeedStrategy) String() string {
+	switch s {
+	case SeedEstimate:
+		return "estimate"
+	case SeedCoupon:
+		return "coupon"
+	case SeedCurrentYield:
+		return "current"
+	}
+	return "unknown"
+}
+
+// ParseSeedStrategy parses a seed strategy name: estimate, coupon or current.
+func ParseSeedStrategy(s string) (SeedStrategy, error) {
+	switch strings.ToLower(s) {
+	case "", "estimate":
+		return SeedEstimate, nil
+	case "coupon":
+		return SeedCoupon, nil
+	case "current":
+		return SeedCurrentYield, nil
+	}
+	return SeedEstimate, ErrInvalidSeedStrategy
+}
+
+// seedYield returns the initial guess of the yield to maturity (as a percentage) of the bond
+// from the clean price and years to maturity.
+func seedYield(b *Bond, years float64, opts SolverOptions) float64 {
+	if opts.InitialGuess != 0 {
+		return opts.InitialGuess
+	}
+
+	switch opts.Seed {
+	case SeedCoupon:
+		return b.Coupon
+	case SeedCurrentYield:
+		return b.Coupon * b.FacePrice / b.CleanPrice
+	}
+
+	return EstimatedYieldToMaturity(b.Coupon, b.FacePrice, b.CleanPrice, years)
+}
+
+// DefaultSolverOptions returns the solver options used by CompleteBond.
+func DefaultSolverOptions() SolverOptions {
+	return SolverOptions{
+		Tolerance:     0.001,
+		MaxIterations: 1_000,
+		MaxStep:       5,
+		Bounded:       true,
+		MinYield:      -5,
+		MaxYield:      50,
+	}
+}
+
+// DirtyPriceYieldToMaturityWithOptions calculates the yield to maturity using the Newton-Raphson
+// numerical method for bonds with unequal intervals between cash flows.
+//
+// Parameters:
+//
+//	C:		Annual coupon rate.
+//	F:		Face value of the bond.
+//	P:		Dirty price.
+//	n:		The number of coupon payments per year.
+//	m:		The number of coupon payouts remaining to maturity.
+//	tn:		The number of days from the settlement date to the next coupon payment.
+//	tb:		The number of days between the last coupon date and the next coupon date.
+//	y:		Estimated yield to maturity (initial guess).
+//	opts:	Solver options.
+//
+// Returns:
+//
+//	Yield to maturity as a percentage.
+func DirtyPriceYieldToMaturityWithOptions(C, F, P float64, n, m, tn, tb int, y float64, opts SolverOptions) (float64, error) {
+	result, err := DirtyPriceYieldToMaturityDetailed(C, F, P, n, m, tn, tb, y, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Yield, nil
+}
+
+// YieldFromDirty calculates the yield to maturity from a dirty price and the coupon schedule,
+// without a bond or any dates, e.g. when the schedule is already known. The schedule is
+// validated in the same way as CompleteBondWithSchedule.
+//
+// Parameters:
+//
+//	coupon:     Annual coupon rate, zero for a strip.
+//	face:       Face value of the bond.
+//	dirtyPrice: Dirty price.
+//	freq:       The number of coupon payments per year.
+//	periods:    The number of coupon payments remaining to maturity.
+//	daysToNext: The number of days from the settlement date to the next coupon date.
+//	periodDays: The number of days between the previous coupon date and the next coupon date.
+//	opts:       Solver options, the initial guess is estimated if not given.
+//
+// Returns:
+//
+//	Yield to maturity as a percentage.
+func YieldFromDirty(coupon, face, dirtyPrice float64, freq, periods, daysToNext, periodDays int, opts SolverOptions) (float64, error) {
+	if err := validateCoupon(coupon, coupon == 0); err != nil {
+		return 0, err
+	}
+	if face <= 0 {
+		return 0, newBondError(ErrInvalidFacePrice, "FacePrice", face)
+	}
+	if dirtyPrice <= 0 {
+		return 0, newBondError(ErrInvalidDirtyPrice, "DirtyPrice", dirtyPrice)
+	}
+	if err := validateSchedule(freq, periods, daysToNext, periodDays); err != nil {
+		return 0, err
+	}
+
+	y := opts.InitialGuess
+	if y == 0 {
+		years := (float64(periods-1) + float64(daysToNext)/float64(periodDays)) / float64(freq)
+		y = EstimatedYieldToMaturity(coupon, face, dirtyPrice, years)
+	}
+
+	return DirtyPriceYieldToMaturityWithOptions(coupon, face, dirtyPrice, freq, periods, daysToNext, periodDays, y, opts)
+}
+
+// SolveResult is the result of the yield to maturity solver.
+type SolveResult struct {
+	// Yield is the yield to maturity as a percentage.
+	Yield float64
+	// Iterations is the number of iterations used, zero if the yield was solved directly.
+	Iterations int
+	// Residual is the difference between the price at the yield and the target price.
+	Residual float64
+}
+
+// DirtyPriceYieldToMaturityDetailed calculates the yield to maturity in the same way as
+// DirtyPriceYieldToMaturityWithOptions but also reports the number of iterations used and
+// the final price residual. If the solver fails the result holds the last iteration.
+//
+// Parameters:
+//
+//	C:		Annual coupon rate.
+//	F:		Face value of the bond.
+//	P:		Dirty price.
+//	n:		The number of coupon payments per year.
+//	m:		The number of coupon payouts remaining to maturity.
+//	tn:		The number of days from the settlement date to the next coupon payment.
+//	tb:		The number of days between the last coupon date and the next coupon date.
+//	y:		Estimated yield to maturity (initial guess).
+//	opts:	Solver options.
+//
+// Returns:
+//
+//	The solver result.
+func DirtyPriceYieldToMaturityDetailed(C, F, P float64, n, m, tn, tb int, y float64, opts SolverOptions) (SolveResult, error) {
+	return solveYieldToMaturity(C, F, P, n, m, tn, tb, 0, y, opts)
+}
+
+// solveYieldToMaturity is DirtyPriceYieldToMaturityDetailed for a bond whose next coupon
+// is irregular, the next coupon pays an extra fraction of a regular coupon (negative for
+// a short coupon).
+func solveYieldToMaturity(C, F, P float64, n, m, tn, tb int, extra, y float64, opts SolverOptions) (SolveResult, error) {
+	if m < 1 {
+		return SolveResult{}, ErrNoRemainingCoupons
+	}
+
+	// A strip (zero-coupon) has a single cash flow so the yield can be solved directly
+	if C == 0 {
+		ytm, err := StripYieldToMaturity(F, P, n, m, tn, tb)
+		if err != nil {
+			return SolveResult{}, err
+		}
+
+		if !isFinite(ytm) {
+			return SolveResult{}, ErrNumericalInstability
+		}
+
+		result := SolveResult{
+			Yield:    ytm,
+			Residual: StripPrice(ytm, F, n, m, tn, tb) - P,
+		}
+
+		return result, checkYieldBounds(result.Yield, opts)
+	}
+
 	y = y / 100
 
-	for range i {
-		p := DirtyPrice(C, y*100, F, n, m, tn, tb)
+	tolerance := opts.Tolerance * F / 100
+
+	result := SolveResult{}
+
+	for i := range opts.MaxIterations {
+		p, d := irregularDirtyPriceAndDerivative(C, F, y, n, m, tn, tb, extra)
+
+		// an extreme yield can overflow the price or derivative, the NaN would otherwise
+		// propagate silently through the remaining iterations
+		if !isFinite(p) || !isFinite(d) {
+			return result, ErrNumericalInstability
+		}
 
 		dp := p - P
-		if math.Abs(dp) < t {
-			return y * 100, nil
+
+		result.Yield = y * 100
+		result.Iterations = i + 1
+		result.Residual = dp
+
+		if math.Abs(dp) < tolerance {
+			return result, checkYieldBounds(result.Yield, opts)
 		}
 
-		d := DirtyPriceDerivative(C, F, y, n, m, tn, tb)
 		if math.Abs(d) < 1e-12 {
-			return 0, ErrYieldToMaturityDerivativeTooSmall
+			return result, ErrYieldToMaturityDerivativeTooSmall
+		}
+
+		step := dp / d
+
+		if opts.Damped {
+			step = dampStep(y, step, opts.MaxStep/100, dampFloor(opts))
+		}
+
+		y = y - step
+	}
+
+	return result, ErrYieldToMaturityNoConvergence
+}
+
+// isFinite reports whether f is neither NaN nor an infinity.
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// checkYieldBounds checks a converged yield (as a percentage) is within the bounds of the options.
+func checkYieldBounds(y float64, opts SolverOptions) error {
+	if opts.Bounded && (y < opts.MinYield || y > opts.MaxYield) {
+		return fmt.Errorf("%w: %.4f%% is outside %.2f%% to %.2f%%", ErrYieldOutOfBounds, y, opts.MinYield, opts.MaxYield)
+	}
+	return nil
+}
+
+// dampFloor returns the yield (as a fraction) damped steps don't cross, the minimum yield
+// when bounded so the solver can reach a negative yield, otherwise zero.
+func dampFloor(opts SolverOptions) float64 {
+	if opts.Bounded {
+		return opts.MinYield / 100
+	}
+	return 0
+}
+
+// dampStep limits a Newton-Raphson step to the maximum step size and halves steps
+// which would take a yield above the floor below it.
+func dampStep(y, step, maxStep, floor float64) float64 {
+	if maxStep > 0 && math.Abs(step) > maxStep {
+		step = math.Copysign(maxStep, step)
+	}
+
+	for range 32 {
+		if y < floor || y-step >= floor {
+			break
 		}
+		step /= 2
+	}
+
+	return step
+}
+
+// StripPrice calculates the price of a strip (zero-coupon bond) which is the
+// redemption payment discounted over the remaining coupon periods.
+// There is no accrued interest on a strip so the clean and dirty prices are equal.
+//
+// Parameters:
+//
+//	y:    Annual yield to maturity (as a percentage).
+//	F:    Face value of the bond.
+//	n:    The number of (notional) coupon periods per year.
+//	m:    The number of coupon periods remaining to maturity.
+//	tn:   The number of days from the settlement date to the next coupon date.
+//	tb:   The number of days between the last coupon date and the next coupon date.
+//
+// Returns:
+//
+//	Strip price, zero if the strip has matured.
+func StripPrice(y, F float64, n, m, tn, tb int) float64 {
+	if m < 1 {
+		return 0
+	}
+
+	t := float64(tn)/float64(tb) + float64(m-1)
+	return F / math.Pow(1+y/100/float64(n), t)
+}
+
+// StripYieldToMaturity calculates the yield to maturity of a strip (zero-coupon bond).
+// As there is a single cash flow the yield is solved directly rather than numerically.
+//
+// Parameters:
+//
+//	F:    Face value of the bond.
+//	P:    Price of the strip.
+//	n:    The number of (notional) coupon periods per year.
+//	m:    The number of coupon periods remaining to maturity.
+//	tn:   The number of days from the settlement date to the next coupon date.
+//	tb:   The number of days between the last coupon date and the next coupon date.
+//
+// Returns:
+//
+//	Yield to maturity as a percentage.
+func StripYieldToMaturity(F, P float64, n, m, tn, tb int) (float64, error) {
+	if P <= 0 {
+		return 0, ErrInvalidDirtyPrice
+	}
 
-		y = y - dp/d
+	t := float64(tn)/float64(tb) + float64(m-1)
+	if t <= 0 {
+		return 0, ErrInvalidSettlementDate
 	}
 
-	return 0, ErrYieldToMaturityNoConvergence
+	y := float64(n) * (math.Pow(F/P, 1/t) - 1)
+
+	return y * 100, nil
+}
+
+// AccruedInterest calculates the interest accrued since the previous coupon date.
+//
+// Parameters:
+//
+//	coupon:           Annual coupon rate (as a percentage).
+//	face:             Face value of the bond.
+//	accruedDays:      The number of days from the previous coupon date to the settlement date.
+//	couponPeriodDays: The number of days between the previous coupon date and the next coupon date.
+//	freq:             The number of coupon payments per year.
+//
+// Returns:
+//
+//	Accrued interest.
+func AccruedInterest(coupon, face float64, accruedDays, couponPeriodDays, freq int) float64 {
+	return accruedInterest(coupon, face, float64(accruedDays)/float64(couponPeriodDays), freq)
+}
+
+// accruedInterest calculates the interest accrued for a fraction of the coupon period.
+func accruedInterest(coupon, face, fraction float64, freq int) float64 {
+	return fraction * coupon / float64(freq) / 100 * face
 }
 
 // EstimatedYieldToMaturity calculates a rough estimate of the yield to maturity which can
@@ -275,35 +817,322 @@ var (
 	ErrInvalidYieldToMaturity            = fmt.Errorf("invalid yield to maturity")
 	ErrInvalidFacePrice                  = fmt.Errorf("invalid face price")
 	ErrMissingPriceAndYield              = fmt.Errorf("missing price and yield")
+	ErrMultipleCashFlowsRemaining        = fmt.Errorf("more than one cash flow remaining")
+	ErrInvalidDayCount                   = fmt.Errorf("invalid day count convention")
+	ErrInvalidCouponSchedule             = fmt.Errorf("invalid coupon schedule")
+	ErrYieldOutOfBounds                  = fmt.Errorf("yield to maturity is out of bounds")
+	ErrInvalidCouponFrequency            = fmt.Errorf("invalid coupon frequency")
+	ErrInvalidAccrualRule                = fmt.Errorf("invalid accrual rule")
+	ErrNoRemainingCoupons                = fmt.Errorf("no coupons remain to maturity")
+	ErrNumericalInstability              = fmt.Errorf("yield to maturity solver numerical instability")
+	ErrInvalidSeedStrategy               = fmt.Errorf("invalid seed strategy")
 )
 
+// InferCouponDates infers the semi-annual coupon dates either side of the settlement date
+// from the maturity date, the coupons are paid on the maturity day and month and six months
+// either side. A coupon paid on the settlement date belongs to the seller so the next coupon
+// date is always after the settlement date.
+//
+// Parameters:
+//
+//	settlement: The settlement date.
+//	maturity:   The maturity date.
+//
+// Returns:
+//
+//	The previous and next coupon dates.
+func InferCouponDates(settlement, maturity time.Time) (prev, next time.Time) {
+	return InferCouponDatesWithFrequency(settlement, maturity, DefaultCouponFrequency)
+}
+
+// InferCouponDatesWithFrequency infers the coupon dates either side of the settlement date in
+// the same way as InferCouponDates for a bond paying a number of coupons per year.
+//
+// Parameters:
+//
+//	settlement: The settlement date.
+//	maturity:   The maturity date.
+//	freq:       The number of coupon payments per year, a divisor of 12.
+//
+// Returns:
+//
+//	The previous and next coupon dates.
+func InferCouponDatesWithFrequency(settlement, maturity time.Time, freq int) (prev, next time.Time) {
+	months := 12 / freq
+
+	// the coupon day is clamped to the end of shorter months rather than rolling over
+	day := maturity.Day()
+
+	// the coupon dates are projected from the maturity day and month in the settlement year
+	// so the schedule doesn't drift through short months
+	anchor := DateClamped(settlement.Year(), maturity.Month(), day, maturity.Location())
+	coupon := func(k int) time.Time {
+		return AddMonthsClamped(anchor, k*months, day)
+	}
+
+	k := 0
+	for !settlement.Before(coupon(k)) {
+		k++
+	}
+	for settlement.Before(coupon(k - 1)) {
+		k--
+	}
+
+	return coupon(k - 1), coupon(k)
+}
+
 func CompleteBond(b *Bond) error {
+	return CompleteBondWithOptions(b, DefaultSolverOptions())
+}
+
+// Completed completes a copy of the bond, the bond is unchanged so several scenarios
+// can be priced from the same base bond.
+//
+// Parameters:
+//
+//	b:    The bond.
+//	opts: The solver options.
+//
+// Returns:
+//
+//	The completed copy of the bond.
+func Completed(b Bond, opts SolverOptions) (Bond, error) {
+	if err := CompleteBondWithOptions(&b, opts); err != nil {
+		return Bond{}, err
+	}
+	return b, nil
+}
+
+// CompleteBondWithOptions completes the bond using the given solver options
+// when solving the yield to maturity.
+func CompleteBondWithOptions(b *Bond, opts SolverOptions) error {
 	if b == nil {
 		return ErrNilBond
 	}
 
+	MergeGiltRef(b)
+
+	if err := CheckGiltRef(b); err != nil {
+		return err
+	}
+
 	if b.SettlementDate.IsZero() {
-		return ErrInvalidSettlementDate
+		return newBondError(ErrInvalidSettlementDate, "SettlementDate", b.SettlementDate)
 	}
 
 	if b.MaturityDate.IsZero() {
-		return ErrInvalidMaturityDate
+		return newBondError(ErrInvalidMaturityDate, "MaturityDate", b.MaturityDate)
+	}
+
+	if err := validatePricing(b, opts); err != nil {
+		return err
+	}
+
+	b.CouponFrequency = b.Frequency()
+	freq := b.CouponFrequency
+
+	years, days, err := MaturityYears(b.SettlementDate, b.MaturityDate)
+	if err != nil {
+		return err
+	}
+
+	b.MaturityYears = years
+	b.MaturityDays = days
+
+	// the redemption on the maturity date is paid to the holder before settlement
+	if years == 0 && days == 0 {
+		return newBondError(ErrNoRemainingCoupons, "MaturityDate", b.MaturityDate)
+	}
+
+	// the first coupon period of a new issue can be longer or shorter than the
+	// regular period, the coupon dates are from the issue date to the first coupon
+	firstPeriod := !b.FirstCouponDate.IsZero() && b.SettlementDate.Before(b.FirstCouponDate)
+
+	// a new issue trades when-issued before the issue date, interest only accrues from the
+	// issue date so the first coupon schedule must be known
+	whenIssued := !b.IssueDate.IsZero() && b.SettlementDate.Before(b.IssueDate)
+	if whenIssued && !firstPeriod {
+		return newBondError(ErrInvalidSettlementDate, "SettlementDate", b.SettlementDate)
+	}
+
+	if firstPeriod {
+		if b.NextCouponDate.IsZero() {
+			b.NextCouponDate = b.FirstCouponDate
+		}
+		if b.PrevCouponDate.IsZero() && !b.IssueDate.IsZero() {
+			b.PrevCouponDate = b.IssueDate
+		}
+	}
+
+	if b.NextCouponDate.IsZero() {
+		_, b.NextCouponDate = InferCouponDatesWithFrequency(b.SettlementDate, b.MaturityDate, freq)
+	}
+
+	if b.PrevCouponDate.IsZero() {
+		b.PrevCouponDate = AddMonthsClamped(b.NextCouponDate, -12/freq, b.MaturityDate.Day())
+	}
+
+	// interest accrues to the end of the accrual period, the settlement date or the day after
+	// depending on the accrual rule
+	accrualEnd := b.AccrualRule.AccrualEnd(b.SettlementDate)
+
+	// the days are actual days, the day count convention only affects the accrued interest
+	b.RemainingDays = int(math.Floor(b.NextCouponDate.Sub(b.SettlementDate).Hours() / 24))
+	b.AccruedDays = int(math.Floor(accrualEnd.Sub(b.PrevCouponDate).Hours() / 24))
+	b.CouponPeriodDays = int(math.Floor(b.NextCouponDate.Sub(b.PrevCouponDate).Hours() / 24))
+
+	// the regular coupon period, differs from the coupon period days in an irregular first period
+	periodDays, _ := regularPeriod(b)
+
+	// the next coupon, the first coupon of a new issue, plus the regular coupons to maturity
+	b.CouponPeriods = 1 + couponsAfter(b.NextCouponDate, b.MaturityDate, freq)
+
+	switch b.DayCountConvention {
+	case ActualActual:
+		b.AccruedAmount = AccruedInterest(b.Coupon, b.FacePrice, b.AccruedDays, periodDays, freq)
+	case Actual365, Thirty360:
+		fraction := b.DayCountConvention.AccruedFraction(accrualEnd, b.PrevCouponDate, b.NextCouponDate, freq)
+		b.AccruedAmount = accruedInterest(b.Coupon, b.FacePrice, fraction, freq)
+	default:
+		return newBondError(ErrInvalidDayCount, "DayCountConvention", b.DayCountConvention)
+	}
+
+	// no interest has accrued when-issued, the clean and dirty prices are the same
+	if whenIssued {
+		b.AccruedDays = 0
+		b.AccruedAmount = 0
+	}
+
+	yearFraction, err := MaturityYearFraction(b.SettlementDate, b.MaturityDate)
+	if err != nil {
+		return err
+	}
+
+	b.AverageLife = WeightedAverageLife(b)
+	b.NextCouponAmount = NextCouponAmount(b)
+
+	return completePrices(b, yearFraction, opts)
+}
+
+// couponsAfter counts the regular coupons after a date by stepping the coupon dates back from
+// the maturity date, the coupon periods vary in length so dividing the days to maturity by the
+// length of one period can miscount quarterly and monthly coupons.
+//
+// Parameters:
+//
+//	date:     The date, normally the next coupon date.
+//	maturity: The maturity date, the last coupon date.
+//	freq:     The number of coupon payments per year, a divisor of 12.
+//
+// Returns:
+//
+//	The number of coupon dates after the date up to and including the maturity date.
+func couponsAfter(date, maturity time.Time, freq int) int {
+	months := 12 / freq
+
+	n := 0
+	for date.Before(AddMonthsClamped(maturity, -n*months, maturity.Day())) {
+		n++
+	}
+
+	return n
+}
+
+// regularPeriod calculates the days in the regular coupon period and the extra fraction of a
+// regular coupon paid by the next coupon. In an irregular first coupon period of a new issue
+// interest accrues at the regular rate and the first coupon is discounted over the actual time
+// to the first coupon date measured in regular periods, the regular period ending on the first
+// coupon date. Otherwise the regular period is the coupon period and there is no extra.
+//
+// Parameters:
+//
+//	b: The bond, the coupon dates are required for an irregular first coupon.
+//
+// Returns:
+//
+//	The days in the regular coupon period and the extra fraction of a regular coupon, positive
+//	for a long and negative for a short first coupon period.
+func regularPeriod(b *Bond) (int, float64) {
+	firstPeriod := !b.FirstCouponDate.IsZero() && b.SettlementDate.Before(b.FirstCouponDate) &&
+		!b.PrevCouponDate.IsZero() && b.NextCouponDate.Equal(b.FirstCouponDate)
+	if !firstPeriod {
+		return b.CouponPeriodDays, 0
 	}
 
-	if b.Coupon <= 0 {
-		return ErrInvalidCoupon
+	quasiCouponDate := AddMonthsClamped(b.NextCouponDate, -12/b.Frequency(), b.MaturityDate.Day())
+	periodDays := int(math.Floor(b.NextCouponDate.Sub(quasiCouponDate).Hours() / 24))
+	firstDays := int(math.Floor(b.NextCouponDate.Sub(b.PrevCouponDate).Hours() / 24))
+	if periodDays <= 0 {
+		return b.CouponPeriodDays, 0
+	}
+
+	return periodDays, float64(firstDays)/float64(periodDays) - 1
+}
+
+// bondDirtyPrice calculates the dirty price of a completed bond at the yield, including an
+// irregular first coupon, the same as CompleteBond so anything repricing a completed bond
+// agrees with its prices.
+//
+// Parameters:
+//
+//	b: The completed bond.
+//	y: Annual yield to maturity (as a percentage).
+//
+// Returns:
+//
+//	Dirty bond price.
+func bondDirtyPrice(b *Bond, y float64) float64 {
+	periodDays, extra := regularPeriod(b)
+
+	if extra == 0 {
+		return DirtyPrice(b.Coupon, y, b.FacePrice, b.Frequency(), b.CouponPeriods, b.RemainingDays, periodDays)
+	}
+
+	price, _ := irregularDirtyPriceAndDerivative(
+		b.Coupon,
+		b.FacePrice,
+		y/100,
+		b.Frequency(),
+		b.CouponPeriods,
+		b.RemainingDays,
+		periodDays,
+		extra,
+	)
+
+	return price
+}
+
+// validatePricing validates the bond fields required to calculate the prices or yield to maturity.
+func validatePricing(b *Bond, opts SolverOptions) error {
+	if err := validateCoupon(b.Coupon, b.Strip); err != nil {
+		return err
 	}
 
 	if b.FacePrice <= 0 {
-		return ErrInvalidFacePrice
+		return newBondError(ErrInvalidFacePrice, "FacePrice", b.FacePrice)
+	}
+
+	if b.AccrualRule != SettlementExclusive && b.AccrualRule != SettlementInclusive {
+		return newBondError(ErrInvalidAccrualRule, "AccrualRule", int(b.AccrualRule))
+	}
+
+	if err := validateFrequency(b.Frequency()); err != nil {
+		return err
 	}
 
 	if b.CleanPrice < 0 {
-		return ErrInvalidCleanPrice
+		return newBondError(ErrInvalidCleanPrice, "CleanPrice", b.CleanPrice)
 	}
 
-	if b.YieldToMaturity < 0 {
-		return ErrInvalidYieldToMaturity
+	// a negative yield is valid, e.g. short gilts traded below zero after 2020, so a given
+	// yield is checked against the same bounds as a solved yield. Unbounded, the yield must
+	// leave a positive discount factor per period.
+	if opts.Bounded {
+		if b.YieldToMaturity < opts.MinYield || b.YieldToMaturity > opts.MaxYield {
+			return newBondError(ErrInvalidYieldToMaturity, "YieldToMaturity", b.YieldToMaturity)
+		}
+	} else if b.YieldToMaturity <= -100*float64(b.Frequency()) {
+		return newBondError(ErrInvalidYieldToMaturity, "YieldToMaturity", b.YieldToMaturity)
 	}
 
 	// requires either a clean price or yield to maturity to calulate the other
@@ -311,96 +1140,167 @@ func CompleteBond(b *Bond) error {
 		return ErrMissingPriceAndYield
 	}
 
-	years, days, err := MaturityYears(b.SettlementDate, b.MaturityDate)
-	if err != nil {
-		return err
+	return nil
+}
+
+// validateCoupon validates the coupon rate, strips are zero-coupon and all other bonds require
+// a coupon. The coupon is a percentage so a coupon outside MinCoupon and MaxCoupon is most likely
+// scaled wrongly.
+func validateCoupon(coupon float64, strip bool) error {
+	if strip {
+		if coupon != 0 {
+			return newBondError(ErrInvalidCoupon, "Coupon", coupon)
+		}
+		return nil
 	}
 
-	b.MaturityYears = years
-	b.MaturityDays = days
+	if coupon < MinCoupon || coupon > MaxCoupon {
+		return newBondError(ErrInvalidCoupon, "Coupon", coupon)
+	}
 
-	if b.NextCouponDate.IsZero() {
-		t := time.Date(
-			b.SettlementDate.Year(),
-			b.MaturityDate.Month(),
-			b.MaturityDate.Day(),
-			0,
-			0,
-			0,
-			0,
-			b.MaturityDate.Location(),
-		)
+	return nil
+}
 
-		if b.SettlementDate.After(t) {
-			t = t.AddDate(0, 6, 0)
-			if b.SettlementDate.After(t) {
-				t = t.AddDate(0, 6, 0)
-			}
-		} else {
-			t2 := t.AddDate(0, -6, 0)
-			if b.SettlementDate.Before(t2) {
-				t = t2
-			}
-		}
+// validateFrequency validates the number of coupon payments per year, coupon dates are whole
+// months apart so the frequency must divide the year.
+func validateFrequency(freq int) error {
+	if freq < 1 || freq > 12 || 12%freq != 0 {
+		return newBondError(ErrInvalidCouponFrequency, "CouponFrequency", freq)
+	}
+	return nil
+}
 
-		b.NextCouponDate = t
+// validateSchedule validates a coupon schedule given rather than inferred from the coupon dates,
+// zero days to the next coupon is settling on a coupon date. Settling on the maturity date
+// leaves no time to discount the redemption over so the yield is undefined.
+func validateSchedule(freq, periods, daysToNext, periodDays int) error {
+	if err := validateFrequency(freq); err != nil {
+		return err
+	}
+	if periods < 1 || periodDays < 1 || daysToNext < 0 || daysToNext > periodDays {
+		return ErrInvalidCouponSchedule
+	}
+	if periods == 1 && daysToNext == 0 {
+		return ErrInvalidCouponSchedule
 	}
+	return nil
+}
 
-	if b.PrevCouponDate.IsZero() {
-		b.PrevCouponDate = b.NextCouponDate.AddDate(0, -6, 0)
+// CompleteBondWithSchedule completes the bond in the same way as CompleteBondWithOptions but
+// uses the given coupon schedule rather than inferring it from the coupon and maturity dates,
+// for unusual issues where the inference is wrong. The accrued interest is calculated from
+// the actual days in the schedule whatever the bond's day count convention.
+//
+// Parameters:
+//
+//	b:             The bond.
+//	periods:       The number of coupon payouts remaining to maturity.
+//	remainingDays: The number of days from the settlement date to the next coupon payment.
+//	periodDays:    The number of days between the last coupon date and the next coupon date.
+//	opts:          Solver options.
+//
+// Returns:
+//
+//	An error if the bond or schedule is invalid or the yield to maturity can't be solved.
+func CompleteBondWithSchedule(b *Bond, periods, remainingDays, periodDays int, opts SolverOptions) error {
+	if b == nil {
+		return ErrNilBond
 	}
 
-	// TODO need to account for different day-count conventions 360/30 vs Actual/Actual
-	// Fine for UK gilts, US treasuries
-	// Bad for euro bonds which use 30/360
-	b.RemainingDays = int(math.Floor(b.NextCouponDate.Sub(b.SettlementDate).Hours() / 24))
-	b.AccruedDays = int(math.Floor(b.SettlementDate.Sub(b.PrevCouponDate).Hours() / 24))
-	b.CouponPeriodDays = int(math.Floor(b.NextCouponDate.Sub(b.PrevCouponDate).Hours() / 24))
-	b.AccruedAmount = float64(b.AccruedDays) / float64(b.CouponPeriodDays) * b.Coupon / 2 / 100 * b.FacePrice
+	if err := validatePricing(b, opts); err != nil {
+		return err
+	}
+
+	if err := validateSchedule(b.Frequency(), periods, remainingDays, periodDays); err != nil {
+		return err
+	}
+
+	b.CouponFrequency = b.Frequency()
+
+	b.CouponPeriods = periods
+	b.RemainingDays = remainingDays
+	b.CouponPeriodDays = periodDays
+	b.AccruedDays = periodDays - remainingDays
+	if b.AccrualRule == SettlementInclusive {
+		b.AccruedDays++
+	}
+	b.AccruedAmount = AccruedInterest(b.Coupon, b.FacePrice, b.AccruedDays, b.CouponPeriodDays, b.CouponFrequency)
+
+	// the years to maturity for the initial estimate of the yield to maturity
+	years := (float64(periods-1) + float64(remainingDays)/float64(periodDays)) / float64(b.CouponFrequency)
+
+	// the schedule has no dates, the principal of a gilt is repaid at the end of the last period
+	b.AverageLife = years
+	b.NextCouponAmount = NextCouponAmount(b)
 
-	b.CouponPeriods = b.MaturityYears * 2
-	b.CouponPeriods += int(math.Ceil(float64(b.MaturityDays) / float64(b.CouponPeriodDays)))
+	return completePrices(b, years, opts)
+}
+
+// completePrices calculates the yield to maturity from the clean price, or the prices from the
+// yield to maturity without a clean price, once the coupon schedule and accrued interest are known.
+func completePrices(b *Bond, years float64, opts SolverOptions) error {
+	freq := b.CouponFrequency
+	periodDays, extra := regularPeriod(b)
 
-	if b.YieldToMaturity == 0 {
+	// the yield is solved from the price when there is one, a yield published by the source
+	// is kept in SourceYield rather than the yield to maturity
+	if b.CleanPrice > 0 {
 		b.DirtyPrice = b.CleanPrice + b.AccruedAmount
 
-		estimatedYTM := EstimatedYieldToMaturity(
-			b.Coupon,
-			b.FacePrice,
-			b.CleanPrice,
-			float64(b.MaturityYears)+float64(b.MaturityDays)/365.0,
-		)
+		estimatedYTM := seedYield(b, years, opts)
 
-		ytm, err := DirtyPriceYieldToMaturity(
+		result, err := solveYieldToMaturity(
 			b.Coupon,
 			b.FacePrice,
 			b.DirtyPrice,
-			2,
+			freq,
 			b.CouponPeriods,
 			b.RemainingDays,
-			b.CouponPeriodDays,
+			periodDays,
+			extra,
 			estimatedYTM,
-			0.001,
-			1_000,
+			opts,
 		)
 
+		b.SolverIterations = result.Iterations
+
 		if err != nil {
 			return err
 		}
 
-		b.YieldToMaturity = ytm
+		b.YieldToMaturity = result.Yield
 	} else {
-		b.DirtyPrice = DirtyPrice(
-			b.Coupon,
-			b.YieldToMaturity,
-			b.FacePrice,
-			2,
-			b.CouponPeriods,
-			b.RemainingDays,
-			b.CouponPeriodDays,
-		)
+		b.DirtyPrice = bondDirtyPrice(b, b.YieldToMaturity)
 
 		b.CleanPrice = b.DirtyPrice - b.AccruedAmount
+
+		if opts.RoundPrices {
+			b.CleanPrice = roundHalfEven(b.CleanPrice, opts.PriceDecimals)
+		}
+	}
+
+	// the risk is calculated from the yield, not the rounded prices
+	b.Duration, b.Convexity, b.DV01 = riskMeasures(
+		b.Coupon,
+		b.FacePrice,
+		b.YieldToMaturity,
+		freq,
+		b.CouponPeriods,
+		b.RemainingDays,
+		periodDays,
+		extra,
+	)
+
+	if opts.RoundPrices {
+		b.DirtyPrice = roundHalfEven(b.DirtyPrice, opts.PriceDecimals)
+	}
+
+	b.YieldToMaturityContinuous = ToContinuous(b.YieldToMaturity, freq)
+
+	// the difference to the yield published by the source is a data quality signal
+	b.ComputedVsSourceYield = 0
+	if b.SourceYield != 0 {
+		b.ComputedVsSourceYield = b.YieldToMaturity - b.SourceYield
 	}
 
 	return nil
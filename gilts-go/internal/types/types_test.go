@@ -0,0 +1,2220 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// refDirtyPrice is the DMO price/yield formula for a conventional gilt written directly from
+// the cash flows, each discounted with math.Pow, as an independent check of the engine.
+//
+// Parameters:
+//
+//	C:  Annual coupon rate (as a percentage).
+//	F:  Face value of the bond.
+//	y:  Yield to maturity (as a fraction).
+//	n:  The number of coupon payments per year.
+//	m:  The number of coupon payments remaining to maturity.
+//	tn: The number of days from the settlement date to the next coupon date.
+//	tb: The number of days in the coupon period.
+func refDirtyPrice(C, F, y float64, n, m, tn, tb int) float64 {
+	g := 1 + y/float64(n)
+	r := float64(tn) / float64(tb)
+	cp := C / 100 / float64(n) * F
+
+	price := 0.0
+	for j := 1; j <= m; j++ {
+		price += cp / math.Pow(g, r+float64(j-1))
+	}
+
+	return price + F/math.Pow(g, r+float64(m-1))
+}
+
+// refYield solves refDirtyPrice for the yield (as a percentage) by bisection.
+func refYield(C, F, P float64, n, m, tn, tb int) float64 {
+	lo, hi := -0.05, 0.5
+	for range 200 {
+		mid := (lo + hi) / 2
+		if refDirtyPrice(C, F, mid, n, m, tn, tb) > P {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2 * 100
+}
+
+// refDuration is the modified duration of refDirtyPrice by a central difference.
+func refDuration(C, F, y float64, n, m, tn, tb int) float64 {
+	const h = 1e-6
+	y = y / 100
+	up := refDirtyPrice(C, F, y+h, n, m, tn, tb)
+	down := refDirtyPrice(C, F, y-h, n, m, tn, tb)
+	return -(up - down) / (2 * h) / refDirtyPrice(C, F, y, n, m, tn, tb)
+}
+
+// the 3½% Treasury Gilt 2025 settling on 7 March 2025, 46 days to the 22 April 2025 coupon
+// of a 182 day period with 2 coupons remaining, the self-test gilt.
+const (
+	tr25Coupon     = 3.5
+	tr25CleanPrice = 99.5
+	tr25Periods    = 2
+	tr25ToNext     = 46
+	tr25PeriodDays = 182
+)
+
+var (
+	tr25Settlement = time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	tr25Maturity   = time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC)
+	// tr25Accrued is 136 of the 182 days of the 1.75 coupon.
+	tr25Accrued = 1.75 * 136 / 182
+)
+
+func TestCompleteBondTreasury2025(t *testing.T) {
+	dirty := tr25CleanPrice + tr25Accrued
+	ytm := refYield(tr25Coupon, 100, dirty, 2, tr25Periods, tr25ToNext, tr25PeriodDays)
+	duration := refDuration(tr25Coupon, 100, ytm, 2, tr25Periods, tr25ToNext, tr25PeriodDays)
+
+	tests := []struct {
+		name       string
+		cleanPrice float64
+		ytm        float64
+	}{
+		{name: "yield from clean price", cleanPrice: tr25CleanPrice},
+		{name: "prices from yield", ytm: ytm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+			b.CleanPrice = tt.cleanPrice
+			b.YieldToMaturity = tt.ytm
+
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+
+			checks := []struct {
+				field     string
+				got, want float64
+				tolerance float64
+			}{
+				// the solver converges to a price within 0.001 so the yield to within ~0.2bp
+				{"YieldToMaturity", b.YieldToMaturity, ytm, 0.005},
+				{"CleanPrice", b.CleanPrice, tr25CleanPrice, 0.001},
+				{"DirtyPrice", b.DirtyPrice, dirty, 0.001},
+				{"AccruedAmount", b.AccruedAmount, tr25Accrued, 1e-9},
+				{"Duration", b.Duration, duration, 1e-4},
+			}
+
+			for _, c := range checks {
+				if math.Abs(c.got-c.want) > c.tolerance {
+					t.Errorf("%s = %.6f, want %.6f", c.field, c.got, c.want)
+				}
+			}
+
+			if b.CouponPeriods != tr25Periods || b.RemainingDays != tr25ToNext || b.CouponPeriodDays != tr25PeriodDays {
+				t.Errorf(
+					"schedule = %d periods, %d/%d days, want %d periods, %d/%d days",
+					b.CouponPeriods, b.RemainingDays, b.CouponPeriodDays,
+					tr25Periods, tr25ToNext, tr25PeriodDays,
+				)
+			}
+		})
+	}
+}
+
+func TestDirtyPriceYieldToMaturity(t *testing.T) {
+	dirty := tr25CleanPrice + tr25Accrued
+	want := refYield(tr25Coupon, 100, dirty, 2, tr25Periods, tr25ToNext, tr25PeriodDays)
+
+	opts := DefaultSolverOptions()
+	opts.Tolerance = 1e-9
+
+	got, err := DirtyPriceYieldToMaturityWithOptions(tr25Coupon, 100, dirty, 2, tr25Periods, tr25ToNext, tr25PeriodDays, 4, opts)
+	if err != nil {
+		t.Fatalf("DirtyPriceYieldToMaturityWithOptions() error = %v", err)
+	}
+
+	if math.Abs(got-want) > 1e-6 {
+		t.Errorf("yield = %.8f%%, want %.8f%%", got, want)
+	}
+}
+
+func TestDirtyPriceMatchesReference(t *testing.T) {
+	for _, C := range []float64{0, 0.125, 4.25, 8} {
+		for _, y := range []float64{-0.5, 0.01, 4.5, 15} {
+			for _, m := range []int{1, 2, 20, 100} {
+				got := DirtyPrice(C, y, 100, 2, m, 46, 182)
+				want := refDirtyPrice(C, 100, y/100, 2, m, 46, 182)
+				if math.Abs(got-want) > 1e-9 {
+					t.Errorf("DirtyPrice(%v, %v, m=%d) = %.12f, want %.12f", C, y, m, got, want)
+				}
+
+				// the derivative is with respect to the yield as a fraction
+				const h = 1e-7
+				wantD := (refDirtyPrice(C, 100, y/100+h, 2, m, 46, 182) - refDirtyPrice(C, 100, y/100-h, 2, m, 46, 182)) / (2 * h)
+				gotD := DirtyPriceDerivative(C, 100, y/100, 2, m, 46, 182)
+				if math.Abs(gotD-wantD) > 1e-4*math.Max(1, math.Abs(wantD)) {
+					t.Errorf("DirtyPriceDerivative(%v, %v, m=%d) = %.6f, want %.6f", C, y, m, gotD, wantD)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkDirtyPrice(b *testing.B) {
+	// a 50 year gilt, 100 coupons
+	for b.Loop() {
+		DirtyPrice(4.25, 4.5, 100, 2, 100, 46, 182)
+	}
+}
+
+// BenchmarkReferenceDirtyPrice is the math.Pow per coupon formula DirtyPrice replaced, for comparison.
+func BenchmarkReferenceDirtyPrice(b *testing.B) {
+	for b.Loop() {
+		refDirtyPrice(4.25, 100, 0.045, 2, 100, 46, 182)
+	}
+}
+
+func BenchmarkCompleteBond(b *testing.B) {
+	maturity := time.Date(2075, 10, 22, 0, 0, 0, 0, time.UTC)
+	for b.Loop() {
+		bond := NewUKGiltWithMaturity("bench", tr25Settlement, 4.25, maturity)
+		bond.CleanPrice = 95
+		if err := CompleteBond(bond); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCleanPriceMatchesCompleteBond(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   float64
+		ytm      float64
+		maturity time.Time
+	}{
+		{name: "4% 2030", coupon: 4, ytm: 4.2381, maturity: time.Date(2030, 10, 22, 0, 0, 0, 0, time.UTC)},
+		{name: "3½% 2025", coupon: tr25Coupon, ytm: 4.311873, maturity: tr25Maturity},
+		{name: "0⅛% 2028", coupon: 0.125, ytm: 3.9, maturity: time.Date(2028, 1, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewUKGiltWithMaturity("test", tr25Settlement, tt.coupon, tt.maturity)
+			b.YieldToMaturity = tt.ytm
+
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+
+			got := CleanPrice(tt.coupon, tt.ytm, b.FacePrice, b.Frequency(), b.CouponPeriods, b.RemainingDays, b.CouponPeriodDays)
+			if math.Abs(got-b.CleanPrice) > 1e-9 {
+				t.Errorf("CleanPrice() = %.6f, CompleteBond clean price = %.6f", got, b.CleanPrice)
+			}
+			if math.Abs(got+b.AccruedAmount-b.DirtyPrice) > 1e-9 {
+				t.Errorf("CleanPrice() + accrued = %.6f, want dirty price %.6f", got+b.AccruedAmount, b.DirtyPrice)
+			}
+		})
+	}
+}
+
+// newIssue is a 4⅜% gilt issued on 29 January 2025 with a long first coupon on 31 July 2025.
+func newIssue() *Bond {
+	b := NewUKGiltWithMaturity("test", tr25Settlement, 4.375, time.Date(2040, 1, 31, 0, 0, 0, 0, time.UTC))
+	b.IssueDate = time.Date(2025, 1, 29, 0, 0, 0, 0, time.UTC)
+	b.FirstCouponDate = time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC)
+	return b
+}
+
+func TestPriceYieldGridMatchesCompleteBond(t *testing.T) {
+	tests := []struct {
+		name string
+		bond *Bond
+	}{
+		{name: "regular period", bond: NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)},
+		{name: "irregular first period", bond: newIssue()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.bond
+			b.YieldToMaturity = 4.5
+
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+
+			points := PriceYieldGrid(b, 4, 5, 0.5)
+			if len(points) != 3 {
+				t.Fatalf("PriceYieldGrid() = %d points, want 3", len(points))
+			}
+
+			if got := points[1].CleanPrice; math.Abs(got-b.CleanPrice) > 1e-9 {
+				t.Errorf("grid clean price at %.2f%% = %.6f, CompleteBond clean price = %.6f", points[1].Yield, got, b.CleanPrice)
+			}
+		})
+	}
+}
+
+func TestScenarioPnL(t *testing.T) {
+	const ytm, nominal = 4.5, 10000.0
+
+	var p Portfolio
+	want := 0.0
+	for _, b := range []*Bond{NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity), newIssue()} {
+		b.YieldToMaturity = ytm
+		shifted := b.Clone()
+		shifted.YieldToMaturity = ytm + 1
+
+		if err := CompleteBond(b); err != nil {
+			t.Fatalf("CompleteBond() error = %v", err)
+		}
+		if err := CompleteBond(shifted); err != nil {
+			t.Fatalf("CompleteBond() error = %v", err)
+		}
+
+		p.AddHolding(b, nominal)
+		want += nominal / b.FacePrice * (shifted.DirtyPrice - b.DirtyPrice)
+	}
+
+	if got := p.ScenarioPnL(0); math.Abs(got) > 1e-9 {
+		t.Errorf("ScenarioPnL(0) = %.12f, want 0", got)
+	}
+
+	// the P&L of a 100bp shift is the change in the prices CompleteBond derives
+	if got := p.ScenarioPnL(100); math.Abs(got-want) > 1e-6 {
+		t.Errorf("ScenarioPnL(100) = %.6f, want %.6f", got, want)
+	}
+}
+
+func TestCompleteBondSourceYield(t *testing.T) {
+	b := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+	b.SourceYield = 4.3
+
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	// the yield is solved from the price, the source yield is only compared
+	want := refYield(tr25Coupon, 100, tr25CleanPrice+tr25Accrued, 2, tr25Periods, tr25ToNext, tr25PeriodDays)
+	if math.Abs(b.YieldToMaturity-want) > 0.005 {
+		t.Errorf("YieldToMaturity = %.6f, want %.6f", b.YieldToMaturity, want)
+	}
+	if b.SourceYield != 4.3 {
+		t.Errorf("SourceYield = %.6f, want 4.3", b.SourceYield)
+	}
+	if got := b.YieldToMaturity - b.SourceYield; b.ComputedVsSourceYield != got {
+		t.Errorf("ComputedVsSourceYield = %.6f, want %.6f", b.ComputedVsSourceYield, got)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	// the expected yield is independent of the engine
+	want := refYield(tr25Coupon, 100, tr25CleanPrice+tr25Accrued, 2, tr25Periods, tr25ToNext, tr25PeriodDays)
+	if math.Abs(selfTestYield-want) > 1e-6 {
+		t.Errorf("selfTestYield = %.6f, want %.6f", selfTestYield, want)
+	}
+
+	if err := SelfTest(); err != nil {
+		t.Errorf("SelfTest() error = %v", err)
+	}
+}
+
+func TestMarshalGiltRefs(t *testing.T) {
+	refs := loadGiltRefs()
+
+	all := make([]GiltRef, 0, len(refs.byISIN))
+	for _, ref := range refs.byISIN {
+		all = append(all, *ref)
+	}
+
+	// the embedded file is in the generated format so regenerating it only diffs the changes
+	data, err := MarshalGiltRefs(all)
+	if err != nil {
+		t.Fatalf("MarshalGiltRefs() error = %v", err)
+	}
+	if string(data) != string(giltsJSON) {
+		t.Errorf("gilts.json is not in the MarshalGiltRefs format:\n%s", data)
+	}
+
+	issued := GiltRef{
+		ISIN:         "GB00BTEST001",
+		Desc:         "4⅜% Treasury Gilt 2040",
+		Coupon:       4.375,
+		MaturityDate: time.Date(2040, 1, 31, 0, 0, 0, 0, time.UTC),
+		IssueDate:    time.Date(2025, 1, 29, 0, 0, 0, 0, time.UTC),
+	}
+
+	data, err = MarshalGiltRefs([]GiltRef{issued})
+	if err != nil {
+		t.Fatalf("MarshalGiltRefs() error = %v", err)
+	}
+
+	parsed, err := parseGiltRefs(data)
+	if err != nil {
+		t.Fatalf("parseGiltRefs() error = %v", err)
+	}
+	if got := parsed.byISIN[issued.ISIN]; got == nil || !got.IssueDate.Equal(issued.IssueDate) {
+		t.Errorf("parsed reference = %+v, want issue date %s", got, issued.IssueDate.Format("2006-01-02"))
+	}
+}
+
+func TestApplyGiltRef(t *testing.T) {
+	ref := &GiltRef{
+		ISIN:            "GB00BTEST001",
+		Ticker:          "TN40",
+		Desc:            "4⅜% Treasury Gilt 2040",
+		Coupon:          4.375,
+		MaturityDate:    time.Date(2040, 1, 31, 0, 0, 0, 0, time.UTC),
+		FirstCouponDate: time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC),
+		IssueDate:       time.Date(2025, 1, 29, 0, 0, 0, 0, time.UTC),
+		AmountInIssue:   4000,
+	}
+
+	t.Run("fills missing fields", func(t *testing.T) {
+		b := NewUKGilt("test", tr25Settlement)
+		b.ISIN = ref.ISIN
+		ApplyGiltRef(b, ref)
+
+		if b.Ticker != ref.Ticker || b.Coupon != ref.Coupon || !b.MaturityDate.Equal(ref.MaturityDate) ||
+			!b.FirstCouponDate.Equal(ref.FirstCouponDate) || !b.IssueDate.Equal(ref.IssueDate) ||
+			b.AmountInIssue != ref.AmountInIssue {
+			t.Errorf("ApplyGiltRef() = %+v, want the fields of %+v", b, ref)
+		}
+	})
+
+	t.Run("keeps set fields", func(t *testing.T) {
+		firstCoupon := time.Date(2025, 7, 30, 0, 0, 0, 0, time.UTC)
+		issue := time.Date(2025, 1, 28, 0, 0, 0, 0, time.UTC)
+
+		b := NewUKGilt("test", tr25Settlement)
+		b.FirstCouponDate = firstCoupon
+		b.IssueDate = issue
+		ApplyGiltRef(b, ref)
+
+		if !b.FirstCouponDate.Equal(firstCoupon) || !b.IssueDate.Equal(issue) {
+			t.Errorf("ApplyGiltRef() overwrote the dates: first coupon %s, issue %s", b.FirstCouponDate, b.IssueDate)
+		}
+	})
+}
+
+func TestSimpleYield(t *testing.T) {
+	settlement := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	days := 82.0 // to 22 October 2025
+
+	tests := []struct {
+		name      string
+		frequency int
+		coupon    float64 // the final coupon per 100
+	}{
+		{name: "semi-annual", frequency: 2, coupon: 1.75},
+		{name: "quarterly", frequency: 4, coupon: 0.875},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewUKGiltWithMaturity("test", settlement, tr25Coupon, tr25Maturity)
+			b.CouponFrequency = tt.frequency
+			b.CleanPrice = 99.9
+
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+
+			got, err := SimpleYield(b)
+			if err != nil {
+				t.Fatalf("SimpleYield() error = %v", err)
+			}
+
+			want := (100 + tt.coupon - b.DirtyPrice) / b.DirtyPrice * 365 / days * 100
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("SimpleYield() = %.6f, want %.6f", got, want)
+			}
+		})
+	}
+}
+
+func TestYieldFromDirtySchedule(t *testing.T) {
+	opts := DefaultSolverOptions()
+	opts.Tolerance = 1e-9
+
+	tests := []struct {
+		name       string
+		freq       int
+		periods    int
+		daysToNext int
+		periodDays int
+		wantErr    error
+	}{
+		{name: "mid period", freq: 2, periods: 2, daysToNext: 46, periodDays: 182},
+		{name: "on a coupon date", freq: 2, periods: 2, daysToNext: 0, periodDays: 182},
+		{name: "on the maturity date", freq: 2, periods: 1, daysToNext: 0, periodDays: 182, wantErr: ErrInvalidCouponSchedule},
+		{name: "past the next coupon", freq: 2, periods: 2, daysToNext: 183, periodDays: 182, wantErr: ErrInvalidCouponSchedule},
+		{name: "invalid frequency", freq: 5, periods: 2, daysToNext: 46, periodDays: 182, wantErr: ErrInvalidCouponFrequency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirty := 100.5
+
+			got, err := YieldFromDirty(tr25Coupon, 100, dirty, tt.freq, tt.periods, tt.daysToNext, tt.periodDays, opts)
+
+			// CompleteBondWithSchedule validates the schedule the same way
+			b := NewUKGilt("test", tr25Settlement)
+			b.Coupon = tr25Coupon
+			b.CouponFrequency = tt.freq
+			b.CleanPrice = dirty - AccruedInterest(tr25Coupon, 100, tt.periodDays-tt.daysToNext, tt.periodDays, tt.freq)
+			scheduleErr := CompleteBondWithSchedule(b, tt.periods, tt.daysToNext, tt.periodDays, opts)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("YieldFromDirty() error = %v, want %v", err, tt.wantErr)
+				}
+				if !errors.Is(scheduleErr, tt.wantErr) {
+					t.Errorf("CompleteBondWithSchedule() error = %v, want %v", scheduleErr, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("YieldFromDirty() error = %v", err)
+			}
+			if scheduleErr != nil {
+				t.Fatalf("CompleteBondWithSchedule() error = %v", scheduleErr)
+			}
+
+			want := refYield(tr25Coupon, 100, dirty, tt.freq, tt.periods, tt.daysToNext, tt.periodDays)
+			if math.Abs(got-want) > 1e-6 {
+				t.Errorf("YieldFromDirty() = %.8f, want %.8f", got, want)
+			}
+			if math.Abs(b.YieldToMaturity-want) > 1e-6 {
+				t.Errorf("CompleteBondWithSchedule() yield = %.8f, want %.8f", b.YieldToMaturity, want)
+			}
+		})
+	}
+}
+
+func TestCompleteBondCouponScale(t *testing.T) {
+	tests := []struct {
+		name    string
+		coupon  float64
+		strip   bool
+		wantErr bool
+	}{
+		{name: "percentage", coupon: 3.5},
+		{name: "smallest gilt coupon", coupon: 0.125},
+		{name: "strip", coupon: 0, strip: true},
+		{name: "fraction", coupon: 0.035, wantErr: true},
+		{name: "basis points", coupon: 350, wantErr: true},
+		{name: "missing", coupon: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewUKGiltWithMaturity("test", tr25Settlement, tt.coupon, tr25Maturity)
+			b.Strip = tt.strip
+			b.CleanPrice = tr25CleanPrice
+
+			err := CompleteBond(b)
+			if tt.wantErr != errors.Is(err, ErrInvalidCoupon) {
+				t.Errorf("CompleteBond() error = %v, want invalid coupon %t", err, tt.wantErr)
+			}
+
+			// YieldFromDirty treats a zero coupon as a strip
+			_, err = YieldFromDirty(tt.coupon, 100, 100.5, 2, tr25Periods, tr25ToNext, tr25PeriodDays, DefaultSolverOptions())
+			if wantErr := tt.wantErr && tt.coupon != 0; wantErr != errors.Is(err, ErrInvalidCoupon) {
+				t.Errorf("YieldFromDirty() error = %v, want invalid coupon %t", err, wantErr)
+			}
+		})
+	}
+}
+
+func TestCouponPeriods(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		freq       int
+		settlement time.Time
+		maturity   time.Time
+		want       int
+	}{
+		{name: "semi-annual", freq: 2, settlement: tr25Settlement, maturity: tr25Maturity, want: 2},
+		{name: "annual", freq: 1, settlement: tr25Settlement, maturity: tr25Maturity, want: 1},
+		// April, July and October, the 272 days to maturity are just over 3 of the 90 day periods
+		{name: "quarterly", freq: 4, settlement: date(2025, 1, 23), maturity: tr25Maturity, want: 3},
+		{name: "quarterly month end", freq: 4, settlement: date(2025, 1, 15), maturity: date(2025, 12, 31), want: 4},
+		{name: "monthly", freq: 12, settlement: date(2025, 1, 23), maturity: tr25Maturity, want: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewUKGiltWithMaturity("test", tt.settlement, 4, tt.maturity)
+			b.CouponFrequency = tt.freq
+			b.CleanPrice = 100
+
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+			if b.CouponPeriods != tt.want {
+				t.Errorf("CouponPeriods = %d, want %d", b.CouponPeriods, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteBondAnnual(t *testing.T) {
+	// the 5% annual coupon and the redemption 229 days away in the 365 day period from
+	// 22 October 2024, discounted at 4% a year
+	const (
+		wantDirty   = 102.44779811644845
+		wantAccrued = 5.0 * 136 / 365
+	)
+
+	b := NewUKGiltWithMaturity("test", tr25Settlement, 5, tr25Maturity)
+	b.CouponFrequency = 1
+	b.YieldToMaturity = 4
+
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	if b.CouponPeriods != 1 || b.RemainingDays != 229 || b.CouponPeriodDays != 365 {
+		t.Errorf("schedule = %d periods, %d/%d days, want 1 period, 229/365 days",
+			b.CouponPeriods, b.RemainingDays, b.CouponPeriodDays)
+	}
+	if math.Abs(b.DirtyPrice-wantDirty) > 1e-6 {
+		t.Errorf("DirtyPrice = %.8f, want %.8f", b.DirtyPrice, wantDirty)
+	}
+	if math.Abs(b.AccruedAmount-wantAccrued) > 1e-9 {
+		t.Errorf("AccruedAmount = %.8f, want %.8f", b.AccruedAmount, wantAccrued)
+	}
+}
+
+func TestParCoupon(t *testing.T) {
+	curve := FlatSpotCurve(4.5)
+
+	// a bond paying coupons at the compounding frequency of a flat curve prices at par with a
+	// coupon equal to the rate
+	coupon, err := ParCoupon(curve, 10, 2)
+	if err != nil {
+		t.Fatalf("ParCoupon() error = %v", err)
+	}
+	if math.Abs(coupon-4.5) > 1e-8 {
+		t.Errorf("ParCoupon() = %.10f, want 4.5", coupon)
+	}
+
+	for _, freq := range []int{0, 5} {
+		var be *BondError
+		_, err := ParCoupon(curve, 10, freq)
+		if !errors.Is(err, ErrInvalidCouponFrequency) || !errors.As(err, &be) || be.Field != "CouponFrequency" {
+			t.Errorf("ParCoupon(freq %d) error = %v, want a CouponFrequency %v", freq, err, ErrInvalidCouponFrequency)
+		}
+	}
+}
+
+func TestBootstrapSpotCurve(t *testing.T) {
+	maturities := []time.Time{
+		time.Date(2025, 10, 22, 0, 0, 0, 0, time.UTC),
+		time.Date(2027, 10, 22, 0, 0, 0, 0, time.UTC),
+		time.Date(2030, 10, 22, 0, 0, 0, 0, time.UTC),
+		time.Date(2040, 10, 22, 0, 0, 0, 0, time.UTC),
+	}
+
+	complete := func(coupon, ytm float64, maturity time.Time) *Bond {
+		b := NewUKGiltWithMaturity("test", tr25Settlement, coupon, maturity)
+		b.YieldToMaturity = ytm
+		if err := CompleteBond(b); err != nil {
+			t.Fatalf("CompleteBond() error = %v", err)
+		}
+		return b
+	}
+
+	t.Run("flat yields", func(t *testing.T) {
+		// discounting every cash flow at the same semi-annual rate is the price/yield formula
+		// so the spot curve of bonds with the same yield is flat at that yield
+		bonds := []*Bond{}
+		for i, maturity := range maturities {
+			bonds = append(bonds, complete(2+float64(i), 4.5, maturity))
+		}
+
+		curve, err := BootstrapSpotCurve(bonds)
+		if err != nil {
+			t.Fatalf("BootstrapSpotCurve() error = %v", err)
+		}
+
+		if len(curve.Rates) != len(bonds) {
+			t.Fatalf("got %d points, want %d", len(curve.Rates), len(bonds))
+		}
+		for i, rate := range curve.Rates {
+			if math.Abs(rate-4.5) > 1e-8 {
+				t.Errorf("rate at %.4f years = %.10f, want 4.5", curve.Maturities[i], rate)
+			}
+		}
+	})
+
+	t.Run("reprices the bonds", func(t *testing.T) {
+		bonds := []*Bond{
+			complete(4, 4.0, maturities[2]),
+			complete(3.5, 4.3, maturities[0]),
+			complete(4.25, 4.8, maturities[3]),
+			complete(1, 4.1, maturities[1]),
+			// a second bond maturing on the same date is skipped
+			complete(6, 4.2, maturities[1]),
+		}
+
+		curve, err := BootstrapSpotCurve(bonds)
+		if err != nil {
+			t.Fatalf("BootstrapSpotCurve() error = %v", err)
+		}
+
+		if len(curve.Rates) != 4 {
+			t.Fatalf("got %d points, want 4", len(curve.Rates))
+		}
+		for _, b := range bonds[:4] {
+			if got := curveDirtyPrice(curve, b, cashFlowTimes(b)); math.Abs(got-b.DirtyPrice) > 1e-6 {
+				t.Errorf("%s bond repriced at %.8f, want %.8f", b.MaturityDate.Format("2006"), got, b.DirtyPrice)
+			}
+		}
+	})
+
+	t.Run("no bonds", func(t *testing.T) {
+		if _, err := BootstrapSpotCurve(nil); !errors.Is(err, ErrNoBootstrapBonds) {
+			t.Errorf("BootstrapSpotCurve() error = %v, want %v", err, ErrNoBootstrapBonds)
+		}
+	})
+}
+
+func TestCleanToDirty(t *testing.T) {
+	prevCoupon := time.Date(2024, 10, 22, 0, 0, 0, 0, time.UTC)
+	nextCoupon := time.Date(2025, 4, 22, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		dayCount    DayCount
+		wantAccrued float64
+	}{
+		{dayCount: ActualActual, wantAccrued: tr25Accrued},
+		{dayCount: Actual365, wantAccrued: 3.5 * 136 / 365},
+		{dayCount: Thirty360, wantAccrued: 3.5 * 135 / 360},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dayCount.String(), func(t *testing.T) {
+			dirty := CleanToDirty(tr25CleanPrice, tr25Coupon, 100, tr25Settlement, prevCoupon, nextCoupon, tt.dayCount)
+			if math.Abs(dirty-(tr25CleanPrice+tt.wantAccrued)) > 1e-12 {
+				t.Errorf("CleanToDirty() = %v, want %v", dirty, tr25CleanPrice+tt.wantAccrued)
+			}
+
+			for _, clean := range []float64{0.01, 72.125, tr25CleanPrice, 100, 131.875} {
+				dirty := CleanToDirty(clean, tr25Coupon, 100, tr25Settlement, prevCoupon, nextCoupon, tt.dayCount)
+				if got := DirtyToClean(dirty, tr25Coupon, 100, tr25Settlement, prevCoupon, nextCoupon, tt.dayCount); math.Abs(got-clean) > 1e-12 {
+					t.Errorf("DirtyToClean(CleanToDirty(%v)) = %v", clean, got)
+				}
+			}
+		})
+	}
+
+	// the accrued interest scales with the face value and is nothing on the coupon date
+	dirty := CleanToDirty(995, tr25Coupon, 1000, tr25Settlement, prevCoupon, nextCoupon, ActualActual)
+	if math.Abs(dirty-(995+10*tr25Accrued)) > 1e-9 {
+		t.Errorf("CleanToDirty() with a face value of 1000 = %v, want %v", dirty, 995+10*tr25Accrued)
+	}
+	if dirty := CleanToDirty(tr25CleanPrice, tr25Coupon, 100, prevCoupon, prevCoupon, nextCoupon, ActualActual); dirty != tr25CleanPrice {
+		t.Errorf("CleanToDirty() on the coupon date = %v, want %v", dirty, tr25CleanPrice)
+	}
+
+	// the conversion matches the bond completed from the clean price
+	b := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if dirty := CleanToDirty(b.CleanPrice, b.Coupon, b.FacePrice, b.SettlementDate, b.PrevCouponDate, b.NextCouponDate, ActualActual); math.Abs(dirty-b.DirtyPrice) > 1e-9 {
+		t.Errorf("CleanToDirty() = %v, want the completed dirty price %v", dirty, b.DirtyPrice)
+	}
+}
+
+func TestContinuousCompounding(t *testing.T) {
+	// ln(1.05), 2 ln(1.02) and e^0.05 - 1
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "5% annual", got: ToContinuous(5, 1), want: 4.879016416943205},
+		{name: "4% semi-annual", got: ToContinuous(4, 2), want: 3.960525459235946},
+		{name: "5% continuous to annual", got: ToCompounded(5, 1), want: 5.127109637602412},
+		{name: "zero", got: ToContinuous(0, 2), want: 0},
+	}
+
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-12 {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	for _, freq := range []int{1, 2, 4, 12} {
+		for _, y := range []float64{-0.5, 0.01, 3.5, 4.25, 15} {
+			c := ToContinuous(y, freq)
+			if got := ToCompounded(c, freq); math.Abs(got-y) > 1e-12 {
+				t.Errorf("ToCompounded(ToContinuous(%v, %d)) = %v", y, freq, got)
+			}
+
+			// continuous compounding needs a lower rate for the same growth
+			if c >= y {
+				t.Errorf("ToContinuous(%v, %d) = %v, want less than %v", y, freq, c, y)
+			}
+		}
+	}
+
+	b := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if want := ToContinuous(b.YieldToMaturity, 2); b.YieldToMaturityContinuous != want {
+		t.Errorf("YieldToMaturityContinuous = %v, want %v", b.YieldToMaturityContinuous, want)
+	}
+}
+
+func TestSolverDamping(t *testing.T) {
+	// a ¼% 30 year deep discount gilt at 40 from a 50% guess, the first undamped step
+	// overshoots far below -200% where the discount factor is negative and the price NaN
+	const (
+		C, F, P = 0.25, 100.0, 40.0
+		n, m    = 2, 60
+		tn, tb  = 91, 182
+		guess   = 50.0
+	)
+
+	opts := SolverOptions{Tolerance: 0.001, MaxIterations: 1_000}
+
+	if _, err := DirtyPriceYieldToMaturityWithOptions(C, F, P, n, m, tn, tb, guess, opts); err == nil {
+		t.Fatalf("undamped DirtyPriceYieldToMaturityWithOptions() error = nil, want the solver to fail")
+	}
+
+	opts.Damped = true
+	opts.MaxStep = 5
+
+	result, err := DirtyPriceYieldToMaturityDetailed(C, F, P, n, m, tn, tb, guess, opts)
+	if err != nil {
+		t.Fatalf("damped DirtyPriceYieldToMaturityDetailed() error = %v", err)
+	}
+
+	if want := refYield(C, F, P, n, m, tn, tb); math.Abs(result.Yield-want) > 1e-3 {
+		t.Errorf("damped yield = %v, want %v", result.Yield, want)
+	}
+
+	// the first steps are clamped to MaxStep so it takes a few more iterations
+	if float64(result.Iterations) < (guess-result.Yield)/opts.MaxStep {
+		t.Errorf("damped solver took %d iterations, want at least one per %v%% step", result.Iterations, opts.MaxStep)
+	}
+}
+
+func TestSolverIterations(t *testing.T) {
+	opts := DefaultSolverOptions()
+
+	// the dirty price of a 4% gilt at a 4% yield solved from a guess of the yield itself
+	P := refDirtyPrice(4, 100, 0.04, 2, 20, 91, 182)
+
+	result, err := DirtyPriceYieldToMaturityDetailed(4, 100, P, 2, 20, 91, 182, 4, opts)
+	if err != nil {
+		t.Fatalf("DirtyPriceYieldToMaturityDetailed() error = %v", err)
+	}
+	if result.Iterations != 1 || result.Yield != 4 || math.Abs(result.Residual) > opts.Tolerance {
+		t.Errorf("result = %+v, want 4%% in 1 iteration with a residual within %v", result, opts.Tolerance)
+	}
+
+	// from a guess 1% away Newton-Raphson converges in a few iterations
+	result, err = DirtyPriceYieldToMaturityDetailed(4, 100, P, 2, 20, 91, 182, 5, opts)
+	if err != nil {
+		t.Fatalf("DirtyPriceYieldToMaturityDetailed() error = %v", err)
+	}
+	if result.Iterations < 2 || result.Iterations > 4 || math.Abs(result.Yield-4) > 1e-3 || math.Abs(result.Residual) > opts.Tolerance {
+		t.Errorf("result = %+v, want 4%% in 2 to 4 iterations", result)
+	}
+
+	// CompleteBond records the iterations of the solved yield, none when the prices are derived
+	b := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if b.SolverIterations < 1 || b.SolverIterations > 4 {
+		t.Errorf("SolverIterations = %d, want 1 to 4", b.SolverIterations)
+	}
+
+	b = NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.YieldToMaturity = 4
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if b.SolverIterations != 0 {
+		t.Errorf("SolverIterations = %d from a yield, want 0", b.SolverIterations)
+	}
+}
+
+func TestSolverOverflow(t *testing.T) {
+	opts := SolverOptions{Tolerance: 0.001, MaxIterations: 1_000}
+
+	tests := []struct {
+		name  string
+		guess float64
+	}{
+		// 1 + y/2 is almost zero so discounting 100 periods overflows to +Inf
+		{name: "discount factor overflow", guess: -199.9999},
+		// 1 + y/2 is negative so the fractional first period is NaN
+		{name: "negative discount factor", guess: -250},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DirtyPriceYieldToMaturityDetailed(4, 100, 100, 2, 100, 91, 182, tt.guess, opts)
+			if !errors.Is(err, ErrNumericalInstability) {
+				t.Fatalf("DirtyPriceYieldToMaturityDetailed() error = %v, want %v", err, ErrNumericalInstability)
+			}
+			if !isFinite(result.Yield) || !isFinite(result.Residual) {
+				t.Errorf("result = %+v, want no NaN or Inf", result)
+			}
+
+			y, err := DirtyPriceYieldToMaturity(4, 100, 100, 2, 100, 91, 182, tt.guess, opts.Tolerance, opts.MaxIterations)
+			if !errors.Is(err, ErrNumericalInstability) || y != 0 {
+				t.Errorf("DirtyPriceYieldToMaturity() = %v, %v, want 0, %v", y, err, ErrNumericalInstability)
+			}
+		})
+	}
+}
+
+func TestCompleteBondNegativeYield(t *testing.T) {
+	// the 3½% 2025 gilt is worth 103.5 dirty at a zero yield, 102.19 clean, so a clean price
+	// of 102.5 yields below zero
+	const cleanPrice = 102.5
+
+	// the short gilt's price barely moves with the yield, a tight tolerance pins the yield
+	opts := DefaultSolverOptions()
+	opts.Tolerance = 1e-10
+
+	b := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = cleanPrice
+	if err := CompleteBondWithOptions(b, opts); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	want := refYield(tr25Coupon, 100, cleanPrice+tr25Accrued, 2, tr25Periods, tr25ToNext, tr25PeriodDays)
+	if want >= 0 || math.Abs(b.YieldToMaturity-want) > 1e-6 {
+		t.Errorf("YieldToMaturity = %v, want %v below zero", b.YieldToMaturity, want)
+	}
+	if b.Duration <= 0 || b.DV01 <= 0 {
+		t.Errorf("Duration = %v, DV01 = %v, want positive risk at a negative yield", b.Duration, b.DV01)
+	}
+
+	// the negative yield prices back to the clean price
+	priced := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	priced.YieldToMaturity = b.YieldToMaturity
+	if err := CompleteBond(priced); err != nil {
+		t.Fatalf("CompleteBond() from a negative yield error = %v", err)
+	}
+	if math.Abs(priced.CleanPrice-b.CleanPrice) > 1e-9 {
+		t.Errorf("CleanPrice = %v from the yield, want %v", priced.CleanPrice, b.CleanPrice)
+	}
+
+	// a given yield below the solver bounds is still rejected
+	priced = NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	priced.YieldToMaturity = DefaultSolverOptions().MinYield - 1
+	if err := CompleteBond(priced); !errors.Is(err, ErrInvalidYieldToMaturity) {
+		t.Errorf("CompleteBond() error = %v, want %v", err, ErrInvalidYieldToMaturity)
+	}
+}
+
+func TestSeedStrategy(t *testing.T) {
+	settlement := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		coupon     float64
+		cleanPrice float64
+		maturity   time.Time
+	}{
+		{name: "deep discount", coupon: 0.25, cleanPrice: 40, maturity: time.Date(2061, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "premium", coupon: 6, cleanPrice: 130, maturity: time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "near par", coupon: 4.25, cleanPrice: 98, maturity: time.Date(2055, 1, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	complete := func(t *testing.T, coupon, cleanPrice float64, maturity time.Time, opts SolverOptions) *Bond {
+		t.Helper()
+
+		b := NewUKGiltWithMaturity("", settlement, coupon, maturity)
+		b.CleanPrice = cleanPrice
+		if err := CompleteBondWithOptions(b, opts); err != nil {
+			t.Fatalf("CompleteBondWithOptions() error = %v", err)
+		}
+
+		return b
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultSolverOptions()
+			opts.Tolerance = 1e-9
+
+			iterations := make(map[SeedStrategy]int)
+			var yield float64
+
+			for _, seed := range []SeedStrategy{SeedEstimate, SeedCoupon, SeedCurrentYield} {
+				opts.Seed = seed
+				b := complete(t, tt.coupon, tt.cleanPrice, tt.maturity, opts)
+
+				if seed == SeedEstimate {
+					yield = b.YieldToMaturity
+				} else if math.Abs(b.YieldToMaturity-yield) > 1e-8 {
+					t.Errorf("%s seed yield = %v, want %v", seed, b.YieldToMaturity, yield)
+				}
+
+				iterations[seed] = b.SolverIterations
+			}
+
+			// the seed is the starting point of the solver so the seeds take different paths
+			if iterations[SeedCoupon] == iterations[SeedEstimate] && iterations[SeedCurrentYield] == iterations[SeedEstimate] {
+				t.Errorf("iterations = %v, want the seeds to start from different yields", iterations)
+			}
+
+			// a guess of the yield itself overrides the seed and converges immediately
+			opts.Seed = SeedCoupon
+			opts.InitialGuess = yield
+			b := complete(t, tt.coupon, tt.cleanPrice, tt.maturity, opts)
+			if b.SolverIterations != 1 || b.YieldToMaturity != yield {
+				t.Errorf("guessed yield = %v in %d iterations, want %v in 1", b.YieldToMaturity, b.SolverIterations, yield)
+			}
+			for seed, n := range iterations {
+				if n <= b.SolverIterations {
+					t.Errorf("%s seed took %d iterations, want more than the guess", seed, n)
+				}
+			}
+		})
+	}
+}
+
+func TestCompleteBondFaceScale(t *testing.T) {
+	complete := func(t *testing.T, face, cleanPrice, ytm float64) *Bond {
+		t.Helper()
+
+		b := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+		b.FacePrice = face
+		b.CleanPrice = cleanPrice
+		b.YieldToMaturity = ytm
+		if err := CompleteBond(b); err != nil {
+			t.Fatalf("CompleteBond() error = %v", err)
+		}
+
+		return b
+	}
+
+	// prices are relative to the face value, £1 face prices are 100 times smaller
+	scaled := func(t *testing.T, name string, one, hundred float64) {
+		t.Helper()
+		if math.Abs(one*100-hundred) > 1e-12*math.Abs(hundred) {
+			t.Errorf("%s = %v at F=1 and %v at F=100, want exactly 100 times", name, one, hundred)
+		}
+	}
+
+	t.Run("price from yield", func(t *testing.T) {
+		one, hundred := complete(t, 1, 0, 4.25), complete(t, 100, 0, 4.25)
+
+		scaled(t, "CleanPrice", one.CleanPrice, hundred.CleanPrice)
+		scaled(t, "DirtyPrice", one.DirtyPrice, hundred.DirtyPrice)
+		scaled(t, "AccruedAmount", one.AccruedAmount, hundred.AccruedAmount)
+		scaled(t, "NextCouponAmount", one.NextCouponAmount, hundred.NextCouponAmount)
+		scaled(t, "DV01", one.DV01, hundred.DV01)
+
+		if hundred.AccruedAmount != tr25Accrued {
+			t.Errorf("AccruedAmount = %v at F=100, want %v", hundred.AccruedAmount, tr25Accrued)
+		}
+
+		// the relative measures are the same, the convexity's second difference of the prices
+		// differs in the rounding
+		if one.Duration != hundred.Duration || math.Abs(one.Convexity/hundred.Convexity-1) > 1e-6 || one.AverageLife != hundred.AverageLife {
+			t.Errorf("duration %v convexity %v average life %v at F=1, want %v %v %v", one.Duration, one.Convexity, one.AverageLife,
+				hundred.Duration, hundred.Convexity, hundred.AverageLife)
+		}
+	})
+
+	t.Run("yield from price", func(t *testing.T) {
+		opts := DefaultSolverOptions()
+		opts.Tolerance = 1e-10
+
+		one := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+		one.FacePrice = 1
+		one.CleanPrice = tr25CleanPrice / 100
+		if err := CompleteBondWithOptions(one, opts); err != nil {
+			t.Fatalf("CompleteBondWithOptions() error = %v", err)
+		}
+
+		hundred := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+		hundred.CleanPrice = tr25CleanPrice
+		if err := CompleteBondWithOptions(hundred, opts); err != nil {
+			t.Fatalf("CompleteBondWithOptions() error = %v", err)
+		}
+
+		scaled(t, "DirtyPrice", one.DirtyPrice, hundred.DirtyPrice)
+		if math.Abs(one.YieldToMaturity-hundred.YieldToMaturity) > 1e-8 {
+			t.Errorf("YieldToMaturity = %v at F=1, want %v", one.YieldToMaturity, hundred.YieldToMaturity)
+		}
+	})
+}
+
+func TestAccruedInterest(t *testing.T) {
+	// real gilts settling on 7 March 2025, the DMO's accrued interest is the coupon for the
+	// period times the actual days accrued over the actual days in the period, worked by hand
+	// and rounded to 6 decimal places
+	tests := []struct {
+		name       string
+		coupon     float64
+		face       float64
+		days       int
+		periodDays int
+		freq       int
+		want       float64
+	}{
+		// 7 Dec 2024 to 7 Jun 2025
+		{name: "4¼% Treasury Gilt 2032", coupon: 4.25, face: 100, days: 90, periodDays: 182, freq: 2, want: 1.050824},
+		{name: "4¾% Treasury Gilt 2030", coupon: 4.75, face: 100, days: 90, periodDays: 182, freq: 2, want: 1.174451},
+		// 22 Jan 2025 to 22 Jul 2025
+		{name: "3¼% Treasury Gilt 2044", coupon: 3.25, face: 100, days: 44, periodDays: 181, freq: 2, want: 0.395028},
+		// 22 Oct 2024 to 22 Apr 2025
+		{name: "3½% Treasury Gilt 2025", coupon: 3.5, face: 100, days: 136, periodDays: 182, freq: 2, want: 1.307692},
+		{name: "on the coupon date", coupon: 3.5, face: 100, days: 0, periodDays: 182, freq: 2, want: 0},
+		{name: "£1,000 nominal", coupon: 4.25, face: 1_000, days: 90, periodDays: 182, freq: 2, want: 10.508242},
+		// 136 days of a 365 day annual period
+		{name: "annual", coupon: 5, face: 100, days: 136, periodDays: 365, freq: 1, want: 1.863014},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AccruedInterest(tt.coupon, tt.face, tt.days, tt.periodDays, tt.freq)
+			if math.Round(got*1e6)/1e6 != tt.want {
+				t.Errorf("AccruedInterest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// CompleteBond uses the same accrued interest
+	b := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if want := AccruedInterest(b.Coupon, b.FacePrice, b.AccruedDays, b.CouponPeriodDays, 2); b.AccruedAmount != want {
+		t.Errorf("AccruedAmount = %v, want %v", b.AccruedAmount, want)
+	}
+}
+
+func TestStripPriceYield(t *testing.T) {
+	// a strip of the 7 December 2030 redemption settling on 7 March 2025, 92 days to the
+	// 7 June 2025 quasi-coupon date of a 182 day period and 11 more periods to maturity.
+	// At 4.5% the DMO strip formula prices it at 100 / 1.0225^(92/182 + 11) = 77.413867.
+	const (
+		yield   = 4.5
+		price   = 77.413867
+		periods = 12
+		toNext  = 92
+		days    = 182
+	)
+
+	if got := StripPrice(yield, 100, 2, periods, toNext, days); math.Abs(got-price) > 5e-7 {
+		t.Errorf("StripPrice() = %v, want %v", got, price)
+	}
+
+	got, err := StripYieldToMaturity(100, price, 2, periods, toNext, days)
+	if err != nil {
+		t.Fatalf("StripYieldToMaturity() error = %v", err)
+	}
+	if math.Abs(got-yield) > 1e-6 {
+		t.Errorf("StripYieldToMaturity() = %v, want %v", got, yield)
+	}
+
+	settlement := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	maturity := time.Date(2030, 12, 7, 0, 0, 0, 0, time.UTC)
+
+	b := NewUKGiltWithMaturity("", settlement, 0, maturity)
+	b.Strip = true
+	b.CleanPrice = price
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if math.Abs(b.YieldToMaturity-yield) > 1e-6 || b.CouponPeriods != periods || b.RemainingDays != toNext || b.CouponPeriodDays != days {
+		t.Errorf("strip yield %v with %d periods, %d of %d days, want %v with %d periods, %d of %d days",
+			b.YieldToMaturity, b.CouponPeriods, b.RemainingDays, b.CouponPeriodDays, yield, periods, toNext, days)
+	}
+
+	// a strip has no accrued interest so the clean and dirty prices are the same
+	b = NewUKGiltWithMaturity("", settlement, 0, maturity)
+	b.Strip = true
+	b.YieldToMaturity = yield
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if math.Abs(b.CleanPrice-price) > 5e-7 || b.DirtyPrice != b.CleanPrice || b.AccruedAmount != 0 {
+		t.Errorf("strip clean %v dirty %v accrued %v, want %v with no accrued", b.CleanPrice, b.DirtyPrice, b.AccruedAmount, price)
+	}
+}
+
+func TestRoundPrices(t *testing.T) {
+	// halves are exact in binary so they round to the even digit rather than away from zero
+	tests := []struct {
+		value    float64
+		decimals int
+		want     float64
+	}{
+		{value: 99.125, decimals: 2, want: 99.12},
+		{value: 99.375, decimals: 2, want: 99.38},
+		{value: 101.5, decimals: 0, want: 102},
+		{value: 100.5, decimals: 0, want: 100},
+		{value: 97.8549, decimals: 2, want: 97.85},
+		{value: 76.395028, decimals: 4, want: 76.395},
+	}
+
+	for _, tt := range tests {
+		if got := roundHalfEven(tt.value, tt.decimals); got != tt.want {
+			t.Errorf("roundHalfEven(%v, %d) = %v, want %v", tt.value, tt.decimals, got, tt.want)
+		}
+	}
+
+	// the yield of the 3½% 2025 gilt at 99.5 clean prices back to 99.5 when rounded to the
+	// 2 decimal places of the published price, but not at full precision
+	opts := DefaultSolverOptions()
+
+	solved := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	solved.CleanPrice = tr25CleanPrice
+	if err := CompleteBondWithOptions(solved, opts); err != nil {
+		t.Fatalf("CompleteBondWithOptions() error = %v", err)
+	}
+
+	price := func(round bool) *Bond {
+		opts := opts
+		opts.RoundPrices = round
+		opts.PriceDecimals = 2
+
+		b := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+		b.YieldToMaturity = solved.YieldToMaturity
+		if err := CompleteBondWithOptions(b, opts); err != nil {
+			t.Fatalf("CompleteBondWithOptions() error = %v", err)
+		}
+		return b
+	}
+
+	full, rounded := price(false), price(true)
+
+	if full.CleanPrice == tr25CleanPrice {
+		t.Fatalf("full precision CleanPrice = %v, want the solver's tolerance to leave it off 99.5", full.CleanPrice)
+	}
+	if rounded.CleanPrice != tr25CleanPrice {
+		t.Errorf("rounded CleanPrice = %v, want %v", rounded.CleanPrice, tr25CleanPrice)
+	}
+	if want := roundHalfEven(full.DirtyPrice, 2); rounded.DirtyPrice != want {
+		t.Errorf("rounded DirtyPrice = %v, want %v", rounded.DirtyPrice, want)
+	}
+
+	// the yield and risk are kept at full precision
+	if rounded.YieldToMaturity != full.YieldToMaturity || rounded.Duration != full.Duration || rounded.AccruedAmount != full.AccruedAmount {
+		t.Errorf("rounded yield %v duration %v accrued %v, want %v %v %v", rounded.YieldToMaturity, rounded.Duration,
+			rounded.AccruedAmount, full.YieldToMaturity, full.Duration, full.AccruedAmount)
+	}
+
+	// a given price is never rounded
+	opts.RoundPrices = true
+	opts.PriceDecimals = 2
+	given := NewUKGiltWithMaturity("", tr25Settlement, tr25Coupon, tr25Maturity)
+	given.CleanPrice = 99.503125
+	if err := CompleteBondWithOptions(given, opts); err != nil {
+		t.Fatalf("CompleteBondWithOptions() error = %v", err)
+	}
+	if given.CleanPrice != 99.503125 {
+		t.Errorf("given CleanPrice = %v, want it unrounded", given.CleanPrice)
+	}
+}
+
+func TestInferCouponDates(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	// the 4¾% 2030 gilt paying on 7 June and 7 December, the 0⅛% 2028 gilt on 31 January and 31 July
+	tr30, tg28 := date(2030, 12, 7), date(2028, 1, 31)
+
+	tests := []struct {
+		name       string
+		settlement time.Time
+		maturity   time.Time
+		wantPrev   time.Time
+		wantNext   time.Time
+	}{
+		{name: "start of year", settlement: date(2025, 1, 1), maturity: tr30, wantPrev: date(2024, 12, 7), wantNext: date(2025, 6, 7)},
+		{name: "mid period", settlement: date(2025, 3, 7), maturity: tr30, wantPrev: date(2024, 12, 7), wantNext: date(2025, 6, 7)},
+		{name: "day before coupon", settlement: date(2025, 6, 6), maturity: tr30, wantPrev: date(2024, 12, 7), wantNext: date(2025, 6, 7)},
+		// the coupon paid on the settlement date belongs to the seller
+		{name: "coupon date", settlement: date(2025, 6, 7), maturity: tr30, wantPrev: date(2025, 6, 7), wantNext: date(2025, 12, 7)},
+		{name: "day after coupon", settlement: date(2025, 6, 8), maturity: tr30, wantPrev: date(2025, 6, 7), wantNext: date(2025, 12, 7)},
+		{name: "end of year", settlement: date(2025, 12, 31), maturity: tr30, wantPrev: date(2025, 12, 7), wantNext: date(2026, 6, 7)},
+		{name: "final period", settlement: date(2030, 12, 6), maturity: tr30, wantPrev: date(2030, 6, 7), wantNext: tr30},
+		{name: "month end", settlement: date(2025, 3, 7), maturity: tg28, wantPrev: date(2025, 1, 31), wantNext: date(2025, 7, 31)},
+		{name: "month end across year", settlement: date(2025, 8, 15), maturity: tg28, wantPrev: date(2025, 7, 31), wantNext: date(2026, 1, 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, next := InferCouponDates(tt.settlement, tt.maturity)
+			if !prev.Equal(tt.wantPrev) || !next.Equal(tt.wantNext) {
+				t.Errorf("InferCouponDates() = %s, %s, want %s, %s", prev.Format(time.DateOnly), next.Format(time.DateOnly),
+					tt.wantPrev.Format(time.DateOnly), tt.wantNext.Format(time.DateOnly))
+			}
+
+			// CompleteBond infers the same coupon dates
+			b := NewUKGiltWithMaturity("test", tt.settlement, 4.75, tt.maturity)
+			b.CleanPrice = 100
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+			if !b.PrevCouponDate.Equal(prev) || !b.NextCouponDate.Equal(next) {
+				t.Errorf("CompleteBond() coupon dates = %s, %s, want %s, %s", b.PrevCouponDate.Format(time.DateOnly),
+					b.NextCouponDate.Format(time.DateOnly), prev.Format(time.DateOnly), next.Format(time.DateOnly))
+			}
+		})
+	}
+
+	// every settlement date of a year is in a six month period of the maturity day
+	for settlement := date(2025, 1, 1); settlement.Year() == 2025; settlement = settlement.AddDate(0, 0, 1) {
+		prev, next := InferCouponDates(settlement, tr30)
+		if settlement.Before(prev) || !settlement.Before(next) || prev.AddDate(0, 6, 0) != next || next.Day() != 7 {
+			t.Errorf("InferCouponDates(%s) = %s, %s, want the 7th either side six months apart",
+				settlement.Format(time.DateOnly), prev.Format(time.DateOnly), next.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestBondError(t *testing.T) {
+	tr25 := func(update func(b *Bond)) error {
+		b := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+		b.CleanPrice = tr25CleanPrice
+		update(b)
+		return CompleteBond(b)
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		want      error
+		wantField string
+		wantValue any
+		wantMsg   string
+	}{
+		{
+			name:      "coupon",
+			err:       tr25(func(b *Bond) { b.Coupon = 350 }),
+			want:      ErrInvalidCoupon,
+			wantField: "Coupon",
+			wantValue: 350.0,
+			wantMsg:   `invalid coupon: Coupon "350"`,
+		},
+		{
+			name:      "clean price",
+			err:       tr25(func(b *Bond) { b.CleanPrice = -99.5 }),
+			want:      ErrInvalidCleanPrice,
+			wantField: "CleanPrice",
+			wantValue: -99.5,
+			wantMsg:   `invalid clean price: CleanPrice "-99.5"`,
+		},
+		{
+			name:      "coupon frequency",
+			err:       tr25(func(b *Bond) { b.CouponFrequency = 5 }),
+			want:      ErrInvalidCouponFrequency,
+			wantField: "CouponFrequency",
+			wantValue: 5,
+		},
+		{
+			name:      "matured",
+			err:       tr25(func(b *Bond) { b.SettlementDate = tr25Maturity }),
+			want:      ErrNoRemainingCoupons,
+			wantField: "MaturityDate",
+			wantValue: tr25Maturity,
+			wantMsg:   ErrNoRemainingCoupons.Error() + `: MaturityDate "2025-10-22"`,
+		},
+		{
+			name:      "description",
+			err:       func() error { _, err := ParseCouponPercentage("Treasury Gilt 2044"); return err }(),
+			want:      ErrInvalidCoupon,
+			wantField: "Desc",
+			wantValue: "Treasury Gilt 2044",
+		},
+		{
+			name:      "day count",
+			err:       func() error { _, err := ParseDayCount("actual360"); return err }(),
+			want:      ErrInvalidDayCount,
+			wantField: "DayCountConvention",
+			wantValue: "actual360",
+			wantMsg:   ErrInvalidDayCount.Error() + `: DayCountConvention "actual360"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the category is still matched once the error is wrapped
+			err := fmt.Errorf("ISIN GB0000000000: %w", tt.err)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("errors.Is(%v, %v) = false, want true", err, tt.want)
+			}
+
+			var bondErr *BondError
+			if !errors.As(err, &bondErr) {
+				t.Fatalf("errors.As(%v) = false, want a BondError", err)
+			}
+			if bondErr.Field != tt.wantField || bondErr.Value != tt.wantValue {
+				t.Errorf("BondError = %s %v, want %s %v", bondErr.Field, bondErr.Value, tt.wantField, tt.wantValue)
+			}
+			if tt.wantMsg != "" && bondErr.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", bondErr.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestDateClamped(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	clamped := []struct {
+		year  int
+		month time.Month
+		day   int
+		want  time.Time
+	}{
+		{year: 2025, month: time.February, day: 29, want: date(2025, 2, 28)},
+		{year: 2024, month: time.February, day: 29, want: date(2024, 2, 29)},
+		{year: 2025, month: time.February, day: 31, want: date(2025, 2, 28)},
+		{year: 2025, month: time.April, day: 31, want: date(2025, 4, 30)},
+		{year: 2025, month: time.January, day: 31, want: date(2025, 1, 31)},
+	}
+	for _, tt := range clamped {
+		if got := DateClamped(tt.year, tt.month, tt.day, time.UTC); !got.Equal(tt.want) {
+			t.Errorf("DateClamped(%d, %s, %d) = %s, want %s", tt.year, tt.month, tt.day, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+
+	months := []struct {
+		from   time.Time
+		months int
+		day    int
+		want   time.Time
+	}{
+		{from: date(2025, 1, 31), months: 1, day: 31, want: date(2025, 2, 28)},
+		// the schedule returns to the 31st after a short month
+		{from: date(2025, 2, 28), months: 6, day: 31, want: date(2025, 8, 31)},
+		{from: date(2025, 8, 31), months: -6, day: 31, want: date(2025, 2, 28)},
+		{from: date(2024, 2, 29), months: 12, day: 29, want: date(2025, 2, 28)},
+		{from: date(2027, 2, 28), months: 12, day: 29, want: date(2028, 2, 29)},
+		{from: date(2025, 12, 31), months: 2, day: 31, want: date(2026, 2, 28)},
+	}
+	for _, tt := range months {
+		if got := AddMonthsClamped(tt.from, tt.months, tt.day); !got.Equal(tt.want) {
+			t.Errorf("AddMonthsClamped(%s, %d, %d) = %s, want %s", tt.from.Format(time.DateOnly), tt.months, tt.day,
+				got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+
+	// coupons on the 29 February maturity day are on 28 February in other years, and on the
+	// 31 January maturity day are on 30 April and 31 July when paid quarterly
+	coupons := []struct {
+		settlement time.Time
+		maturity   time.Time
+		freq       int
+		wantPrev   time.Time
+		wantNext   time.Time
+	}{
+		{settlement: date(2025, 3, 7), maturity: date(2028, 2, 29), freq: 2, wantPrev: date(2025, 2, 28), wantNext: date(2025, 8, 29)},
+		{settlement: date(2027, 12, 1), maturity: date(2028, 2, 29), freq: 2, wantPrev: date(2027, 8, 29), wantNext: date(2028, 2, 29)},
+		{settlement: date(2025, 3, 7), maturity: date(2028, 1, 31), freq: 4, wantPrev: date(2025, 1, 31), wantNext: date(2025, 4, 30)},
+		{settlement: date(2025, 5, 15), maturity: date(2028, 1, 31), freq: 4, wantPrev: date(2025, 4, 30), wantNext: date(2025, 7, 31)},
+	}
+	for _, tt := range coupons {
+		prev, next := InferCouponDatesWithFrequency(tt.settlement, tt.maturity, tt.freq)
+		if !prev.Equal(tt.wantPrev) || !next.Equal(tt.wantNext) {
+			t.Errorf("InferCouponDatesWithFrequency(%s, %s, %d) = %s, %s, want %s, %s",
+				tt.settlement.Format(time.DateOnly), tt.maturity.Format(time.DateOnly), tt.freq,
+				prev.Format(time.DateOnly), next.Format(time.DateOnly), tt.wantPrev.Format(time.DateOnly), tt.wantNext.Format(time.DateOnly))
+		}
+	}
+
+	maturities := []struct {
+		settlement time.Time
+		maturity   time.Time
+		wantYears  int
+		wantDays   int
+	}{
+		// 1 March 2027 to 29 February 2028
+		{settlement: date(2025, 3, 1), maturity: date(2028, 2, 29), wantYears: 2, wantDays: 365},
+		{settlement: date(2025, 2, 28), maturity: date(2028, 2, 29), wantYears: 3, wantDays: 1},
+		// 29 February settles on 28 February in a non-leap year
+		{settlement: date(2024, 2, 29), maturity: date(2027, 2, 28), wantYears: 3, wantDays: 0},
+	}
+	for _, tt := range maturities {
+		years, days, err := MaturityYears(tt.settlement, tt.maturity)
+		if err != nil || years != tt.wantYears || days != tt.wantDays {
+			t.Errorf("MaturityYears(%s, %s) = %d, %d, %v, want %d, %d", tt.settlement.Format(time.DateOnly),
+				tt.maturity.Format(time.DateOnly), years, days, err, tt.wantYears, tt.wantDays)
+		}
+	}
+}
+
+func TestMaturityYearFraction(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		settlement time.Time
+		maturity   time.Time
+		want       float64
+	}{
+		// 182 days from 1 September 2027 in the 366 days to 1 September 2028
+		{name: "leap day", settlement: date(2025, 9, 1), maturity: date(2028, 3, 1), want: 2 + 182.0/366},
+		// 181 days from 1 September 2026 in the 365 days to 1 September 2027
+		{name: "no leap day", settlement: date(2025, 9, 1), maturity: date(2027, 3, 1), want: 1 + 181.0/365},
+		// the leap day is before the part year
+		{name: "leap day in the full years", settlement: date(2023, 9, 1), maturity: date(2025, 3, 1), want: 1 + 181.0/365},
+		{name: "whole years", settlement: tr25Settlement, maturity: date(2028, 3, 7), want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MaturityYearFraction(tt.settlement, tt.maturity)
+			if err != nil {
+				t.Fatalf("MaturityYearFraction() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("MaturityYearFraction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the naive fraction of the days over 365 overstates the part year spanning the leap day
+	years, days, _ := MaturityYears(date(2025, 9, 1), date(2028, 3, 1))
+	if naive := float64(years) + float64(days)/365; math.Abs(naive-(2+182.0/366)) < 1e-3 {
+		t.Errorf("naive fraction = %v, want it to differ from the leap year fraction", naive)
+	}
+
+	if _, err := MaturityYearFraction(tr25Maturity, tr25Settlement); !errors.Is(err, ErrMaturityDateBeforeSettlement) {
+		t.Errorf("MaturityYearFraction() after maturity error = %v, want %v", err, ErrMaturityDateBeforeSettlement)
+	}
+}
+
+func TestAccrualRule(t *testing.T) {
+	// the 3½% 2025 gilt's 182 day coupon period from 22 October 2024 to 22 April 2025
+	prevCoupon := time.Date(2024, 10, 22, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		rule        string
+		settlement  time.Time
+		wantDays    int
+		wantAccrued float64
+	}{
+		// the previous coupon date is included and the settlement date excluded
+		{name: "exclusive day after coupon", rule: "exclusive", settlement: prevCoupon.AddDate(0, 0, 1), wantDays: 1, wantAccrued: 1.75 * 1 / 182},
+		{name: "inclusive day after coupon", rule: "inclusive", settlement: prevCoupon.AddDate(0, 0, 1), wantDays: 2, wantAccrued: 1.75 * 2 / 182},
+		{name: "exclusive coupon date", rule: "exclusive", settlement: prevCoupon, wantDays: 0, wantAccrued: 0},
+		{name: "inclusive coupon date", rule: "inclusive", settlement: prevCoupon, wantDays: 1, wantAccrued: 1.75 * 1 / 182},
+		// the UK gilt convention is the default
+		{name: "default", rule: "", settlement: prevCoupon.AddDate(0, 0, 1), wantDays: 1, wantAccrued: 1.75 * 1 / 182},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseAccrualRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseAccrualRule(%q) error = %v", tt.rule, err)
+			}
+
+			b := NewUKGiltWithMaturity("test", tt.settlement, tr25Coupon, tr25Maturity)
+			b.CleanPrice = tr25CleanPrice
+			b.AccrualRule = rule
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+
+			if !b.PrevCouponDate.Equal(prevCoupon) || b.CouponPeriodDays != 182 {
+				t.Fatalf("coupon period = %s for %d days, want %s for 182 days", b.PrevCouponDate.Format(time.DateOnly), b.CouponPeriodDays, prevCoupon.Format(time.DateOnly))
+			}
+			if b.AccruedDays != tt.wantDays || math.Abs(b.AccruedAmount-tt.wantAccrued) > 1e-12 {
+				t.Errorf("AccruedDays = %d, AccruedAmount = %v, want %d, %v", b.AccruedDays, b.AccruedAmount, tt.wantDays, tt.wantAccrued)
+			}
+
+			// the rule applies to a given schedule in the same way
+			s := NewUKGiltWithMaturity("test", tt.settlement, tr25Coupon, tr25Maturity)
+			s.CleanPrice = tr25CleanPrice
+			s.AccrualRule = rule
+			if err := CompleteBondWithSchedule(s, b.CouponPeriods, b.RemainingDays, b.CouponPeriodDays, DefaultSolverOptions()); err != nil {
+				t.Fatalf("CompleteBondWithSchedule() error = %v", err)
+			}
+			if s.AccruedDays != tt.wantDays {
+				t.Errorf("schedule AccruedDays = %d, want %d", s.AccruedDays, tt.wantDays)
+			}
+		})
+	}
+
+	if _, err := ParseAccrualRule("both"); !errors.Is(err, ErrInvalidAccrualRule) {
+		t.Errorf("ParseAccrualRule(both) error = %v, want %v", err, ErrInvalidAccrualRule)
+	}
+}
+
+func TestCheckGiltRef(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	// the 4¾% Treasury Gilt 2030 pays coupons on 7 June and 7 December
+	tests := []struct {
+		name     string
+		isin     string
+		maturity time.Time
+		wantErr  bool
+	}{
+		{name: "reference maturity", isin: "GB00B24FF097", maturity: date(2030, 12, 7)},
+		{name: "coupon date", isin: "GB00B24FF097", maturity: date(2030, 6, 7)},
+		{name: "wrong day", isin: "GB00B24FF097", maturity: date(2030, 12, 8), wantErr: true},
+		// the day and month swapped by a corrupted date
+		{name: "swapped day and month", isin: "GB00B24FF097", maturity: date(2030, 7, 12), wantErr: true},
+		{name: "not a coupon month", isin: "GB00B24FF097", maturity: date(2030, 9, 7), wantErr: true},
+		{name: "no reference data", isin: "GB00B0000000", maturity: date(2030, 12, 8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewUKGiltWithMaturity("test", tr25Settlement, 4.75, tt.maturity)
+			b.ISIN = tt.isin
+			b.CleanPrice = 101.23
+
+			err := CheckGiltRef(b)
+			if tt.wantErr != errors.Is(err, ErrInvalidMaturityDate) {
+				t.Fatalf("CheckGiltRef() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			// CompleteBond rejects the bond before pricing it
+			if err := CompleteBond(b); tt.wantErr != errors.Is(err, ErrInvalidMaturityDate) {
+				t.Errorf("CompleteBond() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	b := NewUKGiltWithMaturity("test", tr25Settlement, 4.75, date(2030, 12, 8))
+	b.ISIN = "GB00B24FF097"
+	want := "invalid maturity date: 2030-12-08 is not a coupon date of GB00B24FF097 maturing 2030-12-07"
+	if err := CheckGiltRef(b); err == nil || err.Error() != want {
+		t.Errorf("CheckGiltRef() error = %v, want %q", err, want)
+	}
+}
+
+func TestForwardYield(t *testing.T) {
+	b := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	// the 22 April 2025 coupon is paid before settling on 7 May 2025, 15 days into the
+	// 183 day final period with 168 days to the redemption and last coupon of 101.75
+	afterDirty := 99.8 + 1.75*15/183
+	afterYield := 2 * (math.Pow(101.75/afterDirty, 183.0/168) - 1) * 100
+
+	tests := []struct {
+		name       string
+		settlement time.Time
+		cleanPrice float64
+		want       float64
+	}{
+		// 15 days before the 22 April 2025 coupon, 167 days accrued of the 182 day period
+		{name: "before coupon", settlement: time.Date(2025, 4, 7, 0, 0, 0, 0, time.UTC), cleanPrice: 99.6,
+			want: refYield(3.5, 100, 99.6+1.75*167/182, 2, 2, 15, 182)},
+		{name: "after coupon", settlement: time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC), cleanPrice: 99.8, want: afterYield},
+		{name: "settlement date", settlement: tr25Settlement, cleanPrice: tr25CleanPrice, want: b.YieldToMaturity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ForwardYield(b, tt.settlement, tt.cleanPrice)
+			if err != nil {
+				t.Fatalf("ForwardYield() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("ForwardYield() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the bond is unchanged
+	if !b.SettlementDate.Equal(tr25Settlement) || b.CleanPrice != tr25CleanPrice {
+		t.Errorf("bond = %s at %v, want it unchanged", b.SettlementDate.Format(time.DateOnly), b.CleanPrice)
+	}
+
+	if _, err := ForwardYield(b, tr25Settlement.AddDate(0, 0, -1), tr25CleanPrice); !errors.Is(err, ErrInvalidSettlementDate) {
+		t.Errorf("ForwardYield() before settlement error = %v, want %v", err, ErrInvalidSettlementDate)
+	}
+}
+
+func TestRevalue(t *testing.T) {
+	b := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	// valuing on the settlement date at the solved yield reprices the bond
+	v, err := Revalue(b, tr25Settlement, 0)
+	if err != nil {
+		t.Fatalf("Revalue() error = %v", err)
+	}
+	if math.Abs(v.CleanPrice-b.CleanPrice) > 1e-6 || math.Abs(v.DirtyPrice-b.DirtyPrice) > 1e-6 || v.AccruedAmount != b.AccruedAmount {
+		t.Errorf("Revalue() = clean %v dirty %v accrued %v, want %v %v %v",
+			v.CleanPrice, v.DirtyPrice, v.AccruedAmount, b.CleanPrice, b.DirtyPrice, b.AccruedAmount)
+	}
+	if v.YieldToMaturity != b.YieldToMaturity || v.AccruedDays != b.AccruedDays || v.RemainingDays != b.RemainingDays ||
+		v.CouponPeriods != b.CouponPeriods || !v.ValuationDate.Equal(tr25Settlement) {
+		t.Errorf("Revalue() = yield %v, %d accrued days, %d remaining days, %d periods, valued %s, want %v, %d, %d, %d, %s",
+			v.YieldToMaturity, v.AccruedDays, v.RemainingDays, v.CouponPeriods, v.ValuationDate.Format(time.DateOnly),
+			b.YieldToMaturity, b.AccruedDays, b.RemainingDays, b.CouponPeriods, tr25Settlement.Format(time.DateOnly))
+	}
+
+	// valuing later at a yield matches a bond settling on the valuation date, except the
+	// settlement date is kept
+	asOf := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	v, err = Revalue(b, asOf, 4.5)
+	if err != nil {
+		t.Fatalf("Revalue() error = %v", err)
+	}
+
+	want := NewUKGiltWithMaturity("test", asOf, tr25Coupon, tr25Maturity)
+	want.YieldToMaturity = 4.5
+	if err := CompleteBond(want); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if v.CleanPrice != want.CleanPrice || v.AccruedAmount != want.AccruedAmount || !v.PrevCouponDate.Equal(want.PrevCouponDate) {
+		t.Errorf("Revalue() = clean %v accrued %v from %s, want %v %v from %s", v.CleanPrice, v.AccruedAmount,
+			v.PrevCouponDate.Format(time.DateOnly), want.CleanPrice, want.AccruedAmount, want.PrevCouponDate.Format(time.DateOnly))
+	}
+	if !v.SettlementDate.Equal(tr25Settlement) || !v.ValuationDate.Equal(asOf) {
+		t.Errorf("Revalue() settled %s valued %s, want %s and %s", v.SettlementDate.Format(time.DateOnly),
+			v.ValuationDate.Format(time.DateOnly), tr25Settlement.Format(time.DateOnly), asOf.Format(time.DateOnly))
+	}
+
+	// the bond is unchanged
+	if b.CleanPrice != tr25CleanPrice || !b.ValuationDate.IsZero() {
+		t.Errorf("bond clean price %v valued %s, want it unchanged", b.CleanPrice, b.ValuationDate.Format(time.DateOnly))
+	}
+}
+
+func TestWhenIssued(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	// a 4½% 2035 gilt issued on 12 March 2025 with a short first coupon on 7 September 2025
+	auction := func(settlement time.Time) *Bond {
+		b := NewUKGiltWithMaturity("test", settlement, 4.5, date(2035, 3, 7))
+		b.IssueDate = date(2025, 3, 12)
+		b.FirstCouponDate = date(2025, 9, 7)
+		return b
+	}
+
+	// traded when-issued two days before the issue date, from a price and from a yield
+	fromPrice := auction(date(2025, 3, 10))
+	fromPrice.CleanPrice = 99.25
+	fromYield := auction(date(2025, 3, 10))
+	fromYield.YieldToMaturity = 4.6
+
+	for _, b := range []*Bond{fromPrice, fromYield} {
+		if err := CompleteBond(b); err != nil {
+			t.Fatalf("CompleteBond() error = %v", err)
+		}
+		if b.AccruedDays != 0 || b.AccruedAmount != 0 || b.DirtyPrice != b.CleanPrice || b.CleanPrice <= 0 {
+			t.Errorf("when-issued = %d accrued days, accrued %v, clean %v, dirty %v, want no accrued and clean == dirty",
+				b.AccruedDays, b.AccruedAmount, b.CleanPrice, b.DirtyPrice)
+		}
+	}
+
+	// interest accrues from the issue date once issued
+	issued := auction(date(2025, 3, 20))
+	issued.CleanPrice = 99.25
+	if err := CompleteBond(issued); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if issued.AccruedDays != 8 || issued.AccruedAmount <= 0 || issued.DirtyPrice <= issued.CleanPrice {
+		t.Errorf("issued = %d accrued days, accrued %v, want 8 days from the issue date", issued.AccruedDays, issued.AccruedAmount)
+	}
+
+	// without the first coupon date the accrual can't start from the issue date
+	b := auction(date(2025, 3, 10))
+	b.FirstCouponDate = time.Time{}
+	b.CleanPrice = 99.25
+	if err := CompleteBond(b); !errors.Is(err, ErrInvalidSettlementDate) {
+		t.Errorf("CompleteBond() without a first coupon date error = %v, want %v", err, ErrInvalidSettlementDate)
+	}
+}
+
+func TestFinalCoupons(t *testing.T) {
+	// the 3½% 2025 gilt at 4.5% with 46 days to the next coupon of a 182 day period, each cash
+	// flow discounted by 1.0225 per period from the next coupon date
+	tests := []struct {
+		name           string
+		periods        int
+		wantDirty      float64
+		wantClean      float64
+		wantDerivative float64
+	}{
+		// 101.75 / 1.0225^(46/182)
+		{name: "one coupon", periods: 1, wantDirty: 101.17938636324017, wantClean: 99.87169405554786, wantDerivative: -12.505042512450757},
+		// (1.75 + 101.75/1.0225) / 1.0225^(46/182)
+		{name: "two coupons", periods: 2, wantDirty: 100.69313110477077, wantClean: 99.38543879707846, wantDerivative: -60.83269308350765},
+		// settled on or after the last coupon date
+		{name: "no coupons", periods: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirty := DirtyPrice(3.5, 4.5, 100, 2, tt.periods, 46, 182)
+			clean := CleanPrice(3.5, 4.5, 100, 2, tt.periods, 46, 182)
+			derivative := DirtyPriceDerivative(3.5, 100, 0.045, 2, tt.periods, 46, 182)
+
+			if math.Abs(dirty-tt.wantDirty) > 1e-9 || math.Abs(clean-tt.wantClean) > 1e-9 {
+				t.Errorf("DirtyPrice() = %v, CleanPrice() = %v, want %v, %v", dirty, clean, tt.wantDirty, tt.wantClean)
+			}
+			if math.Abs(derivative-tt.wantDerivative) > 1e-9 {
+				t.Errorf("DirtyPriceDerivative() = %v, want %v", derivative, tt.wantDerivative)
+			}
+		})
+	}
+
+	// settling on 7 May 2025 after the April coupon, 168 days to the final coupon of the
+	// 183 day period, 101.75 / 1.0225^(168/183)
+	b := NewUKGiltWithMaturity("test", time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC), tr25Coupon, tr25Maturity)
+	b.YieldToMaturity = 4.5
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if b.CouponPeriods != 1 || math.Abs(b.DirtyPrice-99.69265824514223) > 1e-9 {
+		t.Errorf("CompleteBond() = %d periods, dirty %v, want 1 period, dirty 99.69265824514223", b.CouponPeriods, b.DirtyPrice)
+	}
+
+	// the yield is solved back from the price of the final period
+	b.DirtyPrice, b.YieldToMaturity = 0, 0
+	opts := DefaultSolverOptions()
+	opts.Tolerance = 1e-10
+	if err := CompleteBondWithOptions(b, opts); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if math.Abs(b.YieldToMaturity-4.5) > 1e-6 {
+		t.Errorf("YieldToMaturity = %v, want 4.5", b.YieldToMaturity)
+	}
+}
+
+func TestHorizonReturn(t *testing.T) {
+	// the 4¾% 2030 gilt with 12 coupons remaining, priced from the yield so the price is
+	// exact rather than within the solver tolerance
+	maturity := time.Date(2030, 12, 7, 0, 0, 0, 0, time.UTC)
+	b := NewUKGiltWithMaturity("test", tr25Settlement, 4.75, maturity)
+	b.YieldToMaturity = 4.5
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		horizon time.Time
+	}{
+		{name: "maturity", horizon: maturity},
+		{name: "after maturity", horizon: maturity.AddDate(1, 0, 0)},
+		// sold at the same yield the bond's value grows at the yield
+		{name: "coupon date", horizon: time.Date(2027, 6, 7, 0, 0, 0, 0, time.UTC)},
+		{name: "mid period", horizon: time.Date(2028, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// reinvested at the yield to maturity the return is the yield to maturity
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HorizonReturn(b, tt.horizon, b.YieldToMaturity)
+			if err != nil {
+				t.Fatalf("HorizonReturn() error = %v", err)
+			}
+			if math.Abs(got-b.YieldToMaturity) > 1e-9 {
+				t.Errorf("HorizonReturn() = %v, want the yield to maturity %v", got, b.YieldToMaturity)
+			}
+		})
+	}
+
+	// reinvesting the coupons at a lower rate returns less than the yield
+	if got, err := HorizonReturn(b, maturity, 1); err != nil || got >= b.YieldToMaturity {
+		t.Errorf("HorizonReturn() reinvested at 1%% = %v, %v, want less than %v", got, err, b.YieldToMaturity)
+	}
+
+	if _, err := HorizonReturn(b, tr25Settlement, b.YieldToMaturity); !errors.Is(err, ErrInvalidHorizon) {
+		t.Errorf("HorizonReturn() on the settlement date error = %v, want %v", err, ErrInvalidHorizon)
+	}
+}
+
+func TestSettlementCost(t *testing.T) {
+	b := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	// £10,000 nominal is 100 of the £100 units
+	s := SettlementBreakdown(b, 10_000)
+
+	if s.Nominal != 10_000 || math.Abs(s.CleanConsideration-9_950) > 1e-9 || math.Abs(s.AccruedInterest-100*tr25Accrued) > 1e-9 {
+		t.Errorf("SettlementBreakdown() = %+v, want 9950 clean and %v accrued", s, 100*tr25Accrued)
+	}
+
+	// the clean consideration plus the accrued interest is the dirty price of the nominal
+	if total := 10_000 / 100 * b.DirtyPrice; math.Abs(s.CleanConsideration+s.AccruedInterest-total) > 1e-9 || math.Abs(s.Total-total) > 1e-9 {
+		t.Errorf("SettlementBreakdown() = %v + %v = %v, want %v", s.CleanConsideration, s.AccruedInterest, s.Total, total)
+	}
+
+	if got := SettlementCost(b, 10_000); got != s.Total {
+		t.Errorf("SettlementCost() = %v, want %v", got, s.Total)
+	}
+}
+
+func TestWeightedAverageLife(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   float64
+		maturity time.Time
+		want     float64
+	}{
+		// 229 days from 7 March 2025 to 22 October 2025
+		{name: "3½% 2025", coupon: tr25Coupon, maturity: tr25Maturity, want: 229.0 / 365},
+		// 5 years to 7 March 2030 then 275 days to 7 December 2030
+		{name: "4¾% 2030", coupon: 4.75, maturity: time.Date(2030, 12, 7, 0, 0, 0, 0, time.UTC), want: 5 + 275.0/365},
+		{name: "4¼% 2032", coupon: 4.25, maturity: time.Date(2032, 3, 7, 0, 0, 0, 0, time.UTC), want: 7},
+	}
+
+	// a bullet bond repays all the principal at maturity so its average life is its maturity
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewUKGiltWithMaturity("test", tr25Settlement, tt.coupon, tt.maturity)
+			b.CleanPrice = 100
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+
+			if got := WeightedAverageLife(b); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("WeightedAverageLife() = %v, want %v", got, tt.want)
+			}
+			if math.Abs(b.AverageLife-tt.want) > 1e-12 {
+				t.Errorf("AverageLife = %v, want %v", b.AverageLife, tt.want)
+			}
+		})
+	}
+
+	// no principal is left to repay after maturity
+	matured := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Settlement.AddDate(0, -1, 0))
+	if got := WeightedAverageLife(matured); got != 0 {
+		t.Errorf("WeightedAverageLife() of a matured bond = %v, want 0", got)
+	}
+}
+
+func TestNextCouponAmount(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		coupon      float64
+		issue       time.Time
+		firstCoupon time.Time
+		want        float64
+	}{
+		// half the annual coupon per £100
+		{name: "3½%", coupon: 3.5, want: 1.75},
+		{name: "4¼%", coupon: 4.25, want: 2.125},
+		// the 184 day regular period from 7 March to 7 September 2025, a short first period of
+		// 179 days from a 12 March issue and a long first period of 230 days from a 20 January issue
+		{name: "short first period", coupon: 4.5, issue: date(2025, 3, 12), firstCoupon: date(2025, 9, 7), want: 2.25 * 179 / 184},
+		{name: "long first period", coupon: 4.5, issue: date(2025, 1, 20), firstCoupon: date(2025, 9, 7), want: 2.25 * 230 / 184},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewUKGiltWithMaturity("test", date(2025, 3, 14), tt.coupon, date(2035, 9, 7))
+			b.IssueDate = tt.issue
+			b.FirstCouponDate = tt.firstCoupon
+			b.CleanPrice = 100
+			if err := CompleteBond(b); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+
+			if got := NextCouponAmount(b); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("NextCouponAmount() = %v, want %v", got, tt.want)
+			}
+			if math.Abs(b.NextCouponAmount-tt.want) > 1e-12 {
+				t.Errorf("NextCouponAmount field = %v, want %v", b.NextCouponAmount, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeriodProgress(t *testing.T) {
+	b := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.CleanPrice = tr25CleanPrice
+
+	if days, elapsed := b.PeriodProgress(); days != 0 || elapsed != 0 {
+		t.Errorf("PeriodProgress() before completing = %d, %v, want 0, 0", days, elapsed)
+	}
+
+	if err := CompleteBond(b); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+
+	// 46 days to the next coupon and 136 of the 182 days elapsed
+	if days, elapsed := b.PeriodProgress(); days != tr25ToNext || math.Abs(elapsed-136.0/182) > 1e-12 {
+		t.Errorf("PeriodProgress() = %d, %v, want %d, %v", days, elapsed, tr25ToNext, 136.0/182)
+	}
+
+	// every day of the 22 October 2024 to 22 April 2025 period, under either accrual rule
+	prevCoupon := time.Date(2024, 10, 22, 0, 0, 0, 0, time.UTC)
+	for _, rule := range []AccrualRule{SettlementExclusive, SettlementInclusive} {
+		for d := range 182 {
+			c := NewUKGiltWithMaturity("test", prevCoupon.AddDate(0, 0, d), tr25Coupon, tr25Maturity)
+			c.CleanPrice = tr25CleanPrice
+			c.AccrualRule = rule
+			if err := CompleteBond(c); err != nil {
+				t.Fatalf("CompleteBond() error = %v", err)
+			}
+
+			days, elapsed := c.PeriodProgress()
+			remaining := float64(days) / float64(c.CouponPeriodDays)
+			if days != 182-d || math.Abs(elapsed+remaining-1) > 1e-12 {
+				t.Errorf("%s rule %d days after the coupon = %d days, %v elapsed + %v remaining, want %d days summing to 1",
+					rule, d, days, elapsed, remaining, 182-d)
+			}
+		}
+	}
+}
+
+func TestNewBond(t *testing.T) {
+	gilt := NewUKGilt("DMO", tr25Settlement)
+	if gilt.Type != UKGilt || gilt.FacePrice != DefaultFacePrice || gilt.Source != "DMO" || !gilt.SettlementDate.Equal(tr25Settlement) {
+		t.Errorf("NewUKGilt() = %s %v %s %s, want %s %v DMO %s", gilt.Type, gilt.FacePrice, gilt.Source,
+			gilt.SettlementDate.Format(time.DateOnly), UKGilt, DefaultFacePrice, tr25Settlement.Format(time.DateOnly))
+	}
+
+	bond := NewBond(UKGilt, 1000, "test", tr25Settlement)
+	if bond.Type != UKGilt || bond.FacePrice != 1000 || bond.Source != "test" || !bond.SettlementDate.Equal(tr25Settlement) {
+		t.Errorf("NewBond() = %s %v %s %s, want %s 1000 test %s", bond.Type, bond.FacePrice, bond.Source,
+			bond.SettlementDate.Format(time.DateOnly), UKGilt, tr25Settlement.Format(time.DateOnly))
+	}
+
+	// the same price per face value is the same yield
+	gilt.Coupon, gilt.MaturityDate, gilt.CleanPrice = tr25Coupon, tr25Maturity, tr25CleanPrice
+	bond.Coupon, bond.MaturityDate, bond.CleanPrice = tr25Coupon, tr25Maturity, tr25CleanPrice*10
+	for _, b := range []*Bond{gilt, bond} {
+		if err := CompleteBond(b); err != nil {
+			t.Fatalf("CompleteBond() error = %v", err)
+		}
+	}
+	if math.Abs(bond.YieldToMaturity-gilt.YieldToMaturity) > 1e-9 || math.Abs(bond.AccruedAmount-10*tr25Accrued) > 1e-9 {
+		t.Errorf("£1000 face = yield %v accrued %v, want %v and %v", bond.YieldToMaturity, bond.AccruedAmount,
+			gilt.YieldToMaturity, 10*tr25Accrued)
+	}
+
+	// the face value is validated when the bond is completed
+	for _, face := range []float64{0, -100} {
+		b := NewBond(UKGilt, face, "test", tr25Settlement)
+		b.Coupon, b.MaturityDate, b.CleanPrice = tr25Coupon, tr25Maturity, tr25CleanPrice
+		if err := CompleteBond(b); !errors.Is(err, ErrInvalidFacePrice) {
+			t.Errorf("CompleteBond() with face %v error = %v, want %v", face, err, ErrInvalidFacePrice)
+		}
+	}
+}
+
+func TestCompleted(t *testing.T) {
+	base := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+	base.CleanPrice = tr25CleanPrice
+	original := *base
+
+	// scenarios priced from the same base bond
+	cheap, err := Completed(*base, DefaultSolverOptions())
+	if err != nil {
+		t.Fatalf("Completed() error = %v", err)
+	}
+	base.CleanPrice = 99
+	rich, err := Completed(*base, DefaultSolverOptions())
+	if err != nil {
+		t.Fatalf("Completed() error = %v", err)
+	}
+	base.CleanPrice = tr25CleanPrice
+
+	if *base != original {
+		t.Errorf("bond = %+v, want it unchanged %+v", *base, original)
+	}
+	if cheap.YieldToMaturity == 0 || cheap.DirtyPrice == 0 || rich.YieldToMaturity <= cheap.YieldToMaturity {
+		t.Errorf("Completed() yields = %v and %v, want both completed with a higher yield at 99", cheap.YieldToMaturity, rich.YieldToMaturity)
+	}
+
+	// the completed copy is the same as completing the bond in place
+	if err := CompleteBond(base); err != nil {
+		t.Fatalf("CompleteBond() error = %v", err)
+	}
+	if cheap != *base {
+		t.Errorf("Completed() = %+v, want %+v", cheap, *base)
+	}
+
+	// a clone shares nothing with the bond
+	clone := base.Clone()
+	clone.SettlementDate = clone.SettlementDate.AddDate(0, 0, 1)
+	clone.CleanPrice = 101
+	if !base.SettlementDate.Equal(tr25Settlement) || base.CleanPrice != tr25CleanPrice {
+		t.Errorf("bond = %s at %v after changing the clone, want it unchanged", base.SettlementDate.Format(time.DateOnly), base.CleanPrice)
+	}
+
+	if _, err := Completed(Bond{}, DefaultSolverOptions()); err == nil {
+		t.Errorf("Completed() of an empty bond error = nil, want an error")
+	}
+}
+
+func TestPricePath(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	b := NewUKGiltWithMaturity("test", tr25Settlement, tr25Coupon, tr25Maturity)
+	b.YieldToMaturity = 4.5
+
+	// across the 22 April 2025 coupon, from the 182 day period into the 183 day final period
+	points, err := PricePath(b, date(2025, 4, 18), date(2025, 4, 26))
+	if err != nil {
+		t.Fatalf("PricePath() error = %v", err)
+	}
+	if len(points) != 9 {
+		t.Fatalf("got %d points, want 9", len(points))
+	}
+
+	want := map[time.Time]struct {
+		dirty   float64
+		accrued float64
+	}{
+		// (1.75 + 101.75/1.0225) / 1.0225^(1/182)
+		date(2025, 4, 21): {dirty: 101.24862342717128, accrued: 1.75 * 181 / 182},
+		// 101.75 / 1.0225, the coupon is paid to the seller
+		date(2025, 4, 22): {dirty: 99.51100244498778, accrued: 0},
+		// 101.75 / 1.0225^(182/183)
+		date(2025, 4, 23): {dirty: 99.52310252703414, accrued: 1.75 * 1 / 183},
+	}
+
+	for i, p := range points {
+		if wantDate := date(2025, 4, 18+i); !p.Date.Equal(wantDate) {
+			t.Errorf("point %d date = %s, want %s", i, p.Date.Format(time.DateOnly), wantDate.Format(time.DateOnly))
+		}
+		if math.Abs(p.DirtyPrice-p.CleanPrice-p.AccruedAmount) > 1e-9 {
+			t.Errorf("%s dirty %v, want clean %v plus accrued %v", p.Date.Format(time.DateOnly), p.DirtyPrice, p.CleanPrice, p.AccruedAmount)
+		}
+		if w, ok := want[p.Date]; ok && (math.Abs(p.DirtyPrice-w.dirty) > 1e-9 || math.Abs(p.AccruedAmount-w.accrued) > 1e-12) {
+			t.Errorf("%s = dirty %v accrued %v, want %v and %v", p.Date.Format(time.DateOnly), p.DirtyPrice, p.AccruedAmount, w.dirty, w.accrued)
+		}
+
+		// the accrued interest rises every day except when the coupon is crossed
+		if i > 0 {
+			prev := points[i-1]
+			crossed := p.Date.Equal(date(2025, 4, 22))
+			if rose := p.AccruedAmount > prev.AccruedAmount; rose == crossed {
+				t.Errorf("%s accrued %v after %v, want a drop only on the coupon date", p.Date.Format(time.DateOnly), p.AccruedAmount, prev.AccruedAmount)
+			}
+			// the clean price is continuous across the coupon
+			if math.Abs(p.CleanPrice-prev.CleanPrice) > 0.01 {
+				t.Errorf("%s clean %v after %v, want it to move less than 0.01", p.Date.Format(time.DateOnly), p.CleanPrice, prev.CleanPrice)
+			}
+		}
+	}
+
+	// the path ends the day before maturity
+	points, err = PricePath(b, date(2025, 10, 18), date(2025, 11, 30))
+	if err != nil {
+		t.Fatalf("PricePath() error = %v", err)
+	}
+	if len(points) != 4 || !points[3].Date.Equal(date(2025, 10, 21)) {
+		t.Errorf("got %d points to maturity, want 4 ending 2025-10-21", len(points))
+	}
+
+	if _, err := PricePath(b, date(2025, 4, 26), date(2025, 4, 18)); !errors.Is(err, ErrInvalidSettlementDate) {
+		t.Errorf("PricePath() backwards error = %v, want %v", err, ErrInvalidSettlementDate)
+	}
+}
+
+// universe returns n gilts maturing over 50 years, every 100th with an invalid coupon.
+func universe(n int) []*Bond {
+	bonds := make([]*Bond, n)
+	for i := range bonds {
+		maturity := tr25Settlement.AddDate(1+i%50, 1+i%12, 0)
+		b := NewUKGiltWithMaturity("bench", tr25Settlement, 0.5+float64(i%40)*0.125, maturity)
+		b.CleanPrice = 80 + float64(i%30)
+		if i%100 == 0 {
+			b.Coupon = 0.035
+		}
+		bonds[i] = b
+	}
+	return bonds
+}
+
+func TestCompleteBondsParallel(t *testing.T) {
+	sequential, parallel := universe(1_000), universe(1_000)
+
+	seqErrs := CompleteBonds(sequential, DefaultSolverOptions())
+	parErrs := CompleteBondsParallel(parallel, 8, DefaultSolverOptions())
+
+	if len(parErrs) != len(parallel) {
+		t.Fatalf("got %d errors, want %d", len(parErrs), len(parallel))
+	}
+
+	// the errors are aligned with the bonds and the prices match the sequential pricing
+	for i := range parallel {
+		if wantErr := i%100 == 0; wantErr != errors.Is(parErrs[i], ErrInvalidCoupon) || (seqErrs[i] == nil) != (parErrs[i] == nil) {
+			t.Errorf("bond %d error = %v, sequential %v, want invalid coupon %t", i, parErrs[i], seqErrs[i], wantErr)
+		}
+		if *parallel[i] != *sequential[i] {
+			t.Errorf("bond %d = yield %v, want %v", i, parallel[i].YieldToMaturity, sequential[i].YieldToMaturity)
+		}
+	}
+
+	if errs := CompleteBondsParallel(nil, 0, DefaultSolverOptions()); len(errs) != 0 {
+		t.Errorf("CompleteBondsParallel() of no bonds = %v, want none", errs)
+	}
+}
+
+func BenchmarkCompleteBonds(b *testing.B) {
+	for b.Loop() {
+		b.StopTimer()
+		bonds := universe(5_000)
+		b.StartTimer()
+
+		CompleteBonds(bonds, DefaultSolverOptions())
+	}
+}
+
+func BenchmarkCompleteBondsParallel(b *testing.B) {
+	for b.Loop() {
+		b.StopTimer()
+		bonds := universe(5_000)
+		b.StartTimer()
+
+		CompleteBondsParallel(bonds, 0, DefaultSolverOptions())
+	}
+}
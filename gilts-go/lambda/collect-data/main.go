@@ -18,12 +18,28 @@ import (
 var (
 	ENV_BUCKET_NAME   = "GILTS_DATA_BUCKET_NAME"
 	ENV_BUCKET_PREFIX = "GILTS_DATA_BUCKET_PREFIX"
+	ENV_KEY_LAYOUT    = "GILTS_DATA_KEY_LAYOUT"
+	ENV_SSE_KMS_KEY   = "GILTS_DATA_SSE_KMS_KEY_ID"
+	ENV_STORAGE_CLASS = "GILTS_DATA_STORAGE_CLASS"
+	ENV_LOCAL_PATH    = "GILTS_DATA_LOCAL_PATH"
+	ENV_SOURCE        = "GILTS_SOURCE"
 )
 
-func collectData() error {
+// newStore returns the storage target for the collected data, a local directory
+// if GILTS_DATA_LOCAL_PATH is set otherwise the S3 bucket.
+func newStore(ctx context.Context) (collect.Store, error) {
+	layout, err := collect.ParseKeyLayout(os.Getenv(ENV_KEY_LAYOUT))
+	if err != nil {
+		return nil, fmt.Errorf("%s is invalid: %v", ENV_KEY_LAYOUT, err)
+	}
+
+	if localPath := os.Getenv(ENV_LOCAL_PATH); localPath != "" {
+		return collect.NewPathStore(localPath, layout), nil
+	}
+
 	bucketName := os.Getenv(ENV_BUCKET_NAME)
 	if bucketName == "" {
-		return fmt.Errorf("%s is not set", ENV_BUCKET_NAME)
+		return nil, fmt.Errorf("%s is not set", ENV_BUCKET_NAME)
 	}
 
 	bucketPrefix := os.Getenv(ENV_BUCKET_PREFIX)
@@ -33,24 +49,46 @@ func collectData() error {
 		Prefix: bucketPrefix,
 	}
 
-	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
 
-	// collector := collect.NewDataDividendCollector()
-	collector := collect.NewDMOCollector()
+	s3Client := s3.NewFromConfig(cfg)
 
-	collected, err := collector.Collect(ctx, time.Now())
+	return collect.NewS3Store(s3Client, path, layout, collect.StoreOptions{
+		// SQS retries may collect the same day's data multiple times
+		SkipUnchanged: true,
+		SSEKMSKeyID:   os.Getenv(ENV_SSE_KMS_KEY),
+		StorageClass:  os.Getenv(ENV_STORAGE_CLASS),
+	}), nil
+}
+
+func collectData() error {
+	ctx := context.Background()
+
+	store, err := newStore(ctx)
 	if err != nil {
 		return err
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// the DMO is the default source
+	source := os.Getenv(ENV_SOURCE)
+	if source == "" {
+		source = "dmo"
+	}
+
+	collector, err := collect.CollectorByName(source)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %v", err)
+		return fmt.Errorf("%s is invalid: %v", ENV_SOURCE, err)
 	}
 
-	s3Client := s3.NewFromConfig(cfg)
+	collected, err := collector.Collect(ctx, time.Now())
+	if err != nil {
+		return err
+	}
 
-	outPath, err := collect.StoreToS3(ctx, collected, s3Client, path)
+	outPath, err := store.Store(ctx, collected)
 	if err != nil {
 		return err
 	}
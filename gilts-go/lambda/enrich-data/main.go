@@ -0,0 +1,90 @@
+package main
+
+import (
+	"benritz/gilts/internal/collect"
+
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+var (
+	ENV_SSE_KMS_KEY   = "GILTS_DATA_SSE_KMS_KEY_ID"
+	ENV_STORAGE_CLASS = "GILTS_DATA_STORAGE_CLASS"
+)
+
+func enrichObject(ctx context.Context, s3Client collect.S3API, bucket string, stored *collect.StoredKey, key string) error {
+	bonds, err := collect.LoadFromS3(ctx, s3Client, &collect.S3Path{Bucket: bucket, Prefix: key})
+	if err != nil {
+		return err
+	}
+
+	enriched, failures := collect.EnrichBonds(bonds)
+
+	for _, f := range failures {
+		fmt.Printf("Failed to enrich %s %s: %v\n", f.Bond.ISIN, f.Bond.Desc, f.Err)
+	}
+
+	// the enriched bonds are stored alongside the source's file in the same layout
+	store := collect.NewS3Store(s3Client, &collect.S3Path{Bucket: bucket, Prefix: stored.Prefix}, stored.Layout, collect.StoreOptions{
+		// S3 event notifications may be delivered more than once
+		SkipUnchanged: true,
+		SSEKMSKeyID:   os.Getenv(ENV_SSE_KMS_KEY),
+		StorageClass:  os.Getenv(ENV_STORAGE_CLASS),
+	})
+
+	collected := collect.NewCollectedBonds(stored.Source, stored.Date)
+	collected.Bonds = enriched
+
+	outPath, err := store.StoreVariant(ctx, collected, collect.EnrichedVariant)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Stored %d enriched bonds (%d failed) to %s\n", len(enriched), len(failures), outPath)
+
+	return nil
+}
+
+func handler(ctx context.Context, event events.S3Event) error {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	s3Client := s3.NewFromConfig(cfg)
+
+	for _, rec := range event.Records {
+		bucket := rec.S3.Bucket.Name
+
+		// object keys are URL encoded in S3 events
+		key, err := url.QueryUnescape(rec.S3.Object.Key)
+		if err != nil {
+			return fmt.Errorf("invalid object key %s: %v", rec.S3.Object.Key, err)
+		}
+
+		// ignore anything other than the collected bonds, including the enriched files written
+		// by this function and the raw files written with their enriched files by
+		// collect.StoreEnriched
+		stored, err := collect.ParseKey(key)
+		if err != nil || stored.Variant != "" {
+			continue
+		}
+
+		if err := enrichObject(ctx, s3Client, bucket, stored, key); err != nil {
+			return fmt.Errorf("failed to enrich s3://%s/%s: %v", bucket, key, err)
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	lambda.Start(handler)
+}